@@ -0,0 +1,90 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// defaultServer is address of server started by cmd/main.go
+const defaultServer = "http://localhost:8080"
+
+// config is stored in JSON file, every key may be overridden by ENV variable of the same name, which is used by server
+type config struct {
+	// Server is address of server, tokens belong to
+	Server       string `json:"server,omitempty"`
+	User         string `json:"user,omitempty"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+
+	DeeplKey      string `json:"deepl_key,omitempty"`
+	MWDictKey     string `json:"mw_dict_key,omitempty"`
+	MWLearnersKey string `json:"mw_learners_key,omitempty"`
+	MWThKey       string `json:"mw_th_key,omitempty"`
+	// KaikkiDB is database of Wiktionary entries, see kaikki.NewSQLiteStore, it replaces Merriam-Webster
+	KaikkiDB string `json:"kaikki_db,omitempty"`
+}
+
+// configPath returns DICTIONARY_CONFIG, or config.json in user's configuration directory
+func configPath() (string, error) {
+	if path := os.Getenv("DICTIONARY_CONFIG"); len(path) > 0 {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "dictionary", "config.json"), nil
+}
+
+// loadConfig reads config from path, missing file results in empty config
+func loadConfig(path string) (*config, error) {
+	c := &config{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// save writes c to path, only owner can read it, as it contains tokens and keys
+func (c *config) save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// withEnv returns copy of c overridden by non-empty ENV variables, so they are never saved
+func (c config) withEnv() config {
+	for name, field := range map[string]*string{
+		"DICTIONARY_SERVER": &c.Server,
+		"DEEPL_KEY":         &c.DeeplKey,
+		"MW_DICT_KEY":       &c.MWDictKey,
+		"MW_LEARNERS_KEY":   &c.MWLearnersKey,
+		"MW_TH_KEY":         &c.MWThKey,
+		"KAIKKI_DB":         &c.KaikkiDB,
+	} {
+		if v := os.Getenv(name); len(v) > 0 {
+			*field = v
+		}
+	}
+	return c
+}
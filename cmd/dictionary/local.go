@@ -0,0 +1,66 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/internal/kaikki"
+	"github.com/a-clap/dictionary/internal/merriamw/dictionary"
+	"github.com/a-clap/dictionary/internal/merriamw/thesaurus"
+	"github.com/a-clap/dictionary/internal/mymemory"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"net/http"
+	"os"
+)
+
+var errNoDictionary = errors.New("no english dictionary, set KAIKKI_DB or Merriam-Webster keys (MW_DICT_KEY or MW_LEARNERS_KEY, MW_TH_KEY)")
+
+// english returns offline Wiktionary dictionary, if KaikkiDB is set, otherwise Merriam-Webster
+func (a *app) english() (translator.Monolingual, error) {
+	c := a.settings()
+	if len(c.KaikkiDB) > 0 {
+		// NewSQLiteStore would create empty database instead
+		if _, err := os.Stat(c.KaikkiDB); err != nil {
+			return nil, err
+		}
+		s, err := kaikki.NewSQLiteStore(c.KaikkiDB)
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", c.KaikkiDB, err)
+		}
+		a.closers = append(a.closers, s)
+		return translator.NewMerriamWebster(
+			dictionary.NewDictionary(kaikki.NewDefinitioner(s)),
+			thesaurus.NewThesaurus(kaikki.NewThesauruser(s)),
+		), nil
+	}
+
+	if len(c.MWDictKey) == 0 && len(c.MWLearnersKey) == 0 && len(c.MWThKey) == 0 {
+		return nil, errNoDictionary
+	}
+	d := dictionary.NewDefaultGetDefinition(c.MWDictKey, nil)
+	if len(c.MWLearnersKey) > 0 {
+		d = dictionary.NewLearnersGetDefinition(c.MWLearnersKey, nil)
+	}
+	return translator.NewMerriamWebster(dictionary.NewDictionary(d), thesaurus.NewThesaurusDefault(c.MWThKey)), nil
+}
+
+// translator returns translator using DeepL, if key is set, and MyMemory, which doesn't require any
+func (a *app) translator() *translator.Translator {
+	c := a.settings()
+	var providers []translator.Provider
+	if len(c.DeeplKey) > 0 {
+		providers = append(providers, translator.NewDeepLProvider(deepl.NewDeepL(deepl.NewDeeplerDefault(c.DeeplKey, nil))))
+	}
+	providers = append(providers, translator.NewMyMemoryProvider(mymemory.NewMyMemory(mymemory.NewDefault(&http.Client{Timeout: mymemory.DefaultTimeout}, os.Getenv("MYMEMORY_EMAIL")))))
+
+	// Translation is still useful without definitions
+	dictionaries := make(map[translator.Lang]translator.Monolingual)
+	if en, err := a.english(); err == nil {
+		dictionaries["EN"] = en
+	}
+	return translator.NewStandardWithDictionaries(providers, dictionaries, translator.Limits{})
+}
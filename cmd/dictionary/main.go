@@ -0,0 +1,486 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+// Command dictionary looks up words and manages word list from terminal.
+// Lookups call translator, dictionary and thesaurus packages directly with keys from ENV or config,
+// translate may also use running server, after login. Words and reviews are kept by server
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/pkg/client"
+	"github.com/a-clap/dictionary/pkg/review"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const usage = `usage: dictionary <command> [flags] [arguments]
+
+commands:
+  translate [-from LANG] [-to LANG] [-autocorrect] <text>
+  define <text>
+  synonyms <text>
+  login [-server URL] <name>    password is read from DICTIONARY_PASSWORD or stdin
+  logout
+  words add [-from LANG] [-to LANG] <text>
+  words list
+  words rm <id>...
+  review [-limit N]             grade due cards interactively
+  review add <word id>
+  review grade <card id> <grade>
+
+Flags must precede arguments, use -- before text starting with "-".
+
+common flags:
+  -json      print JSON instead of human-readable text
+  -local     translate with local keys, even when logged in
+  -config    path to config file (default DICTIONARY_CONFIG or user's config directory)
+
+Keys are read from DEEPL_KEY, MW_DICT_KEY, MW_LEARNERS_KEY, MW_TH_KEY and KAIKKI_DB, or from config file.
+Without DeepL key, MyMemory is used`
+
+var (
+	errUsage       = errors.New("invalid usage")
+	errNotLoggedIn = errors.New("not logged in, run: dictionary login <name>")
+)
+
+// app runs single command
+type app struct {
+	stdin          *bufio.Reader
+	stdout, stderr io.Writer
+
+	configPath string
+	config     *config
+	json       bool
+	local      bool
+	closers    []io.Closer
+}
+
+func main() {
+	a := &app{stdin: bufio.NewReader(os.Stdin), stdout: os.Stdout, stderr: os.Stderr}
+	err := a.run(context.Background(), os.Args[1:])
+	a.close()
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dictionary:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes command from args, without program name
+func (a *app) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	command, args := args[0], args[1:]
+	if (command == "words" || command == "review") && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = command+" "+args[0], args[1:]
+	}
+
+	commands := map[string]func(context.Context, *flag.FlagSet, []string) error{
+		"translate":    a.translate,
+		"define":       a.define,
+		"synonyms":     a.synonyms,
+		"login":        a.login,
+		"logout":       a.logout,
+		"words add":    a.addWord,
+		"words list":   a.listWords,
+		"words rm":     a.removeWords,
+		"review":       a.review,
+		"review add":   a.addCard,
+		"review grade": a.gradeCard,
+	}
+	cmd, ok := commands[command]
+	if !ok {
+		if command == "help" || command == "-h" || command == "-help" {
+			fmt.Fprintln(a.stdout, usage)
+			return nil
+		}
+		return fmt.Errorf("%w: unknown command %s", errUsage, command)
+	}
+
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.BoolVar(&a.json, "json", false, "print JSON")
+	flags.BoolVar(&a.local, "local", false, "translate with local keys")
+	flags.StringVar(&a.configPath, "config", "", "path to config file")
+	return cmd(ctx, flags, args)
+}
+
+// parse parses flags and loads config, n is exact number of positional arguments, negative means at least -n
+func (a *app) parse(flags *flag.FlagSet, args []string, n int) error {
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	// Parse stops at the first argument, so flag placed after it would silently become part of text
+	if parsed := len(args) - flags.NArg(); parsed == 0 || args[parsed-1] != "--" {
+		for _, arg := range flags.Args() {
+			if strings.HasPrefix(arg, "-") {
+				return fmt.Errorf("%w: %s: flag %s must precede arguments", errUsage, flags.Name(), arg)
+			}
+		}
+	}
+	if (n >= 0 && flags.NArg() != n) || (n < 0 && flags.NArg() < -n) {
+		return fmt.Errorf("%w: %s: unexpected number of arguments", errUsage, flags.Name())
+	}
+
+	if len(a.configPath) == 0 {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		a.configPath = path
+	}
+	c, err := loadConfig(a.configPath)
+	if err != nil {
+		return err
+	}
+	a.config = c
+	return nil
+}
+
+// settings returns config overridden by ENV
+func (a *app) settings() config {
+	return a.config.withEnv()
+}
+
+// client returns client of server with stored tokens, refreshed tokens are saved
+func (a *app) client() (*client.Client, error) {
+	c := a.settings()
+	if len(c.Token) == 0 {
+		return nil, errNotLoggedIn
+	}
+	cl := client.New(c.Server, nil)
+	cl.SetTokens(client.Tokens{Token: c.Token, RefreshToken: c.RefreshToken})
+	cl.OnRefresh(func(t client.Tokens) {
+		a.config.Token, a.config.RefreshToken = t.Token, t.RefreshToken
+		if err := a.config.save(a.configPath); err != nil {
+			fmt.Fprintf(a.stderr, "failed to save refreshed token: %v\n", err)
+		}
+	})
+	return cl, nil
+}
+
+// print prints v as JSON, if requested, otherwise calls human
+func (a *app) print(v interface{}, human func(w io.Writer)) error {
+	if !a.json {
+		human(a.stdout)
+		return nil
+	}
+	e := json.NewEncoder(a.stdout)
+	e.SetIndent("", "  ")
+	return e.Encode(v)
+}
+
+func (a *app) close() {
+	for _, elem := range a.closers {
+		_ = elem.Close()
+	}
+}
+
+func (a *app) translate(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	from := flags.String("from", "", "source language, detected if empty")
+	to := flags.String("to", "EN-GB", "target language")
+	autocorrect := flags.Bool("autocorrect", false, "define the top suggestion of misspelled word")
+	if err := a.parse(flags, args, -1); err != nil {
+		return err
+	}
+	text := strings.Join(flags.Args(), " ")
+
+	src, err := deepl.ParseSourceLang(*from)
+	if err != nil {
+		return err
+	}
+	dst, err := deepl.ParseTargetLang(*to)
+	if err != nil {
+		return err
+	}
+
+	cl, err := a.client()
+	if a.local || err != nil {
+		t, err := a.translator().Get(ctx, text, src, dst, translator.Options{Autocorrect: *autocorrect})
+		if err != nil {
+			return err
+		}
+		return a.print(t, func(w io.Writer) { printTranslation(w, text, t) })
+	}
+
+	t, err := cl.Translate(ctx, text, string(src), string(dst), *autocorrect)
+	if err != nil {
+		return err
+	}
+	return a.print(t, func(w io.Writer) { printTranslation(w, text, t) })
+}
+
+func (a *app) define(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if err := a.parse(flags, args, -1); err != nil {
+		return err
+	}
+	text := strings.Join(flags.Args(), " ")
+	en, err := a.english()
+	if err != nil {
+		return err
+	}
+	d, suggestions, err := en.Define(ctx, text)
+	if err != nil {
+		return err
+	}
+	if len(d.Defs) == 0 {
+		if len(suggestions) > 0 {
+			return fmt.Errorf("%s not found, did you mean: %s", text, strings.Join(suggestions, ", "))
+		}
+		return fmt.Errorf("%s not found", text)
+	}
+	return a.print(d, func(w io.Writer) {
+		fmt.Fprintln(w, text)
+		printDictionary(w, d)
+	})
+}
+
+func (a *app) synonyms(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if err := a.parse(flags, args, -1); err != nil {
+		return err
+	}
+	text := strings.Join(flags.Args(), " ")
+	en, err := a.english()
+	if err != nil {
+		return err
+	}
+	entries, err := en.Thesaurus(ctx, text)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%s not found in thesaurus", text)
+	}
+	return a.print(entries, func(w io.Writer) { printThesaurus(w, entries) })
+}
+
+func (a *app) login(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	server := flags.String("server", "", "address of server (default config, DICTIONARY_SERVER or "+defaultServer+")")
+	if err := a.parse(flags, args, 1); err != nil {
+		return err
+	}
+	name := flags.Arg(0)
+	if len(*server) == 0 {
+		*server = a.settings().Server
+	}
+	if len(*server) == 0 {
+		*server = defaultServer
+	}
+
+	password := os.Getenv("DICTIONARY_PASSWORD")
+	if len(password) == 0 {
+		fmt.Fprint(a.stderr, "password: ")
+		line, err := a.stdin.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	tokens, err := client.New(*server, nil).Login(ctx, name, password)
+	if err != nil {
+		return err
+	}
+	a.config.Server, a.config.User = *server, name
+	a.config.Token, a.config.RefreshToken = tokens.Token, tokens.RefreshToken
+	if err := a.config.save(a.configPath); err != nil {
+		return err
+	}
+	return a.print(map[string]string{"name": name, "server": *server}, func(w io.Writer) {
+		fmt.Fprintf(w, "logged in as %s to %s\n", name, *server)
+	})
+}
+
+func (a *app) logout(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if err := a.parse(flags, args, 0); err != nil {
+		return err
+	}
+	cl, err := a.client()
+	if err != nil {
+		return err
+	}
+	// Token is forgotten even if server refuses it, e.g. it has already expired
+	if err := cl.Logout(ctx); err != nil && !errors.Is(err, client.ErrUnauthorized) {
+		return err
+	}
+	name := a.config.User
+	a.config.User, a.config.Token, a.config.RefreshToken = "", "", ""
+	if err := a.config.save(a.configPath); err != nil {
+		return err
+	}
+	return a.print(map[string]string{"name": name}, func(w io.Writer) {
+		fmt.Fprintf(w, "logged out %s\n", name)
+	})
+}
+
+func (a *app) addWord(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	from := flags.String("from", "", "source language, detected if empty")
+	to := flags.String("to", "EN-GB", "target language")
+	if err := a.parse(flags, args, -1); err != nil {
+		return err
+	}
+	cl, err := a.client()
+	if err != nil {
+		return err
+	}
+	text := strings.Join(flags.Args(), " ")
+	word, err := cl.AddWord(ctx, text, *from, *to)
+	if err != nil {
+		return err
+	}
+	return a.print(word, func(w io.Writer) {
+		fmt.Fprintf(w, "added %s: %s -> %s\n", word.ID, word.Text, translations(word.Translation))
+	})
+}
+
+func (a *app) listWords(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if err := a.parse(flags, args, 0); err != nil {
+		return err
+	}
+	cl, err := a.client()
+	if err != nil {
+		return err
+	}
+	words, err := cl.Words(ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(words, func(w io.Writer) { printWords(w, words) })
+}
+
+func (a *app) removeWords(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if err := a.parse(flags, args, -1); err != nil {
+		return err
+	}
+	cl, err := a.client()
+	if err != nil {
+		return err
+	}
+	for _, id := range flags.Args() {
+		if err := cl.RemoveWord(ctx, id); err != nil {
+			return err
+		}
+	}
+	return a.print(flags.Args(), func(w io.Writer) {
+		fmt.Fprintf(w, "removed %s\n", strings.Join(flags.Args(), ", "))
+	})
+}
+
+func (a *app) addCard(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if err := a.parse(flags, args, 1); err != nil {
+		return err
+	}
+	cl, err := a.client()
+	if err != nil {
+		return err
+	}
+	card, err := cl.AddCard(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return a.print(card, func(w io.Writer) {
+		fmt.Fprintf(w, "added card %s: %s\n", card.ID, card.Text)
+	})
+}
+
+func (a *app) gradeCard(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if err := a.parse(flags, args, 2); err != nil {
+		return err
+	}
+	grade, err := strconv.Atoi(flags.Arg(1))
+	if err != nil {
+		return fmt.Errorf("%w: grade must be number from %d to %d", errUsage, review.MinGrade, review.MaxGrade)
+	}
+	cl, err := a.client()
+	if err != nil {
+		return err
+	}
+	card, err := cl.Grade(ctx, flags.Arg(0), grade)
+	if err != nil {
+		return err
+	}
+	return a.print(card, func(w io.Writer) { printCard(w, card) })
+}
+
+// review asks user to recall translation of every due card and grade it. With -json, due cards are only printed
+func (a *app) review(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	limit := flags.Int("limit", 10, "maximum number of cards")
+	if err := a.parse(flags, args, 0); err != nil {
+		return err
+	}
+	cl, err := a.client()
+	if err != nil {
+		return err
+	}
+	cards, err := cl.Due(ctx, *limit)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(cards, nil)
+	}
+	if len(cards) == 0 {
+		fmt.Fprintln(a.stdout, "nothing to review")
+		return nil
+	}
+
+	for i, card := range cards {
+		fmt.Fprintf(a.stdout, "[%d/%d] %s\n", i+1, len(cards), card.Text)
+		fmt.Fprint(a.stdout, "press Enter to show translation")
+		if _, err := a.stdin.ReadString('\n'); err != nil {
+			return nil
+		}
+		fmt.Fprintln(a.stdout, translations(card.Translation))
+
+		grade, ok := a.readGrade()
+		if !ok {
+			return nil
+		}
+		if grade < 0 {
+			continue
+		}
+		graded, err := cl.Grade(ctx, card.ID, grade)
+		if err != nil {
+			return err
+		}
+		printCard(a.stdout, graded)
+	}
+	return nil
+}
+
+// readGrade asks for grade until valid one is entered. Grade is negative, if user skips card, false means user quits
+func (a *app) readGrade() (int, bool) {
+	for {
+		fmt.Fprintf(a.stdout, "grade %d-%d (Enter skips, q quits): ", review.MinGrade, review.MaxGrade)
+		line, err := a.stdin.ReadString('\n')
+		if err != nil && len(line) == 0 {
+			return 0, false
+		}
+		line = strings.TrimSpace(line)
+		switch line {
+		case "":
+			return -1, true
+		case "q":
+			return 0, false
+		}
+		grade, err := strconv.Atoi(line)
+		if err == nil && grade >= review.MinGrade && grade <= review.MaxGrade {
+			return grade, true
+		}
+	}
+}
@@ -0,0 +1,145 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/a-clap/dictionary/internal/kaikki"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const extract = `{"word": "brain", "pos": "noun", "lang_code": "en", "sounds": [{"ipa": "/bɹeɪn/"}], "senses": [{"glosses": ["The control center of the central nervous system."], "examples": [{"text": "The brain is protected by the skull."}]}, {"glosses": ["Intellect."], "synonyms": [{"word": "mind"}]}]}`
+
+// run runs command with stdin and returns its stdout
+func run(t *testing.T, stdin string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	a := &app{stdin: bufio.NewReader(strings.NewReader(stdin)), stdout: &stdout, stderr: &bytes.Buffer{}}
+	defer a.close()
+	err := a.run(context.Background(), args)
+	return stdout.String(), err
+}
+
+// setup points config to temporary directory and clears ENV, which would override it. Empty variables are ignored
+func setup(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("DICTIONARY_CONFIG", filepath.Join(dir, "config.json"))
+	for _, name := range []string{"DICTIONARY_SERVER", "DICTIONARY_PASSWORD", "DEEPL_KEY", "MW_DICT_KEY", "MW_LEARNERS_KEY", "MW_TH_KEY", "KAIKKI_DB"} {
+		t.Setenv(name, "")
+	}
+	return dir
+}
+
+func TestApp_offline(t *testing.T) {
+	dir := setup(t)
+	_, err := run(t, "", "define", "brain")
+	require.True(t, errors.Is(err, errNoDictionary), err)
+
+	path := filepath.Join(dir, "kaikki.db")
+	s, err := kaikki.NewSQLiteStore(path)
+	require.Nil(t, err)
+	_, err = kaikki.Import(strings.NewReader(extract), s, "en")
+	require.Nil(t, err)
+	require.Nil(t, s.Close())
+	t.Setenv("KAIKKI_DB", path)
+
+	out, err := run(t, "", "define", "brain")
+	require.Nil(t, err)
+	require.Contains(t, out, "noun /bɹeɪn/\n  1. The control center of the central nervous system.\n  2. Intellect.\n")
+	require.Contains(t, out, `"The brain is protected by the skull."`)
+
+	out, err = run(t, "", "synonyms", "-json", "brain")
+	require.Nil(t, err)
+	var entries []struct {
+		Senses []struct {
+			Synonyms []string `json:"synonyms"`
+		} `json:"senses"`
+	}
+	require.Nil(t, json.Unmarshal([]byte(out), &entries))
+	require.Equal(t, []string{"mind"}, entries[0].Senses[0].Synonyms)
+
+	_, err = run(t, "", "define", "cerebrum")
+	require.Contains(t, err.Error(), "cerebrum not found")
+
+	// Flags after text aren't part of it
+	_, err = run(t, "", "synonyms", "brain", "-json")
+	require.True(t, errors.Is(err, errUsage), err)
+	_, err = run(t, "", "define", "--", "-brain")
+	require.Contains(t, err.Error(), "-brain not found")
+}
+
+func TestApp_server(t *testing.T) {
+	setup(t)
+	var graded []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/user/login", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"token": "token", "refresh_token": "refresh"}`))
+	})
+	authorized := func(f http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error": "token expired"}`))
+				return
+			}
+			f(w, r)
+		}
+	}
+	mux.HandleFunc("/api/translate", authorized(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"provider": "deepl", "deepl": [{"text": "mózg"}], "did_you_mean": true, "suggestions": ["brian"]}`))
+	}))
+	mux.HandleFunc("/api/words", authorized(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"id": "w1", "text": "brain", "from": "EN", "to": "PL", "translation": {"deepl": [{"text": "mózg"}]}}]`))
+	}))
+	mux.HandleFunc("/api/review/due", authorized(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"id": "c1", "text": "brain", "translation": {"deepl": [{"text": "mózg"}]}}, {"id": "c2", "text": "mind"}]`))
+	}))
+	mux.HandleFunc("/api/review/", authorized(func(w http.ResponseWriter, r *http.Request) {
+		graded = append(graded, r.URL.Path)
+		_, _ = w.Write([]byte(`{"id": "c1", "text": "brain", "interval": 1}`))
+	}))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	_, err := run(t, "", "words", "list")
+	require.True(t, errors.Is(err, errNotLoggedIn), err)
+
+	out, err := run(t, "pwd\n", "login", "-server", srv.URL, "adam")
+	require.Nil(t, err)
+	require.Equal(t, "logged in as adam to "+srv.URL+"\n", out)
+
+	out, err = run(t, "", "translate", "-from", "EN", "-to", "PL", "brain")
+	require.Nil(t, err)
+	require.Equal(t, "brain -> mózg (deepl)\ndid you mean: brian\n", out)
+
+	out, err = run(t, "", "words", "list")
+	require.Nil(t, err)
+	require.Contains(t, out, "w1  brain  EN-PL  mózg")
+
+	out, err = run(t, "", "words", "list", "-json")
+	require.Nil(t, err)
+	require.Contains(t, out, `"id": "w1"`)
+
+	// First card is graded after showing translation, the second one skipped
+	out, err = run(t, "\n4\n\n\n", "review")
+	require.Nil(t, err)
+	require.Contains(t, out, "[1/2] brain")
+	require.Contains(t, out, "mózg\n")
+	require.Contains(t, out, "brain: next review in 1 day(s)")
+	require.Equal(t, []string{"/api/review/c1"}, graded)
+
+	_, err = run(t, "", "review", "grade", "c1", "x")
+	require.True(t, errors.Is(err, errUsage), err)
+	_, err = run(t, "", "unknown")
+	require.True(t, errors.Is(err, errUsage), err)
+}
@@ -0,0 +1,142 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"github.com/a-clap/dictionary/pkg/review"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/a-clap/dictionary/pkg/wordlist"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// printTranslation prints translations of text, followed by definitions and thesaurus of defined side
+func printTranslation(w io.Writer, text string, t *translator.Translation) {
+	fmt.Fprintf(w, "%s -> %s", text, translations(t))
+	if len(t.Provider) > 0 {
+		fmt.Fprintf(w, " (%s)", t.Provider)
+	}
+	fmt.Fprintln(w)
+
+	var alternatives []string
+	for _, elem := range t.Alternatives {
+		alternatives = append(alternatives, elem.Text)
+	}
+	printList(w, "", "alternatives", alternatives)
+	if t.DidYouMean {
+		printList(w, "", "did you mean", t.Suggestions)
+	}
+	corrected := make([]string, 0, len(t.Corrected))
+	for from := range t.Corrected {
+		corrected = append(corrected, from)
+	}
+	sort.Strings(corrected)
+	for _, from := range corrected {
+		fmt.Fprintf(w, "corrected: %s -> %s\n", from, t.Corrected[from])
+	}
+
+	if t.Dictionary != nil && len(t.Dictionary.Defs) > 0 {
+		fmt.Fprintln(w)
+		printDictionary(w, *t.Dictionary)
+	}
+	if len(t.Thesaurus) > 0 {
+		fmt.Fprintln(w)
+		printThesaurus(w, t.Thesaurus)
+	}
+	for _, elem := range t.Errors {
+		fmt.Fprintf(w, "error: %s %s: %s\n", elem.Source, elem.Text, elem.Error)
+	}
+}
+
+// translations returns every translation of t, joined
+func translations(t *translator.Translation) string {
+	if t == nil || len(t.Deepl) == 0 {
+		return "?"
+	}
+	texts := make([]string, len(t.Deepl))
+	for i, elem := range t.Deepl {
+		texts[i] = elem.Text
+	}
+	return strings.Join(texts, ", ")
+}
+
+// printDictionary prints every definition with its pronunciations and examples
+func printDictionary(w io.Writer, d translator.DictionaryTranslate) {
+	for i, def := range d.Defs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprint(w, def.Function)
+		if def.Offensive {
+			fmt.Fprint(w, " [offensive]")
+		}
+		for _, elem := range def.Audio {
+			if len(elem.PhoneticNotation) > 0 {
+				fmt.Fprintf(w, " %s", elem.PhoneticNotation)
+			}
+		}
+		fmt.Fprintln(w)
+		for j, elem := range def.Definition {
+			fmt.Fprintf(w, "  %d. %s\n", j+1, elem)
+		}
+		for _, elem := range def.Examples {
+			fmt.Fprintf(w, "     \"%s\"\n", elem)
+		}
+		printList(w, "  ", "forms", def.Inflections)
+	}
+	printList(w, "", "see also", d.Synonyms)
+}
+
+// printThesaurus prints every sense of entries with its word lists
+func printThesaurus(w io.Writer, entries []translator.ThesaurusTranslate) {
+	for i, entry := range entries {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s (%s)", entry.Text, entry.Function)
+		if entry.Offensive {
+			fmt.Fprint(w, " [offensive]")
+		}
+		fmt.Fprintln(w)
+		for j, s := range entry.Senses {
+			fmt.Fprintf(w, "  %d. %s\n", j+1, s.Definition)
+			printList(w, "     ", "synonyms", s.Synonyms)
+			printList(w, "     ", "related", s.Related)
+			printList(w, "     ", "phrases", s.Phrases)
+			printList(w, "     ", "near antonyms", s.NearAntonyms)
+			printList(w, "     ", "antonyms", s.Antonyms)
+		}
+	}
+}
+
+// printList prints "name: a, b" line, nothing if list is empty
+func printList(w io.Writer, indent, name string, list []string) {
+	if len(list) > 0 {
+		fmt.Fprintf(w, "%s%s: %s\n", indent, name, strings.Join(list, ", "))
+	}
+}
+
+// printWords prints table of words
+func printWords(w io.Writer, words []wordlist.Word) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTEXT\tLANGS\tTRANSLATION\tADDED")
+	for _, elem := range words {
+		from := string(elem.From)
+		if len(from) == 0 {
+			from = "auto"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s-%s\t%s\t%s\n", elem.ID, elem.Text, from, elem.To, translations(elem.Translation), elem.Created.Format("2006-01-02"))
+	}
+	_ = tw.Flush()
+}
+
+// printCard prints when card is due for the next review
+func printCard(w io.Writer, card *review.Card) {
+	fmt.Fprintf(w, "%s: next review in %d day(s), on %s\n", card.Text, card.Interval, card.Due.Local().Format(time.RFC1123))
+}
@@ -1,11 +1,234 @@
 package main
 
 import (
+	"github.com/a-clap/dictionary/internal/auth"
+	"github.com/a-clap/dictionary/internal/cache"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/internal/kaikki"
+	"github.com/a-clap/dictionary/internal/merriamw/audio"
+	"github.com/a-clap/dictionary/internal/merriamw/dictionary"
+	"github.com/a-clap/dictionary/internal/merriamw/thesaurus"
+	"github.com/a-clap/dictionary/internal/mymemory"
+	"github.com/a-clap/dictionary/pkg/glossary"
+	"github.com/a-clap/dictionary/pkg/quiz"
+	"github.com/a-clap/dictionary/pkg/review"
 	"github.com/a-clap/dictionary/pkg/server"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/a-clap/dictionary/pkg/usage"
+	"github.com/a-clap/dictionary/pkg/wordlist"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
+type handler struct {
+	auth.StoreTokener
+	translator.Translate
+	wordlist.WordStore
+	review.CardStore
+	quiz.Store
+	glossary.GlossaryStore
+	glossary.Client
+	usage.Reporter
+	audio.Opener
+}
+
+func env(name string) string {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		log.Fatalf("%s not found in ENV", name)
+	}
+	return v
+}
+
+// authStore returns sqlite store, if AUTH_DB points to database file, otherwise memory store
+func authStore(key []byte) auth.StoreTokener {
+	path, ok := os.LookupEnv("AUTH_DB")
+	if !ok {
+		return auth.NewMemoryStore(key, time.Hour)
+	}
+	s, err := auth.NewSQLiteStore(path, key, time.Hour)
+	if err != nil {
+		log.Fatalf("failed to open %s: %v", path, err)
+	}
+	return s
+}
+
+// cacheBackend returns disk cache, if CACHE_DIR is set, otherwise in-memory cache
+func cacheBackend() cache.Backend {
+	const ttl = 30 * 24 * time.Hour
+	dir, ok := os.LookupEnv("CACHE_DIR")
+	if !ok {
+		return cache.NewLRU(10000, ttl)
+	}
+	d, err := cache.NewDisk(dir, ttl)
+	if err != nil {
+		log.Fatalf("failed to create cache in %s: %v", dir, err)
+	}
+	return d
+}
+
+// audioCache returns cache of pronunciations in AUDIO_DIR, or in temporary directory if it isn't set
+func audioCache() *audio.Cache {
+	dir, ok := os.LookupEnv("AUDIO_DIR")
+	if !ok {
+		dir = filepath.Join(os.TempDir(), "dictionary-audio")
+	}
+	c, err := audio.NewCache(dir, audio.NewDefaultFetcher(httpClient("MW_TIMEOUT", audio.DefaultTimeout)))
+	if err != nil {
+		log.Fatalf("failed to create audio cache in %s: %v", dir, err)
+	}
+	return c
+}
+
+// httpClient returns client with timeout read from ENV variable name (e.g. "5s"), or fallback if it isn't set
+func httpClient(name string, fallback time.Duration) *http.Client {
+	timeout := fallback
+	if v, ok := os.LookupEnv(name); ok {
+		var err error
+		if timeout, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("invalid %s: %v", name, err)
+		}
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// quota returns number read from ENV variable name, zero (no limit) if it isn't set
+func quota(name string) int64 {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", name, err)
+	}
+	return n
+}
+
+// quotaMode reads DEEPL_QUOTA_MODE, either "fallback" (default) or "refuse"
+func quotaMode() translator.QuotaMode {
+	switch v := os.Getenv("DEEPL_QUOTA_MODE"); v {
+	case "", "fallback":
+		return translator.QuotaFallback
+	case "refuse":
+		return translator.QuotaRefuse
+	default:
+		log.Fatalf("invalid DEEPL_QUOTA_MODE: %s", v)
+		return 0
+	}
+}
+
+// definitioner returns Learner's Dictionary client, if MW_LEARNERS_KEY is set, otherwise collegiate one
+func definitioner() *dictionary.DefaultGetDefinition {
+	client := httpClient("MW_TIMEOUT", dictionary.DefaultTimeout)
+	if key, ok := os.LookupEnv("MW_LEARNERS_KEY"); ok {
+		return dictionary.NewLearnersGetDefinition(key, client)
+	}
+	return dictionary.NewDefaultGetDefinition(env("MW_DICT_KEY"), client)
+}
+
+// english returns Merriam-Webster, unless KAIKKI_DB points to database of Wiktionary entries, which is used offline instead.
+// KAIKKI_IMPORT may name Kaikki JSONL extract, which replaces content of database on start
+func english(c *cache.Cache) translator.Monolingual {
+	path, ok := os.LookupEnv("KAIKKI_DB")
+	if !ok {
+		return translator.NewMerriamWebster(
+			dictionary.NewDictionary(cache.NewDefinitioner(definitioner(), c)),
+			thesaurus.NewThesaurus(cache.NewThesauruser(thesaurus.NewDefaultThesauruser(env("MW_TH_KEY"), httpClient("MW_TIMEOUT", thesaurus.DefaultTimeout)), c)),
+		)
+	}
+	s, err := kaikki.NewSQLiteStore(path)
+	if err != nil {
+		log.Fatalf("failed to open %s: %v", path, err)
+	}
+	if extract, ok := os.LookupEnv("KAIKKI_IMPORT"); ok {
+		f, err := os.Open(extract)
+		if err != nil {
+			log.Fatalf("failed to open %s: %v", extract, err)
+		}
+		n, err := kaikki.Import(f, s, "en")
+		_ = f.Close()
+		if err != nil {
+			log.Fatalf("failed to import %s: %v", extract, err)
+		}
+		log.Printf("imported %d entries from %s", n, extract)
+	}
+	return translator.NewMerriamWebster(
+		dictionary.NewDictionary(kaikki.NewDefinitioner(s)),
+		thesaurus.NewThesaurus(kaikki.NewThesauruser(s)),
+	)
+}
+
+// dictionaries returns english dictionary, together with local dictionaries read from LOCAL_DICTIONARIES,
+// which is comma separated list of language=path pairs, e.g. "PL=pl.json,DE=de.json"
+func dictionaries(c *cache.Cache) map[translator.Lang]translator.Monolingual {
+	d := map[translator.Lang]translator.Monolingual{
+		"EN": english(c),
+	}
+	v, ok := os.LookupEnv("LOCAL_DICTIONARIES")
+	if !ok {
+		return d
+	}
+	for _, pair := range strings.Split(v, ",") {
+		lang, path, ok := strings.Cut(pair, "=")
+		if !ok {
+			log.Fatalf("invalid LOCAL_DICTIONARIES entry: %s", pair)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("failed to open %s: %v", path, err)
+		}
+		local, err := translator.LoadLocal(f)
+		_ = f.Close()
+		if err != nil {
+			log.Fatalf("failed to load %s: %v", path, err)
+		}
+		d[translator.Lang(strings.ToUpper(lang))] = local
+	}
+	return d
+}
+
+// newTranslator returns standard translator falling back to MyMemory, with every upstream response cached.
+// Characters sent to DeepL are counted by tracker, cached translations are not
+func newTranslator(d *deepl.DeeplerDefault, tracker *usage.Tracker) *translator.Translator {
+	c := cache.New(cacheBackend())
+	return translator.NewStandardWithDictionaries(
+		[]translator.Provider{
+			translator.NewDeepLProvider(deepl.NewDeepL(cache.NewDeepler(translator.NewQuotaDeepler(d, tracker, quotaMode()), c))),
+			translator.NewMyMemoryProvider(mymemory.NewMyMemory(cache.NewGetWord(mymemory.NewDefault(httpClient("MYMEMORY_TIMEOUT", mymemory.DefaultTimeout), os.Getenv("MYMEMORY_EMAIL")), c))),
+		},
+		dictionaries(c),
+		translator.Limits{},
+	)
+}
+
 func main() {
+	d := deepl.NewDeeplerDefault(env("DEEPL_KEY"), httpClient("DEEPL_TIMEOUT", deepl.DefaultTimeout))
+	tracker := usage.New(usage.NewMemoryStore(), d, usage.Limits{
+		User:   quota("DEEPL_USER_QUOTA"),
+		Global: quota("DEEPL_GLOBAL_QUOTA"),
+	})
+	h := &handler{
+		StoreTokener:  authStore([]byte(env("JWT_KEY"))),
+		Translate:     newTranslator(d, tracker),
+		WordStore:     wordlist.NewMemoryStore(),
+		CardStore:     review.NewMemoryStore(),
+		Store:         quiz.NewMemoryStore(),
+		GlossaryStore: glossary.NewMemoryStore(),
+		Client:        d,
+		Reporter:      tracker,
+		Opener:        audioCache(),
+	}
 
-	s := server.New(nil)
+	s := server.New(h)
+	// ADMINS is comma separated list of users allowed to access /api/admin
+	if admins, ok := os.LookupEnv("ADMINS"); ok {
+		s.SetAdmins(strings.Split(admins, ",")...)
+	}
 	panic(s.Run(":8080"))
 }
@@ -13,14 +13,18 @@ import (
 )
 
 type Manager struct {
-	i StoreTokener
+	i               StoreTokener
+	refreshDuration time.Duration
 }
 
 type User struct {
 	Name     string `json:"name"`
 	Password string `json:"password"`
 	claims   struct {
-		Name string `json:"name"`
+		Name       string `json:"name"`
+		Generation uint64 `json:"gen"`
+		// Family of refresh tokens issued together with token, empty if there are none
+		Family string `json:"fam,omitempty"`
 		jwt.RegisteredClaims
 	}
 }
@@ -35,6 +39,8 @@ var (
 	ErrInvalidToken       = errors.New("invalid token")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrBlacklisted        = errors.New("user blacklisted - logged out")
+	ErrRevoked            = errors.New("token revoked - logged out everywhere")
+	ErrRefreshReused      = errors.New("refresh token already used - token family revoked")
 )
 
 type (
@@ -49,12 +55,26 @@ type (
 		NameExists(name string) (bool, error)
 		// Remove user with provided name, if user doesn't exist, don't do anything
 		Remove(name string) error
-		// AddToken adds token to token blacklists
-		AddToken(token string) error
+		// AddToken adds token to token blacklists. Token is needed only until expires, after that it should be forgotten
+		AddToken(token string, expires time.Time) error
 		// TokenExists checks whether token exists in blacklist
 		TokenExists(token string) (bool, error)
 		// RemoveToken removes token from blacklist
 		RemoveToken(token string) error
+		// Generation returns tokens generation of user, 0 if it was never set
+		Generation(name string) (uint64, error)
+		// SetGeneration sets tokens generation of user, tokens with other generation are not valid anymore
+		SetGeneration(name string, generation uint64) error
+		// SaveRefresh saves refresh token. Overwrites, if token with the same ID already exists.
+		// Token is needed only until its Expires, after that it should be forgotten
+		SaveRefresh(token RefreshToken) error
+		// LoadRefresh returns refresh token with provided ID, nil if it doesn't exist
+		LoadRefresh(id string) (*RefreshToken, error)
+		// UseRefresh marks refresh token with provided ID as Used, if it isn't yet. Check and update must be atomic,
+		// false means token doesn't exist or was already used
+		UseRefresh(id string) (bool, error)
+		// RemoveFamily removes every refresh token from family
+		RemoveFamily(family string) error
 	}
 
 	// Tokener realizes access to:
@@ -73,7 +93,10 @@ type (
 
 // New is default constructor for Manager
 func New(storeTokener StoreTokener) *Manager {
-	return &Manager{i: storeTokener}
+	return &Manager{
+		i:               storeTokener,
+		refreshDuration: DefaultRefreshDuration,
+	}
 }
 
 // Add adds user to base
@@ -131,17 +154,43 @@ func (m *Manager) Auth(user User) (bool, error) {
 	}
 }
 
+// Logout blacklists token, it won't be valid anymore. Refresh tokens issued together with token are revoked too
 func (m *Manager) Logout(token string) (*User, error) {
 	user, err := m.ValidateToken(token)
 	if err != nil {
 		return nil, err
 	}
 
-	err = m.addToken(token)
+	var expires time.Time
+	if user.claims.ExpiresAt != nil {
+		expires = user.claims.ExpiresAt.Time
+	}
+
+	err = m.addToken(token, expires)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(user.claims.Family) > 0 {
+		if err := m.removeFamily(user.claims.Family); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// LogoutAll invalidates every token issued to user before now, including passed token
+func (m *Manager) LogoutAll(token string) (*User, error) {
+	user, err := m.ValidateToken(token)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := m.setGeneration(user.Name, user.claims.Generation+1); err != nil {
+		return nil, err
+	}
+
 	return user, nil
 }
 
@@ -153,10 +202,24 @@ func (m *Manager) Token(user User) (string, error) {
 		return "", ErrInvalidCredentials
 	}
 
-	expires := time.Now().Add(m.i.Duration())
-	user.claims.Name = user.Name
+	generation, err := m.generation(user.Name)
+	if err != nil {
+		return "", err
+	}
+
+	return m.token(user.Name, "", generation)
+}
+
+// token returns signed jwtToken for user, without checking credentials. Family is family of refresh tokens issued with it
+func (m *Manager) token(name, family string, generation uint64) (string, error) {
+	var user User
+	now := time.Now()
+	user.claims.Name = name
+	user.claims.Generation = generation
+	user.claims.Family = family
 	user.claims.RegisteredClaims = jwt.RegisteredClaims{
-		ExpiresAt: &jwt.NumericDate{Time: expires},
+		ExpiresAt: jwt.NewNumericDate(now.Add(m.i.Duration())),
+		IssuedAt:  jwt.NewNumericDate(now),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, user.claims)
@@ -189,6 +252,12 @@ func (m *Manager) ValidateToken(token string) (*User, error) {
 		return nil, ErrBlacklisted
 	}
 
+	if generation, err := m.generation(user.claims.Name); err != nil {
+		return nil, err
+	} else if generation != user.claims.Generation {
+		return nil, ErrRevoked
+	}
+
 	user.Name = user.claims.Name
 
 	return &user, nil
@@ -229,9 +298,9 @@ func (m *Manager) remove(name string) error {
 	return err
 }
 
-// AddToken adds token to token blacklists
-func (m *Manager) addToken(token string) error {
-	err := m.i.AddToken(token)
+// addToken adds token to token blacklists
+func (m *Manager) addToken(token string, expires time.Time) error {
+	err := m.i.AddToken(token, expires)
 	if err != nil {
 		return fmt.Errorf("%w: AddToken: %s, error: %v", ErrIO, token, err)
 	}
@@ -251,3 +320,20 @@ func (m *Manager) removeToken(token string) error {
 	}
 	return nil
 }
+
+// generation is wrapper for interface call Generation, returns appropriate wrapped error
+func (m *Manager) generation(name string) (uint64, error) {
+	generation, err := m.i.Generation(name)
+	if err != nil {
+		return 0, fmt.Errorf("%w: Generation: %s, error: %v", ErrIO, name, err)
+	}
+	return generation, nil
+}
+
+// setGeneration is wrapper for interface call SetGeneration, returns appropriate wrapped error
+func (m *Manager) setGeneration(name string, generation uint64) error {
+	if err := m.i.SetGeneration(name, generation); err != nil {
+		return fmt.Errorf("%w: SetGeneration: %s, error: %v", ErrIO, name, err)
+	}
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 )
@@ -20,11 +22,11 @@ type MemoryStoreError struct {
 	returnErr bool
 }
 
-func (m *MemoryStoreError) AddToken(token string) error {
+func (m *MemoryStoreError) AddToken(token string, expires time.Time) error {
 	if m.returnErr {
 		return fmt.Errorf("io err")
 	}
-	return m.store.AddToken(token)
+	return m.store.AddToken(token, expires)
 }
 
 func (m *MemoryStoreError) TokenExists(token string) (bool, error) {
@@ -41,6 +43,48 @@ func (m *MemoryStoreError) RemoveToken(token string) error {
 	return m.store.RemoveToken(token)
 }
 
+func (m *MemoryStoreError) Generation(name string) (uint64, error) {
+	if m.returnErr {
+		return 0, fmt.Errorf("io err")
+	}
+	return m.store.Generation(name)
+}
+
+func (m *MemoryStoreError) SetGeneration(name string, generation uint64) error {
+	if m.returnErr {
+		return fmt.Errorf("io err")
+	}
+	return m.store.SetGeneration(name, generation)
+}
+
+func (m *MemoryStoreError) SaveRefresh(token RefreshToken) error {
+	if m.returnErr {
+		return fmt.Errorf("io err")
+	}
+	return m.store.SaveRefresh(token)
+}
+
+func (m *MemoryStoreError) LoadRefresh(id string) (*RefreshToken, error) {
+	if m.returnErr {
+		return nil, fmt.Errorf("io err")
+	}
+	return m.store.LoadRefresh(id)
+}
+
+func (m *MemoryStoreError) UseRefresh(id string) (bool, error) {
+	if m.returnErr {
+		return false, fmt.Errorf("io err")
+	}
+	return m.store.UseRefresh(id)
+}
+
+func (m *MemoryStoreError) RemoveFamily(family string) error {
+	if m.returnErr {
+		return fmt.Errorf("io err")
+	}
+	return m.store.RemoveFamily(family)
+}
+
 func (m *MemoryStoreError) Key() []byte {
 	return []byte("super key")
 }
@@ -77,662 +121,856 @@ func (m *MemoryStoreError) Remove(name string) error {
 	return m.store.Remove(name)
 }
 
-func TestUsers_Add(t *testing.T) {
-	// Table driven tests
-	type fields struct {
-		storeTokener StoreTokener
+// storeFactory creates StoreTokener already filled with users data
+type storeFactory func(t *testing.T, key []byte, duration time.Duration, users map[string][]byte) StoreTokener
+
+func newMemoryStore(t *testing.T, key []byte, duration time.Duration, users map[string][]byte) StoreTokener {
+	m := NewMemoryStore(key, duration)
+	for name, data := range users {
+		require.Nil(t, m.Save(name, data))
 	}
-	type args struct {
-		user    User
-		err     bool
-		errType error
+	return m
+}
+
+func newSQLiteStore(t *testing.T, key []byte, duration time.Duration, users map[string][]byte) StoreTokener {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "auth.db"), key, duration)
+	require.Nil(t, err)
+	t.Cleanup(func() {
+		require.Nil(t, s.Close())
+	})
+	for name, data := range users {
+		require.Nil(t, s.Save(name, data))
 	}
-	tests := []struct {
-		name   string
-		fields fields
-		io     []args
+	return s
+}
+
+// forEachStore runs f against every StoreTokener implementation
+func forEachStore(t *testing.T, f func(t *testing.T, newStore storeFactory)) {
+	stores := []struct {
+		name    string
+		factory storeFactory
 	}{
-		{
-			name:   "add single user",
-			fields: fields{storeTokener: &MemoryStore{store: map[string][]byte{}}},
-			io: []args{{
-				user: User{
-					Name:     "adam",
-					Password: "password",
-				},
-				err:     false,
-				errType: nil,
-			}},
-		},
-		{
-			name: "add already existing user twice",
-			fields: fields{storeTokener: &MemoryStore{store: map[string][]byte{
-				"adam": []byte("password"),
-			}}},
-			io: []args{
-				{
+		{name: "memory", factory: newMemoryStore},
+		{name: "sqlite", factory: newSQLiteStore},
+	}
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			f(t, store.factory)
+		})
+	}
+}
+
+func TestUsers_Add(t *testing.T) {
+	forEachStore(t, func(t *testing.T, newStore storeFactory) {
+		// Table driven tests
+		type fields struct {
+			storeTokener StoreTokener
+		}
+		type args struct {
+			user    User
+			err     bool
+			errType error
+		}
+		tests := []struct {
+			name   string
+			fields fields
+			io     []args
+		}{
+			{
+				name:   "add single user",
+				fields: fields{storeTokener: newStore(t, []byte("key"), time.Hour, nil)},
+				io: []args{{
 					user: User{
 						Name:     "adam",
 						Password: "password",
 					},
-					err:     true,
-					errType: ErrExist,
+					err:     false,
+					errType: nil,
+				}},
+			},
+			{
+				name: "add already existing user twice",
+				fields: fields{storeTokener: newStore(t, []byte("key"), time.Hour, map[string][]byte{
+					"adam": []byte("password"),
+				})},
+				io: []args{
+					{
+						user: User{
+							Name:     "adam",
+							Password: "password",
+						},
+						err:     true,
+						errType: ErrExist,
+					},
 				},
 			},
-		},
-		{
-			name:   "invalid argument: password",
-			fields: fields{storeTokener: &MemoryStore{store: map[string][]byte{}}},
-			io: []args{
-				{
-					user: User{
-						Name:     "adam",
-						Password: "",
+			{
+				name:   "invalid argument: password",
+				fields: fields{storeTokener: newStore(t, []byte("key"), time.Hour, nil)},
+				io: []args{
+					{
+						user: User{
+							Name:     "adam",
+							Password: "",
+						},
+						err:     true,
+						errType: ErrInvalid,
 					},
-					err:     true,
-					errType: ErrInvalid,
 				},
 			},
-		},
-		{
-			name:   "invalid argument: name",
-			fields: fields{storeTokener: &MemoryStore{store: map[string][]byte{}}},
-			io: []args{
-				{
-					user: User{
-						Name:     "",
-						Password: "1",
+			{
+				name:   "invalid argument: name",
+				fields: fields{storeTokener: newStore(t, []byte("key"), time.Hour, nil)},
+				io: []args{
+					{
+						user: User{
+							Name:     "",
+							Password: "1",
+						},
+						err:     true,
+						errType: ErrInvalid,
 					},
-					err:     true,
-					errType: ErrInvalid,
 				},
 			},
-		},
-		{
-			name:   "invalid argument: pass and name",
-			fields: fields{storeTokener: &MemoryStore{store: map[string][]byte{}}},
-			io: []args{
-				{
-					user: User{
-						Name:     "",
-						Password: "",
+			{
+				name:   "invalid argument: pass and name",
+				fields: fields{storeTokener: newStore(t, []byte("key"), time.Hour, nil)},
+				io: []args{
+					{
+						user: User{
+							Name:     "",
+							Password: "",
+						},
+						err:     true,
+						errType: ErrInvalid,
 					},
-					err:     true,
-					errType: ErrInvalid,
 				},
 			},
-		},
-		{
-			name:   "handle internal IO error",
-			fields: fields{&MemoryStoreError{store: NewMemoryStore([]byte("key"), time.Hour), returnErr: true}},
-			io: []args{
-				{
-					user: User{
-						Name:     "adam",
-						Password: "password",
+			{
+				name:   "handle internal IO error",
+				fields: fields{&MemoryStoreError{store: NewMemoryStore([]byte("key"), time.Hour), returnErr: true}},
+				io: []args{
+					{
+						user: User{
+							Name:     "adam",
+							Password: "password",
+						},
+						err:     true,
+						errType: ErrIO,
 					},
-					err:     true,
-					errType: ErrIO,
 				},
 			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			u := New(tt.fields.storeTokener)
-			for _, v := range tt.io {
-				err := u.Add(v.user)
-				if (err != nil) != v.err {
-					t.Fatalf("%s: Add() error = %v, wantErr %v", tt.name, err, v.err)
-				}
-				// Check error type, if error needed
-				if v.err {
-					if !errors.Is(err, v.errType) {
-						t.Errorf("%s: Add() error = %v, errType %v", tt.name, err, v.errType)
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				u := New(tt.fields.storeTokener)
+				for _, v := range tt.io {
+					err := u.Add(v.user)
+					if (err != nil) != v.err {
+						t.Fatalf("%s: Add() error = %v, wantErr %v", tt.name, err, v.err)
+					}
+					// Check error type, if error needed
+					if v.err {
+						if !errors.Is(err, v.errType) {
+							t.Errorf("%s: Add() error = %v, errType %v", tt.name, err, v.errType)
+						}
 					}
 				}
-			}
-		})
-	}
+			})
+		}
 
-	// Custom tests
-	t.Run("add doesn't store passwords directly", func(t *testing.T) {
-		mock := &MemoryStore{store: map[string][]byte{}}
-		u := New(mock)
+		// Custom tests
+		t.Run("add doesn't store passwords directly", func(t *testing.T) {
+			mock := newStore(t, []byte("key"), time.Hour, nil)
+			u := New(mock)
 
-		user := User{
-			Name:     "adam",
-			Password: "some crazy password",
-		}
+			user := User{
+				Name:     "adam",
+				Password: "some crazy password",
+			}
 
-		err := u.Add(user)
-		if err != nil {
-			t.Errorf("%s: Add() error %v unexpected", t.Name(), err)
-		}
-		// Naive compare
-		if string(mock.store[user.Name]) == user.Password {
-			t.Errorf("%s: Add() saves plain password", t.Name())
-		}
+			err := u.Add(user)
+			if err != nil {
+				t.Errorf("%s: Add() error %v unexpected", t.Name(), err)
+			}
+			// Naive compare
+			data, err := mock.Load(user.Name)
+			require.Nil(t, err)
+			if string(data) == user.Password {
+				t.Errorf("%s: Add() saves plain password", t.Name())
+			}
+		})
 	})
 }
 
 func TestUsers_Remove(t *testing.T) {
-	type fields struct {
-		Store StoreTokener
-	}
-	type io struct {
-		user    User
-		err     bool
-		errType error
-	}
-	tests := []struct {
-		name   string
-		fields fields
-		args   []io
-	}{
-		{
-			name: "handle io error",
-			fields: fields{&MemoryStoreError{
-				store:     NewMemoryStore([]byte("key"), time.Hour),
-				returnErr: true,
-			}},
-			args: []io{
-				{
-					user: User{
-						Name: "adam",
+	forEachStore(t, func(t *testing.T, newStore storeFactory) {
+		type fields struct {
+			Store StoreTokener
+		}
+		type io struct {
+			user    User
+			err     bool
+			errType error
+		}
+		tests := []struct {
+			name   string
+			fields fields
+			args   []io
+		}{
+			{
+				name: "handle io error",
+				fields: fields{&MemoryStoreError{
+					store:     NewMemoryStore([]byte("key"), time.Hour),
+					returnErr: true,
+				}},
+				args: []io{
+					{
+						user: User{
+							Name: "adam",
+						},
+						err:     true,
+						errType: ErrIO,
 					},
-					err:     true,
-					errType: ErrIO,
 				},
 			},
-		},
-		{
-			name:   "can't remove not existing user",
-			fields: fields{Store: &MemoryStore{store: map[string][]byte{}}},
-			args: []io{
-				{
-					user: User{
-						Name: "not exists",
+			{
+				name:   "can't remove not existing user",
+				fields: fields{Store: newStore(t, []byte("key"), time.Hour, nil)},
+				args: []io{
+					{
+						user: User{
+							Name: "not exists",
+						},
+						err:     true,
+						errType: ErrNotExist,
 					},
-					err:     true,
-					errType: ErrNotExist,
 				},
 			},
-		},
-		{
-			name: "remove existing user",
-			fields: fields{Store: &MemoryStore{store: map[string][]byte{
-				"adam": []byte("pwd"),
-			}}},
-			args: []io{},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			u := New(tt.fields.Store)
-			for _, v := range tt.args {
-				err := u.Remove(v.user)
-				if (err != nil) != v.err {
-					t.Fatalf("%s: Remove() error = %v, wantErr %v", tt.name, err, v.err)
-				}
-				// Check error type, if error needed
-				if v.err {
-					if !errors.Is(err, v.errType) {
-						t.Errorf("%s: Add() error = %v, errType %v", tt.name, err, v.errType)
+			{
+				name: "remove existing user",
+				fields: fields{Store: newStore(t, []byte("key"), time.Hour, map[string][]byte{
+					"adam": []byte("pwd"),
+				})},
+				args: []io{},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				u := New(tt.fields.Store)
+				for _, v := range tt.args {
+					err := u.Remove(v.user)
+					if (err != nil) != v.err {
+						t.Fatalf("%s: Remove() error = %v, wantErr %v", tt.name, err, v.err)
+					}
+					// Check error type, if error needed
+					if v.err {
+						if !errors.Is(err, v.errType) {
+							t.Errorf("%s: Add() error = %v, errType %v", tt.name, err, v.errType)
+						}
 					}
-				}
 
-			}
-		})
-	}
+				}
+			})
+		}
+	})
 }
 
 func TestUsers_Auth(t *testing.T) {
-	type fields struct {
-		storeTokener StoreTokener
-	}
-	type io struct {
-		user    User
-		auth    bool
-		err     bool
-		errType error
-	}
-	tests := []struct {
-		name   string
-		fields fields
-		args   []io
-	}{
-		{
-			name: "handle io error",
-			fields: fields{storeTokener: &MemoryStoreError{
-				returnErr: true,
-			}},
-			args: []io{
-				{
-					user: User{
-						Name:     "dont matter",
-						Password: "also",
+	forEachStore(t, func(t *testing.T, newStore storeFactory) {
+		type fields struct {
+			storeTokener StoreTokener
+		}
+		type io struct {
+			user    User
+			auth    bool
+			err     bool
+			errType error
+		}
+		tests := []struct {
+			name   string
+			fields fields
+			args   []io
+		}{
+			{
+				name: "handle io error",
+				fields: fields{storeTokener: &MemoryStoreError{
+					returnErr: true,
+				}},
+				args: []io{
+					{
+						user: User{
+							Name:     "dont matter",
+							Password: "also",
+						},
+						auth:    false,
+						err:     true,
+						errType: ErrIO,
 					},
-					auth:    false,
-					err:     true,
-					errType: ErrIO,
 				},
 			},
-		},
-		{
-			name: "unauthorized access",
-			fields: fields{storeTokener: &MemoryStore{
-				store: map[string][]byte{
+			{
+				name: "unauthorized access",
+				fields: fields{storeTokener: newStore(t, []byte("key"), time.Hour, map[string][]byte{
 					"adam": []byte("correct_pwd_but_not_hashed"),
 					"beta": []byte("wrong_pwd"),
-				},
-			}},
-			args: []io{
-				{
-					user: User{
-						Name:     "adam",
-						Password: "correct_pwd_but_not_hashed",
+				})},
+				args: []io{
+					{
+						user: User{
+							Name:     "adam",
+							Password: "correct_pwd_but_not_hashed",
+						},
+						auth: false,
+						err:  false,
 					},
-					auth: false,
-					err:  false,
-				},
-				{
-					user: User{
-						Name:     "beta",
-						Password: "other",
+					{
+						user: User{
+							Name:     "beta",
+							Password: "other",
+						},
+						auth: false,
+						err:  false,
 					},
-					auth: false,
-					err:  false,
 				},
 			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			u := New(tt.fields.storeTokener)
-
-			for _, args := range tt.args {
-				auth, err := u.Auth(args.user)
-				if (err != nil) != args.err {
-					t.Errorf("%s: Auth() error = %#v, wantErr %v", tt.name, err, args.err)
-				}
-				// Check error type, if error needed
-				if args.err {
-					if !errors.Is(err, args.errType) {
-						t.Errorf("%s: Add() error = %v, errType %v", tt.name, err, args.errType)
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				u := New(tt.fields.storeTokener)
+
+				for _, args := range tt.args {
+					auth, err := u.Auth(args.user)
+					if (err != nil) != args.err {
+						t.Errorf("%s: Auth() error = %#v, wantErr %v", tt.name, err, args.err)
+					}
+					// Check error type, if error needed
+					if args.err {
+						if !errors.Is(err, args.errType) {
+							t.Errorf("%s: Add() error = %v, errType %v", tt.name, err, args.errType)
+						}
+					}
+					if auth != args.auth {
+						t.Errorf("%s: Auth() got %v, want %v", tt.name, auth, args.auth)
 					}
 				}
-				if auth != args.auth {
-					t.Errorf("%s: Auth() got %v, want %v", tt.name, auth, args.auth)
-				}
-			}
-
-		})
-	}
 
-	t.Run("authorized access", func(t *testing.T) {
-		//	Custom test - add user and then check authorized access
-		m := &MemoryStore{
-			store: map[string][]byte{},
+			})
 		}
-		u := New(m)
 
-		user := User{
-			Name:     "testing",
-			Password: "awesome password",
-		}
+		t.Run("authorized access", func(t *testing.T) {
+			//	Custom test - add user and then check authorized access
+			m := newStore(t, []byte("key"), time.Hour, nil)
+			u := New(m)
 
-		if err := u.Add(user); err != nil {
-			t.Errorf("%s: Add() unexpected error %#v", t.Name(), err)
-		}
+			user := User{
+				Name:     "testing",
+				Password: "awesome password",
+			}
 
-		if auth, err := u.Auth(user); err != nil {
-			t.Errorf("%s: Auth() unexpected error %#v", t.Name(), err)
-		} else if !auth {
-			t.Errorf("%s: Auth() expected to authorize user %v", t.Name(), user.Name)
-		}
+			if err := u.Add(user); err != nil {
+				t.Errorf("%s: Add() unexpected error %#v", t.Name(), err)
+			}
+
+			if auth, err := u.Auth(user); err != nil {
+				t.Errorf("%s: Auth() unexpected error %#v", t.Name(), err)
+			} else if !auth {
+				t.Errorf("%s: Auth() expected to authorize user %v", t.Name(), user.Name)
+			}
+		})
 	})
 }
 
 func TestManager_TokenValidateToken(t *testing.T) {
-	type fields struct {
-		i StoreTokener
-	}
-	type args struct {
-		add           User
-		token         User
-		messWithToken bool
-		newToken      string
-	}
-	type wants struct {
-		addErr          bool
-		addErrType      error
-		tokenErr        bool
-		tokenErrType    error
-		validateErr     bool
-		validateErrType error
-		validate        User
-	}
-	tests := []struct {
-		name   string
-		fields fields
-		args   args
-		wants  wants
-	}{
-		{
-			name: "the right path",
-			fields: fields{
-				i: NewMemoryStore([]byte("key"), time.Hour),
-			},
-			args: args{
-				add: User{
-					Name:     "adam",
-					Password: "pwd",
+	forEachStore(t, func(t *testing.T, newStore storeFactory) {
+		type fields struct {
+			i StoreTokener
+		}
+		type args struct {
+			add           User
+			token         User
+			messWithToken bool
+			newToken      string
+		}
+		type wants struct {
+			addErr          bool
+			addErrType      error
+			tokenErr        bool
+			tokenErrType    error
+			validateErr     bool
+			validateErrType error
+			validate        User
+		}
+		tests := []struct {
+			name   string
+			fields fields
+			args   args
+			wants  wants
+		}{
+			{
+				name: "the right path",
+				fields: fields{
+					i: newStore(t, []byte("key"), time.Hour, nil),
 				},
-				token: User{
-					Name:     "adam",
-					Password: "pwd",
+				args: args{
+					add: User{
+						Name:     "adam",
+						Password: "pwd",
+					},
+					token: User{
+						Name:     "adam",
+						Password: "pwd",
+					},
 				},
-			},
-			wants: wants{
-				addErr:          false,
-				addErrType:      nil,
-				tokenErr:        false,
-				tokenErrType:    nil,
-				validateErr:     false,
-				validateErrType: nil,
-				validate: User{
-					Name: "adam",
+				wants: wants{
+					addErr:          false,
+					addErrType:      nil,
+					tokenErr:        false,
+					tokenErrType:    nil,
+					validateErr:     false,
+					validateErrType: nil,
+					validate: User{
+						Name: "adam",
+					},
 				},
 			},
-		},
-		{
-			name: "token expired",
-			fields: fields{
-				i: NewMemoryStore([]byte("key"), time.Microsecond),
-			},
-			args: args{
-				add: User{
-					Name:     "adam",
-					Password: "pwd",
+			{
+				name: "token expired",
+				fields: fields{
+					i: newStore(t, []byte("key"), time.Microsecond, nil),
 				},
-				token: User{
-					Name:     "adam",
-					Password: "pwd",
+				args: args{
+					add: User{
+						Name:     "adam",
+						Password: "pwd",
+					},
+					token: User{
+						Name:     "adam",
+						Password: "pwd",
+					},
+				},
+				wants: wants{
+					addErr:          false,
+					addErrType:      nil,
+					tokenErr:        false,
+					tokenErrType:    nil,
+					validateErr:     true,
+					validateErrType: ErrExpired,
+					validate:        User{},
 				},
 			},
-			wants: wants{
-				addErr:          false,
-				addErrType:      nil,
-				tokenErr:        false,
-				tokenErrType:    nil,
-				validateErr:     true,
-				validateErrType: ErrExpired,
-				validate:        User{},
-			},
-		},
-		{
-			name: "not existing user",
-			fields: fields{
-				i: NewMemoryStore([]byte("key"), time.Hour),
-			},
-			args: args{
-				add: User{
-					Name:     "adam",
-					Password: "pwd",
+			{
+				name: "not existing user",
+				fields: fields{
+					i: newStore(t, []byte("key"), time.Hour, nil),
+				},
+				args: args{
+					add: User{
+						Name:     "adam",
+						Password: "pwd",
+					},
+					token: User{
+						Name: "hehe",
+					},
 				},
-				token: User{
-					Name: "hehe",
+				wants: wants{
+					addErr:          false,
+					addErrType:      nil,
+					tokenErr:        true,
+					tokenErrType:    ErrNotExist,
+					validateErr:     false,
+					validateErrType: nil,
+					validate:        User{},
 				},
 			},
-			wants: wants{
-				addErr:          false,
-				addErrType:      nil,
-				tokenErr:        true,
-				tokenErrType:    ErrNotExist,
-				validateErr:     false,
-				validateErrType: nil,
-				validate:        User{},
-			},
-		},
-		{
-			name: "invalid credentials user",
-			fields: fields{
-				i: NewMemoryStore([]byte("key"), time.Hour),
-			},
-			args: args{
-				add: User{
-					Name:     "adam",
-					Password: "pwd",
+			{
+				name: "invalid credentials user",
+				fields: fields{
+					i: newStore(t, []byte("key"), time.Hour, nil),
 				},
-				token: User{
-					Name:     "adam",
-					Password: "pwd2",
+				args: args{
+					add: User{
+						Name:     "adam",
+						Password: "pwd",
+					},
+					token: User{
+						Name:     "adam",
+						Password: "pwd2",
+					},
+				},
+				wants: wants{
+					addErr:          false,
+					addErrType:      nil,
+					tokenErr:        true,
+					tokenErrType:    ErrInvalidCredentials,
+					validateErr:     false,
+					validateErrType: nil,
+					validate:        User{},
 				},
 			},
-			wants: wants{
-				addErr:          false,
-				addErrType:      nil,
-				tokenErr:        true,
-				tokenErrType:    ErrInvalidCredentials,
-				validateErr:     false,
-				validateErrType: nil,
-				validate:        User{},
-			},
-		},
-		{
-			name: "mess with token",
-			fields: fields{
-				i: NewMemoryStore([]byte("key"), time.Hour),
-			},
-			args: args{
-				add: User{
-					Name:     "adam",
-					Password: "pwd",
+			{
+				name: "mess with token",
+				fields: fields{
+					i: newStore(t, []byte("key"), time.Hour, nil),
 				},
-				token: User{
-					Name:     "adam",
-					Password: "pwd",
+				args: args{
+					add: User{
+						Name:     "adam",
+						Password: "pwd",
+					},
+					token: User{
+						Name:     "adam",
+						Password: "pwd",
+					},
+					messWithToken: true,
+					newToken:      "blabla",
+				},
+				wants: wants{
+					addErr:          false,
+					addErrType:      nil,
+					tokenErr:        false,
+					tokenErrType:    nil,
+					validateErr:     true,
+					validateErrType: jwt.NewValidationError("token contains an invalid number of segments", jwt.ValidationErrorMalformed),
+					validate:        User{},
 				},
-				messWithToken: true,
-				newToken:      "blabla",
-			},
-			wants: wants{
-				addErr:          false,
-				addErrType:      nil,
-				tokenErr:        false,
-				tokenErrType:    nil,
-				validateErr:     true,
-				validateErrType: jwt.NewValidationError("token contains an invalid number of segments", jwt.ValidationErrorMalformed),
-				validate:        User{},
 			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			u := New(tt.fields.i)
-
-			// First, need to add user
-			err := u.Add(tt.args.add)
-			if tt.wants.addErr {
-				require.NotNil(t, err)
-				require.Equal(t, err, tt.wants.addErrType)
-				return
-			}
-			require.Nil(t, err)
-
-			// Then generate token
-			got, err := u.Token(tt.args.token)
-			if tt.wants.tokenErr {
-				require.NotNil(t, err)
-				require.True(t, errors.Is(err, tt.wants.tokenErrType))
-				return
-			}
-			require.Nil(t, err)
-			require.NotEmpty(t, got)
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				u := New(tt.fields.i)
+
+				// First, need to add user
+				err := u.Add(tt.args.add)
+				if tt.wants.addErr {
+					require.NotNil(t, err)
+					require.Equal(t, err, tt.wants.addErrType)
+					return
+				}
+				require.Nil(t, err)
+
+				// Then generate token
+				got, err := u.Token(tt.args.token)
+				if tt.wants.tokenErr {
+					require.NotNil(t, err)
+					require.True(t, errors.Is(err, tt.wants.tokenErrType))
+					return
+				}
+				require.Nil(t, err)
+				require.NotEmpty(t, got)
 
-			if tt.args.messWithToken {
-				got = tt.args.newToken
-			}
+				if tt.args.messWithToken {
+					got = tt.args.newToken
+				}
 
-			// Try to validate token
-			user, err := u.ValidateToken(got)
-			if tt.wants.validateErr {
-				require.NotNil(t, err)
-				require.Equal(t, err, tt.wants.validateErrType)
-				return
-			}
+				// Try to validate token
+				user, err := u.ValidateToken(got)
+				if tt.wants.validateErr {
+					require.NotNil(t, err)
+					require.Equal(t, err, tt.wants.validateErrType)
+					return
+				}
 
-			require.Nil(t, err)
-			require.Equal(t, tt.wants.validate.Name, user.Name)
+				require.Nil(t, err)
+				require.Equal(t, tt.wants.validate.Name, user.Name)
 
-		})
-	}
+			})
+		}
+	})
 }
 
 func TestManager_Logout(t *testing.T) {
-	type fields struct {
-		i StoreTokener
-	}
-	type args struct {
-		loginUser *User
-		token     string
-	}
-	type wants struct {
-		logout  User
-		err     bool
-		errType error
-	}
-	tests := []struct {
-		name   string
-		fields fields
-		args   args
-		wants  wants
-	}{
-		{
-			name: "the right path",
-			fields: fields{
-				i: NewMemoryStore([]byte("super secret key"), time.Hour),
-			},
-			args: args{
-				loginUser: &User{
-					Name:     "adam",
-					Password: "pwd",
+	forEachStore(t, func(t *testing.T, newStore storeFactory) {
+		type fields struct {
+			i StoreTokener
+		}
+		type args struct {
+			loginUser *User
+			token     string
+		}
+		type wants struct {
+			logout  User
+			err     bool
+			errType error
+		}
+		tests := []struct {
+			name   string
+			fields fields
+			args   args
+			wants  wants
+		}{
+			{
+				name: "the right path",
+				fields: fields{
+					i: newStore(t, []byte("super secret key"), time.Hour, nil),
 				},
-				token: "",
-			},
-			wants: wants{
-				logout: User{
-					Name:     "adam",
-					Password: "",
+				args: args{
+					loginUser: &User{
+						Name:     "adam",
+						Password: "pwd",
+					},
+					token: "",
+				},
+				wants: wants{
+					logout: User{
+						Name:     "adam",
+						Password: "",
+					},
+					err:     false,
+					errType: nil,
 				},
-				err:     false,
-				errType: nil,
-			},
-		},
-		{
-			name: "wrong token",
-			fields: fields{
-				i: NewMemoryStore([]byte("super secret key"), time.Hour),
-			},
-			args: args{
-				loginUser: nil,
-				token:     "",
-			},
-			wants: wants{
-				logout:  User{},
-				err:     true,
-				errType: jwt.NewValidationError("token contains an invalid number of segments", jwt.ValidationErrorMalformed),
-			},
-		},
-		{
-			name: "wrong token #2",
-			fields: fields{
-				i: NewMemoryStore([]byte("super secret key"), time.Hour),
 			},
-			args: args{
-				loginUser: nil,
-				token:     "123asfasb543rqsa",
+			{
+				name: "wrong token",
+				fields: fields{
+					i: newStore(t, []byte("super secret key"), time.Hour, nil),
+				},
+				args: args{
+					loginUser: nil,
+					token:     "",
+				},
+				wants: wants{
+					logout:  User{},
+					err:     true,
+					errType: jwt.NewValidationError("token contains an invalid number of segments", jwt.ValidationErrorMalformed),
+				},
 			},
-			wants: wants{
-				logout:  User{},
-				err:     true,
-				errType: jwt.NewValidationError("token contains an invalid number of segments", jwt.ValidationErrorMalformed),
+			{
+				name: "wrong token #2",
+				fields: fields{
+					i: newStore(t, []byte("super secret key"), time.Hour, nil),
+				},
+				args: args{
+					loginUser: nil,
+					token:     "123asfasb543rqsa",
+				},
+				wants: wants{
+					logout:  User{},
+					err:     true,
+					errType: jwt.NewValidationError("token contains an invalid number of segments", jwt.ValidationErrorMalformed),
+				},
 			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			m := New(tt.fields.i)
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				m := New(tt.fields.i)
 
-			if tt.args.loginUser != nil {
-				if err := m.Add(*tt.args.loginUser); err != nil {
-					t.Fatalf("%s: err not expected %#v", t.Name(), err)
-				}
-				auth, err := m.Auth(*tt.args.loginUser)
-				if err != nil {
-					t.Fatalf("%s: err not expected %#v", t.Name(), err)
+				if tt.args.loginUser != nil {
+					if err := m.Add(*tt.args.loginUser); err != nil {
+						t.Fatalf("%s: err not expected %#v", t.Name(), err)
+					}
+					auth, err := m.Auth(*tt.args.loginUser)
+					if err != nil {
+						t.Fatalf("%s: err not expected %#v", t.Name(), err)
+					}
+					if !auth {
+						t.Fatalf("%s: auth expected %#v", t.Name(), auth)
+					}
+
+					tt.args.token, err = m.Token(*tt.args.loginUser)
+					if err != nil {
+						t.Fatalf("%s: err not expected %#v", t.Name(), err)
+					}
 				}
-				if !auth {
-					t.Fatalf("%s: auth expected %#v", t.Name(), auth)
+
+				got, err := m.Logout(tt.args.token)
+				if (err != nil) != tt.wants.err {
+					t.Errorf("%s: Logout() error = %#v, tt.wants.err %#v", t.Name(), err, tt.wants.err)
+					return
 				}
 
-				tt.args.token, err = m.Token(*tt.args.loginUser)
-				if err != nil {
-					t.Fatalf("%s: err not expected %#v", t.Name(), err)
+				if tt.wants.err {
+					require.Equal(t, tt.wants.errType, err)
+					return
 				}
-			}
 
-			got, err := m.Logout(tt.args.token)
-			if (err != nil) != tt.wants.err {
-				t.Errorf("%s: Logout() error = %#v, tt.wants.err %#v", t.Name(), err, tt.wants.err)
-				return
-			}
+				require.NotNil(t, got)
+				require.Equal(t, tt.wants.logout.Name, got.Name)
+				require.Empty(t, tt.wants.logout.Password)
+			})
+		}
+	})
+}
+
+func TestManager_LoginLogout(t *testing.T) {
+	forEachStore(t, func(t *testing.T, newStore storeFactory) {
+		t.Run("logout twice", func(t *testing.T) {
+			m := New(newStore(t, []byte("key"), time.Hour, nil))
 
-			if tt.wants.err {
-				require.Equal(t, tt.wants.errType, err)
-				return
+			addUser := User{
+				Name:     "adam",
+				Password: "pwd",
 			}
 
-			require.NotNil(t, got)
-			require.Equal(t, tt.wants.logout.Name, got.Name)
-			require.Empty(t, tt.wants.logout.Password)
+			// First, need to add user
+			require.Nil(t, m.Add(addUser))
+
+			// Get token for user
+			token, err := m.Token(addUser)
+			require.Nil(t, err)
+			require.NotEmpty(t, token)
+
+			// Check whether token is right
+			validateToken, err := m.ValidateToken(token)
+			require.Nil(t, err)
+			require.Equal(t, validateToken.Name, addUser.Name)
+
+			// Logout user
+			logout, err := m.Logout(token)
+			require.Nil(t, err)
+			require.Equal(t, logout.Name, addUser.Name)
+
+			// Logout for second time
+			logout, err = m.Logout(token)
+			require.NotNil(t, err)
+			require.Nil(t, logout)
+
 		})
-	}
+
+	})
 }
 
-func TestManager_LoginLogout(t *testing.T) {
-	t.Run("logout twice", func(t *testing.T) {
-		m := New(NewMemoryStore([]byte("key"), time.Hour))
+func TestSQLiteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.db")
 
-		addUser := User{
-			Name:     "adam",
-			Password: "pwd",
-		}
+	t.Run("data survives reopening", func(t *testing.T) {
+		s, err := NewSQLiteStore(path, []byte("key"), time.Hour)
+		require.Nil(t, err)
+		m := New(s)
 
-		// First, need to add user
-		require.Nil(t, m.Add(addUser))
+		user := User{Name: "adam", Password: "pwd"}
+		require.Nil(t, m.Add(user))
+		token, err := m.Token(user)
+		require.Nil(t, err)
+		_, err = m.Logout(token)
+		require.Nil(t, err)
+		require.Nil(t, s.Close())
 
-		// Get token for user
-		token, err := m.Token(addUser)
+		// Migrations mustn't be applied twice
+		s, err = NewSQLiteStore(path, []byte("key"), time.Hour)
 		require.Nil(t, err)
-		require.NotEmpty(t, token)
+		defer s.Close()
+		m = New(s)
 
-		// Check whether token is right
-		validateToken, err := m.ValidateToken(token)
+		auth, err := m.Auth(user)
 		require.Nil(t, err)
-		require.Equal(t, validateToken.Name, addUser.Name)
+		require.True(t, auth)
 
-		// Logout user
-		logout, err := m.Logout(token)
+		_, err = m.ValidateToken(token)
+		require.Equal(t, ErrBlacklisted, err)
+	})
+
+	t.Run("closed database returns ErrIO", func(t *testing.T) {
+		s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "closed.db"), []byte("key"), time.Hour)
 		require.Nil(t, err)
-		require.Equal(t, logout.Name, addUser.Name)
+		require.Nil(t, s.Close())
 
-		// Logout for second time
-		logout, err = m.Logout(token)
+		err = New(s).Add(User{Name: "adam", Password: "pwd"})
+		require.True(t, errors.Is(err, ErrIO))
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		_, err := NewSQLiteStore(filepath.Join(t.TempDir(), "not", "existing", "dir.db"), []byte("key"), time.Hour)
 		require.NotNil(t, err)
-		require.Nil(t, logout)
+	})
+}
+
+func TestMemoryStore_concurrent(t *testing.T) {
+	s := NewMemoryStore([]byte("key"), time.Hour)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				token := fmt.Sprintf("%d-%d", i, j)
+				// Expired tokens make AddToken prune the map, which others read
+				require.Nil(t, s.AddToken(token, time.Now().Add(-time.Second)))
+				_, err := s.TokenExists(token)
+				require.Nil(t, err)
+				require.Nil(t, s.SetGeneration(token, uint64(j)))
+				_, err = s.Generation(token)
+				require.Nil(t, err)
+				require.Nil(t, s.SaveRefresh(RefreshToken{ID: token, Family: token, Expires: time.Now().Add(time.Hour)}))
+				_, err = s.LoadRefresh(token)
+				require.Nil(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestManager_LogoutAll(t *testing.T) {
+	forEachStore(t, func(t *testing.T, newStore storeFactory) {
+		m := New(newStore(t, []byte("key"), time.Hour, nil))
+		adam := User{Name: "adam", Password: "pwd"}
+		eve := User{Name: "eve", Password: "pwd"}
+		require.Nil(t, m.Add(adam))
+		require.Nil(t, m.Add(eve))
+
+		adamToken, err := m.Token(adam)
+		require.Nil(t, err)
+		eveToken, err := m.Token(eve)
+		require.Nil(t, err)
 
+		user, err := m.LogoutAll(adamToken)
+		require.Nil(t, err)
+		require.Equal(t, adam.Name, user.Name)
+
+		_, err = m.ValidateToken(adamToken)
+		require.Equal(t, ErrRevoked, err)
+		_, err = m.LogoutAll(adamToken)
+		require.Equal(t, ErrRevoked, err)
+
+		// Other users are not affected
+		_, err = m.ValidateToken(eveToken)
+		require.Nil(t, err)
+
+		// New token is valid
+		adamToken, err = m.Token(adam)
+		require.Nil(t, err)
+		_, err = m.ValidateToken(adamToken)
+		require.Nil(t, err)
 	})
 
+	t.Run("handle io error", func(t *testing.T) {
+		store := &MemoryStoreError{store: NewMemoryStore([]byte("key"), time.Hour)}
+		m := New(store)
+		adam := User{Name: "adam", Password: "pwd"}
+		require.Nil(t, m.Add(adam))
+		token, err := m.Token(adam)
+		require.Nil(t, err)
+
+		store.returnErr = true
+		_, err = m.LogoutAll(token)
+		require.True(t, errors.Is(err, ErrIO))
+	})
+}
+
+func TestStore_pruneTokens(t *testing.T) {
+	forEachStore(t, func(t *testing.T, newStore storeFactory) {
+		s := newStore(t, []byte("key"), time.Hour, nil)
+
+		require.Nil(t, s.AddToken("forever", time.Time{}))
+		require.Nil(t, s.AddToken("expired", time.Now().Add(-time.Hour)))
+		exists, err := s.TokenExists("expired")
+		require.Nil(t, err)
+		require.True(t, exists)
+
+		// Next AddToken forgets already expired tokens
+		require.Nil(t, s.AddToken("valid", time.Now().Add(time.Hour)))
+		for token, want := range map[string]bool{"expired": false, "forever": true, "valid": true} {
+			exists, err := s.TokenExists(token)
+			require.Nil(t, err)
+			require.Equal(t, want, exists, token)
+		}
+	})
+
+	t.Run("memory store doesn't grow", func(t *testing.T) {
+		m := NewMemoryStore([]byte("key"), time.Hour)
+		for i := 0; i < 100; i++ {
+			require.Nil(t, m.AddToken(fmt.Sprint(i), time.Now().Add(-time.Second)))
+		}
+		require.Len(t, m.tokens, 1)
+	})
 }
@@ -5,26 +5,32 @@
 package auth
 
 import (
+	"sync"
 	"time"
 )
 
 var _ StoreTokener = &MemoryStore{}
 
-// MemoryStore satisfies Store interface
+// MemoryStore satisfies Store interface, it is safe for concurrent use
 type MemoryStore struct {
-	store    map[string][]byte
-	tokens   []string
-	key      []byte
-	duration time.Duration
+	mtx         sync.RWMutex
+	store       map[string][]byte
+	tokens      map[string]time.Time
+	generations map[string]uint64
+	refresh     map[string]RefreshToken
+	key         []byte
+	duration    time.Duration
 }
 
 // NewMemoryStore is default constructor for MemoryStore
 func NewMemoryStore(key []byte, duration time.Duration) *MemoryStore {
 	return &MemoryStore{
-		store:    map[string][]byte{},
-		tokens:   []string{},
-		key:      key,
-		duration: duration,
+		store:       map[string][]byte{},
+		tokens:      map[string]time.Time{},
+		generations: map[string]uint64{},
+		refresh:     map[string]RefreshToken{},
+		key:         key,
+		duration:    duration,
 	}
 }
 
@@ -40,49 +46,140 @@ func (m *MemoryStore) Duration() time.Duration {
 
 // Load loads user data from store
 func (m *MemoryStore) Load(name string) ([]byte, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
 	data, _ := m.store[name]
 	return data, nil
 }
 
 // Save users data into store
 func (m *MemoryStore) Save(name string, data []byte) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
 	m.store[name] = data
 	return nil
 }
 
 // NameExists returns true, whether user with provided name exists
 func (m *MemoryStore) NameExists(name string) (bool, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
 	_, ok := m.store[name]
 	return ok, nil
 }
 
 // Remove user from store
 func (m *MemoryStore) Remove(name string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
 	delete(m.store, name)
 	return nil
 }
 
-func (m *MemoryStore) AddToken(token string) error {
-	m.tokens = append(m.tokens, token)
+// AddToken adds token to blacklist, at the same time forgets already expired tokens
+func (m *MemoryStore) AddToken(token string, expires time.Time) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.prune(time.Now())
+	if m.tokens == nil {
+		m.tokens = map[string]time.Time{}
+	}
+	m.tokens[token] = expires
 	return nil
 }
 
+// TokenExists returns true, whether token is blacklisted
 func (m *MemoryStore) TokenExists(token string) (bool, error) {
-	for _, elem := range m.tokens {
-		if elem == token {
-			return true, nil
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	_, ok := m.tokens[token]
+	return ok, nil
+}
+
+// RemoveToken removes token from blacklist
+func (m *MemoryStore) RemoveToken(token string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	delete(m.tokens, token)
+	return nil
+}
+
+// Generation returns tokens generation of user
+func (m *MemoryStore) Generation(name string) (uint64, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	return m.generations[name], nil
+}
+
+// SetGeneration sets tokens generation of user
+func (m *MemoryStore) SetGeneration(name string, generation uint64) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.generations == nil {
+		m.generations = map[string]uint64{}
+	}
+	m.generations[name] = generation
+	return nil
+}
+
+// SaveRefresh saves refresh token, at the same time forgets already expired ones
+func (m *MemoryStore) SaveRefresh(token RefreshToken) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	now := time.Now()
+	for id, elem := range m.refresh {
+		if elem.Expires.Before(now) {
+			delete(m.refresh, id)
 		}
 	}
-	return false, nil
+	if m.refresh == nil {
+		m.refresh = map[string]RefreshToken{}
+	}
+	m.refresh[token.ID] = token
+	return nil
 }
 
-func (m *MemoryStore) RemoveToken(token string) error {
-	for i, elem := range m.tokens {
-		if elem == token {
-			m.tokens[i] = m.tokens[len(m.tokens)-1]
-			m.tokens = m.tokens[:len(m.tokens)-1]
-			return nil
+// LoadRefresh returns refresh token with provided id, nil if it doesn't exist
+func (m *MemoryStore) LoadRefresh(id string) (*RefreshToken, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	token, ok := m.refresh[id]
+	if !ok {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+// UseRefresh marks refresh token as used, if it isn't yet
+func (m *MemoryStore) UseRefresh(id string) (bool, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	token, ok := m.refresh[id]
+	if !ok || token.Used {
+		return false, nil
+	}
+	token.Used = true
+	m.refresh[id] = token
+	return true, nil
+}
+
+// RemoveFamily removes every refresh token from family
+func (m *MemoryStore) RemoveFamily(family string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for id, elem := range m.refresh {
+		if elem.Family == family {
+			delete(m.refresh, id)
 		}
 	}
 	return nil
 }
+
+// prune removes tokens expired before now. Tokens without expiration time are kept forever, caller must hold lock
+func (m *MemoryStore) prune(now time.Time) {
+	for token, expires := range m.tokens {
+		if !expires.IsZero() && expires.Before(now) {
+			delete(m.tokens, token)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// DefaultRefreshDuration is validation time of refresh tokens, if not changed by SetRefreshDuration
+const DefaultRefreshDuration = 30 * 24 * time.Hour
+
+// RefreshToken is stored representation of opaque refresh token.
+// Raw token is never stored, ID is its SHA-256 hash.
+// Each Refresh rotates token - the old one is marked as Used and new one, from the same Family, is issued
+type RefreshToken struct {
+	ID         string
+	Name       string
+	Family     string
+	Generation uint64
+	Expires    time.Time
+	Used       bool
+}
+
+// SetRefreshDuration sets validation time of new refresh tokens
+func (m *Manager) SetRefreshDuration(duration time.Duration) {
+	m.refreshDuration = duration
+}
+
+// Login authenticates user and returns short-lived jwtToken together with long-lived refresh token
+func (m *Manager) Login(user User) (token string, refresh string, err error) {
+	if auth, err := m.Auth(user); err != nil {
+		return "", "", err
+	} else if !auth {
+		return "", "", ErrInvalidCredentials
+	}
+
+	generation, err := m.generation(user.Name)
+	if err != nil {
+		return "", "", err
+	}
+
+	family, err := random()
+	if err != nil {
+		return "", "", err
+	}
+
+	return m.issue(user.Name, family, generation)
+}
+
+// Refresh rotates refresh token: returns new jwtToken and new refresh token, the old one can't be used anymore.
+// Reusing already rotated refresh token revokes whole token family, as it may have been stolen
+func (m *Manager) Refresh(refresh string) (token string, newRefresh string, err error) {
+	id := hash(refresh)
+	stored, err := m.loadRefresh(id)
+	if err != nil {
+		return "", "", err
+	}
+	if stored == nil {
+		return "", "", ErrInvalidToken
+	}
+
+	if stored.Used {
+		if err := m.removeFamily(stored.Family); err != nil {
+			return "", "", err
+		}
+		return "", "", ErrRefreshReused
+	}
+
+	if time.Now().After(stored.Expires) {
+		return "", "", ErrExpired
+	}
+
+	if exists, err := m.nameExists(stored.Name); err != nil {
+		return "", "", err
+	} else if !exists {
+		return "", "", fmt.Errorf("%w %s", ErrNotExist, stored.Name)
+	}
+
+	if generation, err := m.generation(stored.Name); err != nil {
+		return "", "", err
+	} else if generation != stored.Generation {
+		return "", "", ErrRevoked
+	}
+
+	// Concurrent Refresh may have used token since it was loaded, losing the race is reuse as well
+	if used, err := m.useRefresh(id); err != nil {
+		return "", "", err
+	} else if !used {
+		if err := m.removeFamily(stored.Family); err != nil {
+			return "", "", err
+		}
+		return "", "", ErrRefreshReused
+	}
+
+	return m.issue(stored.Name, stored.Family, stored.Generation)
+}
+
+// issue returns new jwtToken and saves new refresh token in family
+func (m *Manager) issue(name, family string, generation uint64) (token string, refresh string, err error) {
+	if token, err = m.token(name, family, generation); err != nil {
+		return "", "", err
+	}
+
+	if refresh, err = random(); err != nil {
+		return "", "", err
+	}
+
+	err = m.saveRefresh(RefreshToken{
+		ID:         hash(refresh),
+		Name:       name,
+		Family:     family,
+		Generation: generation,
+		Expires:    time.Now().Add(m.refreshDuration),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return token, refresh, nil
+}
+
+// saveRefresh is wrapper for interface call SaveRefresh, returns appropriate wrapped error
+func (m *Manager) saveRefresh(token RefreshToken) error {
+	if err := m.i.SaveRefresh(token); err != nil {
+		return fmt.Errorf("%w: SaveRefresh: %s, error: %v", ErrIO, token.Name, err)
+	}
+	return nil
+}
+
+// loadRefresh is wrapper for interface call LoadRefresh, returns appropriate wrapped error
+func (m *Manager) loadRefresh(id string) (*RefreshToken, error) {
+	token, err := m.i.LoadRefresh(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: LoadRefresh: %s, error: %v", ErrIO, id, err)
+	}
+	return token, nil
+}
+
+// useRefresh is wrapper for interface call UseRefresh, returns appropriate wrapped error
+func (m *Manager) useRefresh(id string) (bool, error) {
+	used, err := m.i.UseRefresh(id)
+	if err != nil {
+		return false, fmt.Errorf("%w: UseRefresh: %s, error: %v", ErrIO, id, err)
+	}
+	return used, nil
+}
+
+// removeFamily is wrapper for interface call RemoveFamily, returns appropriate wrapped error
+func (m *Manager) removeFamily(family string) error {
+	if err := m.i.RemoveFamily(family); err != nil {
+		return fmt.Errorf("%w: RemoveFamily: %s, error: %v", ErrIO, family, err)
+	}
+	return nil
+}
+
+// random returns random, url safe string
+func random() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: rand.Read: %v", ErrIO, err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hash returns hex encoded SHA-256 of token
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,177 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestManager_Refresh(t *testing.T) {
+	adam := User{Name: "adam", Password: "pwd"}
+
+	forEachStore(t, func(t *testing.T, newStore storeFactory) {
+		t.Run("rotate", func(t *testing.T) {
+			m := New(newStore(t, []byte("key"), time.Hour, nil))
+			require.Nil(t, m.Add(adam))
+
+			token, refresh, err := m.Login(adam)
+			require.Nil(t, err)
+			require.NotEmpty(t, token)
+			require.NotEmpty(t, refresh)
+
+			newToken, newRefresh, err := m.Refresh(refresh)
+			require.Nil(t, err)
+			require.NotEqual(t, refresh, newRefresh)
+
+			user, err := m.ValidateToken(newToken)
+			require.Nil(t, err)
+			require.Equal(t, adam.Name, user.Name)
+
+			// Rotated token can be used again
+			_, _, err = m.Refresh(newRefresh)
+			require.Nil(t, err)
+		})
+
+		t.Run("reuse revokes family", func(t *testing.T) {
+			m := New(newStore(t, []byte("key"), time.Hour, nil))
+			require.Nil(t, m.Add(adam))
+
+			_, first, err := m.Login(adam)
+			require.Nil(t, err)
+			_, other, err := m.Login(adam)
+			require.Nil(t, err)
+
+			_, second, err := m.Refresh(first)
+			require.Nil(t, err)
+
+			_, _, err = m.Refresh(first)
+			require.Equal(t, ErrRefreshReused, err)
+
+			// Whole family is gone
+			_, _, err = m.Refresh(second)
+			require.Equal(t, ErrInvalidToken, err)
+
+			// Other families (e.g. other device) still work
+			_, _, err = m.Refresh(other)
+			require.Nil(t, err)
+		})
+
+		t.Run("invalid credentials and tokens", func(t *testing.T) {
+			m := New(newStore(t, []byte("key"), time.Hour, nil))
+			require.Nil(t, m.Add(adam))
+
+			_, _, err := m.Login(User{Name: "adam", Password: "other"})
+			require.Equal(t, ErrInvalidCredentials, err)
+
+			_, _, err = m.Refresh("not existing")
+			require.Equal(t, ErrInvalidToken, err)
+		})
+
+		t.Run("expired", func(t *testing.T) {
+			m := New(newStore(t, []byte("key"), time.Hour, nil))
+			m.SetRefreshDuration(-time.Minute)
+			require.Nil(t, m.Add(adam))
+
+			_, refresh, err := m.Login(adam)
+			require.Nil(t, err)
+			_, _, err = m.Refresh(refresh)
+			require.Equal(t, ErrExpired, err)
+		})
+
+		t.Run("logout everywhere revokes refresh tokens", func(t *testing.T) {
+			m := New(newStore(t, []byte("key"), time.Hour, nil))
+			require.Nil(t, m.Add(adam))
+
+			token, refresh, err := m.Login(adam)
+			require.Nil(t, err)
+			_, err = m.LogoutAll(token)
+			require.Nil(t, err)
+
+			_, _, err = m.Refresh(refresh)
+			require.Equal(t, ErrRevoked, err)
+		})
+
+		t.Run("logout revokes refresh token", func(t *testing.T) {
+			m := New(newStore(t, []byte("key"), time.Hour, nil))
+			require.Nil(t, m.Add(adam))
+
+			token, refresh, err := m.Login(adam)
+			require.Nil(t, err)
+			_, other, err := m.Login(adam)
+			require.Nil(t, err)
+			_, err = m.Logout(token)
+			require.Nil(t, err)
+
+			_, _, err = m.Refresh(refresh)
+			require.Equal(t, ErrInvalidToken, err)
+
+			// Token issued by refresh revokes family as well
+			token, refresh, err = m.Refresh(other)
+			require.Nil(t, err)
+			_, err = m.Logout(token)
+			require.Nil(t, err)
+			_, _, err = m.Refresh(refresh)
+			require.Equal(t, ErrInvalidToken, err)
+		})
+
+		t.Run("concurrent refresh", func(t *testing.T) {
+			m := New(newStore(t, []byte("key"), time.Hour, nil))
+			require.Nil(t, m.Add(adam))
+
+			_, refresh, err := m.Login(adam)
+			require.Nil(t, err)
+
+			const workers = 8
+			errs := make(chan error, workers)
+			var wg sync.WaitGroup
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, _, err := m.Refresh(refresh)
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			succeeded := 0
+			for err := range errs {
+				if err == nil {
+					succeeded++
+				}
+			}
+			require.Equal(t, 1, succeeded)
+		})
+
+		t.Run("removed user", func(t *testing.T) {
+			m := New(newStore(t, []byte("key"), time.Hour, nil))
+			require.Nil(t, m.Add(adam))
+
+			_, refresh, err := m.Login(adam)
+			require.Nil(t, err)
+			require.Nil(t, m.Remove(adam))
+
+			_, _, err = m.Refresh(refresh)
+			require.True(t, errors.Is(err, ErrNotExist))
+		})
+	})
+
+	t.Run("handle io error", func(t *testing.T) {
+		store := &MemoryStoreError{store: NewMemoryStore([]byte("key"), time.Hour)}
+		m := New(store)
+		require.Nil(t, m.Add(adam))
+		_, refresh, err := m.Login(adam)
+		require.Nil(t, err)
+
+		store.returnErr = true
+		_, _, err = m.Refresh(refresh)
+		require.True(t, errors.Is(err, ErrIO))
+	})
+}
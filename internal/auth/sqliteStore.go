@@ -0,0 +1,200 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/a-clap/dictionary/internal/sqlitedb"
+	"time"
+)
+
+var _ StoreTokener = &SQLiteStore{}
+
+// migrations of database, see sqlitedb.Open. Never modify existing migration, always append new one
+var migrations = []string{
+	`CREATE TABLE users (
+		name TEXT PRIMARY KEY NOT NULL,
+		data BLOB NOT NULL
+	);
+	CREATE TABLE tokens (
+		token TEXT PRIMARY KEY NOT NULL
+	);`,
+	// tokens blacklisted before this migration have NULL expiration and are kept forever
+	`ALTER TABLE tokens ADD COLUMN expires INTEGER;
+	CREATE TABLE generations (
+		name TEXT PRIMARY KEY NOT NULL,
+		generation INTEGER NOT NULL
+	);`,
+	`CREATE TABLE refresh_tokens (
+		id TEXT PRIMARY KEY NOT NULL,
+		name TEXT NOT NULL,
+		family TEXT NOT NULL,
+		generation INTEGER NOT NULL,
+		expires INTEGER NOT NULL,
+		used INTEGER NOT NULL
+	);
+	CREATE INDEX refresh_tokens_family ON refresh_tokens (family);`,
+}
+
+// SQLiteStore satisfies Store interface, keeps everything in sqlite database
+type SQLiteStore struct {
+	db       *sql.DB
+	key      []byte
+	duration time.Duration
+}
+
+// NewSQLiteStore opens (or creates) database at path and migrates it to the newest schema
+func NewSQLiteStore(path string, key []byte, duration time.Duration) (*SQLiteStore, error) {
+	db, err := sqlitedb.Open(path, migrations)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteStore{
+		db:       db,
+		key:      key,
+		duration: duration,
+	}, nil
+}
+
+// Close closes underlying database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// Key is responsible for returning key to generate jwtToken
+func (s *SQLiteStore) Key() []byte {
+	return s.key
+}
+
+// Duration returns token validation time
+func (s *SQLiteStore) Duration() time.Duration {
+	return s.duration
+}
+
+// Load loads user data from store
+func (s *SQLiteStore) Load(name string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM users WHERE name = ?`, name).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Save users data into store
+func (s *SQLiteStore) Save(name string, data []byte) error {
+	_, err := s.db.Exec(`INSERT INTO users (name, data) VALUES (?, ?) ON CONFLICT(name) DO UPDATE SET data = excluded.data`, name, data)
+	return err
+}
+
+// NameExists returns true, whether user with provided name exists
+func (s *SQLiteStore) NameExists(name string) (bool, error) {
+	return s.exists(`SELECT 1 FROM users WHERE name = ?`, name)
+}
+
+// Remove user from store
+func (s *SQLiteStore) Remove(name string) error {
+	_, err := s.db.Exec(`DELETE FROM users WHERE name = ?`, name)
+	return err
+}
+
+// AddToken adds token to blacklist, at the same time forgets already expired tokens
+func (s *SQLiteStore) AddToken(token string, expires time.Time) error {
+	if _, err := s.db.Exec(`DELETE FROM tokens WHERE expires IS NOT NULL AND expires < ?`, time.Now().Unix()); err != nil {
+		return err
+	}
+
+	var exp interface{}
+	if !expires.IsZero() {
+		exp = expires.Unix()
+	}
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO tokens (token, expires) VALUES (?, ?)`, token, exp)
+	return err
+}
+
+// TokenExists returns true, whether token is blacklisted
+func (s *SQLiteStore) TokenExists(token string) (bool, error) {
+	return s.exists(`SELECT 1 FROM tokens WHERE token = ?`, token)
+}
+
+// RemoveToken removes token from blacklist
+func (s *SQLiteStore) RemoveToken(token string) error {
+	_, err := s.db.Exec(`DELETE FROM tokens WHERE token = ?`, token)
+	return err
+}
+
+// Generation returns tokens generation of user
+func (s *SQLiteStore) Generation(name string) (uint64, error) {
+	var generation uint64
+	err := s.db.QueryRow(`SELECT generation FROM generations WHERE name = ?`, name).Scan(&generation)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return generation, err
+}
+
+// SetGeneration sets tokens generation of user
+func (s *SQLiteStore) SetGeneration(name string, generation uint64) error {
+	_, err := s.db.Exec(`INSERT INTO generations (name, generation) VALUES (?, ?) ON CONFLICT(name) DO UPDATE SET generation = excluded.generation`, name, int64(generation))
+	return err
+}
+
+// SaveRefresh saves refresh token, at the same time forgets already expired ones
+func (s *SQLiteStore) SaveRefresh(token RefreshToken) error {
+	if _, err := s.db.Exec(`DELETE FROM refresh_tokens WHERE expires < ?`, time.Now().Unix()); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO refresh_tokens (id, name, family, generation, expires, used) VALUES (?, ?, ?, ?, ?, ?)`,
+		token.ID, token.Name, token.Family, int64(token.Generation), token.Expires.Unix(), token.Used)
+	return err
+}
+
+// LoadRefresh returns refresh token with provided id, nil if it doesn't exist
+func (s *SQLiteStore) LoadRefresh(id string) (*RefreshToken, error) {
+	var (
+		token   RefreshToken
+		expires int64
+	)
+	err := s.db.QueryRow(`SELECT id, name, family, generation, expires, used FROM refresh_tokens WHERE id = ?`, id).
+		Scan(&token.ID, &token.Name, &token.Family, &token.Generation, &expires, &token.Used)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	token.Expires = time.Unix(expires, 0)
+	return &token, nil
+}
+
+// UseRefresh marks refresh token as used, if it isn't yet. Conditional update makes it atomic
+func (s *SQLiteStore) UseRefresh(id string) (bool, error) {
+	result, err := s.db.Exec(`UPDATE refresh_tokens SET used = 1 WHERE id = ? AND used = 0`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// RemoveFamily removes every refresh token from family
+func (s *SQLiteStore) RemoveFamily(family string) error {
+	_, err := s.db.Exec(`DELETE FROM refresh_tokens WHERE family = ?`, family)
+	return err
+}
+
+// exists returns true, whether query returns any row
+func (s *SQLiteStore) exists(query string, args ...interface{}) (bool, error) {
+	var one int
+	err := s.db.QueryRow(query, args...).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,95 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"fmt"
+	"github.com/a-clap/logger"
+	"strings"
+	"sync/atomic"
+)
+
+var Logger logger.Logger = logger.NewNop()
+
+// Backend realizes storage for cached responses
+type Backend interface {
+	// Get returns value stored under key, false if there is no (or expired) value
+	Get(key string) (value []byte, ok bool, err error)
+	// Set stores value under key. Overwrites, if already exists
+	Set(key string, value []byte) error
+}
+
+// Stats holds counters of Cache
+type Stats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// Cache stores successful upstream responses in Backend
+type Cache struct {
+	b      Backend
+	hits   uint64
+	misses uint64
+}
+
+// New is default constructor for Cache
+func New(backend Backend) *Cache {
+	return &Cache{b: backend}
+}
+
+// Stats returns current counters
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+	}
+}
+
+// get returns value stored under key, on miss calls fetch and stores its result.
+// Errors from fetch are not cached, errors from Backend are only logged - upstream is still usable
+func (c *Cache) get(key string, fetch func() ([]byte, error)) ([]byte, error) {
+	value, ok, err := c.b.Get(key)
+	if err != nil {
+		Logger.Errorf("cache get %s failed: %v", key, err)
+	}
+	if ok {
+		atomic.AddUint64(&c.hits, 1)
+		return value, nil
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	value, err = fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.b.Set(key, value); err != nil {
+		Logger.Errorf("cache set %s failed: %v", key, err)
+	}
+	return value, nil
+}
+
+// key builds cache key from provider name, its parameters (e.g. languages) and collapsed text.
+// Case is kept, translation of "Turkey" differs from translation of "turkey"
+func key(provider string, text string, params ...string) string {
+	parts := append([]string{provider}, params...)
+	parts = append(parts, collapse(text))
+	return strings.Join(parts, "|")
+}
+
+// foldedKey is key, which ignores case of text as well. Useful for single words looked up in dictionaries
+func foldedKey(provider string, text string, params ...string) string {
+	return key(provider, strings.ToLower(text), params...)
+}
+
+// collapse makes "  brain  mind " and "brain mind" the same key
+func collapse(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// String is useful for logging
+func (s Stats) String() string {
+	return fmt.Sprintf("hits: %d, misses: %d", s.Hits, s.Misses)
+}
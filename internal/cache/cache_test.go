@@ -0,0 +1,205 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/cache"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/internal/merriamw/dictionary"
+	"github.com/a-clap/dictionary/internal/mymemory"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// upstream counts calls, returns text as response
+type upstream struct {
+	calls int
+	err   bool
+}
+
+func (u *upstream) response(text string, params ...interface{}) ([]byte, error) {
+	u.calls++
+	if u.err {
+		return nil, fmt.Errorf("upstream err")
+	}
+	return []byte(fmt.Sprint(append([]interface{}{text}, params...)...)), nil
+}
+
+func (u *upstream) Query(_ context.Context, texts []string, sourceLang deepl.SourceLang, targetLang deepl.TargetLang, opts deepl.Options) ([]byte, error) {
+	return u.response(fmt.Sprint(texts), sourceLang, targetLang, opts)
+}
+
+func (u *upstream) Get(_ context.Context, text string) ([]byte, error) {
+	return u.response(text)
+}
+
+type getWord struct {
+	upstream
+}
+
+func (g *getWord) Get(_ context.Context, text string, from, to mymemory.Language) ([]byte, error) {
+	return g.response(text, from, to)
+}
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestCache_clients(t *testing.T) {
+	ctx := context.Background()
+	u := &upstream{}
+	c := cache.New(cache.NewLRU(100, time.Hour))
+	d := cache.NewDeepler(u, c)
+
+	first, err := d.Query(ctx, []string{"Brain"}, deepl.SrcEnglish, deepl.TarPolish, deepl.Options{})
+	require.Nil(t, err)
+	// Collapsed whitespace hits the same key
+	second, err := d.Query(ctx, []string{"  Brain "}, deepl.SrcEnglish, deepl.TarPolish, deepl.Options{})
+	require.Nil(t, err)
+	require.Equal(t, first, second)
+	require.Equal(t, 1, u.calls)
+	require.Equal(t, cache.Stats{Hits: 1, Misses: 1}, c.Stats())
+	// But case matters for translations
+	_, err = d.Query(ctx, []string{"brain"}, deepl.SrcEnglish, deepl.TarPolish, deepl.Options{})
+	require.Nil(t, err)
+	require.Equal(t, 2, u.calls)
+
+	// Different language pair is different key
+	_, err = d.Query(ctx, []string{"brain"}, deepl.SrcEnglish, deepl.TarGerman, deepl.Options{})
+	require.Nil(t, err)
+	require.Equal(t, 3, u.calls)
+	// So are different options and texts
+	_, err = d.Query(ctx, []string{"brain"}, deepl.SrcEnglish, deepl.TarGerman, deepl.Options{Formality: deepl.FormalityMore})
+	require.Nil(t, err)
+	_, err = d.Query(ctx, []string{"brain", "mind"}, deepl.SrcEnglish, deepl.TarGerman, deepl.Options{})
+	require.Nil(t, err)
+	require.Equal(t, 5, u.calls)
+
+	// Different clients don't share keys, even with the same cache
+	_, err = cache.NewDefinitioner(u, c).Get(ctx, "brain")
+	require.Nil(t, err)
+	_, err = cache.NewThesauruser(u, c).Get(ctx, "brain")
+	require.Nil(t, err)
+	// Dictionaries ignore case
+	_, err = cache.NewDefinitioner(u, c).Get(ctx, "Brain")
+	require.Nil(t, err)
+	_, err = cache.NewThesauruser(u, c).Get(ctx, " BRAIN")
+	require.Nil(t, err)
+	g := &getWord{}
+	m := cache.NewGetWord(g, c)
+	_, err = m.Get(ctx, "brain", mymemory.English, mymemory.Polish)
+	require.Nil(t, err)
+	_, err = m.Get(ctx, "brain", mymemory.Polish, mymemory.English)
+	require.Nil(t, err)
+	_, err = m.Get(ctx, "brain", mymemory.Polish, mymemory.English)
+	require.Nil(t, err)
+	_, err = m.Get(ctx, "Brain", mymemory.Polish, mymemory.English)
+	require.Nil(t, err)
+	require.Equal(t, 7, u.calls)
+	require.Equal(t, 3, g.calls)
+	require.Equal(t, cache.Stats{Hits: 4, Misses: 10}, c.Stats())
+}
+
+func TestCache_errorsNotCached(t *testing.T) {
+	ctx := context.Background()
+	u := &upstream{err: true}
+	c := cache.New(cache.NewLRU(100, time.Hour))
+	d := cache.NewDefinitioner(u, c)
+
+	_, err := d.Get(ctx, "brain")
+	require.NotNil(t, err)
+	u.err = false
+	got, err := d.Get(ctx, "brain")
+	require.Nil(t, err)
+	require.Equal(t, []byte("brain"), got)
+	require.Equal(t, 2, u.calls)
+}
+
+// reference is upstream of one of MW dictionaries
+type reference struct {
+	*upstream
+	name string
+}
+
+func (r reference) Reference() string {
+	return r.name
+}
+
+func TestCache_dictionaryReference(t *testing.T) {
+	ctx := context.Background()
+	u := &upstream{}
+	c := cache.New(cache.NewLRU(100, time.Hour))
+
+	// Collegiate dictionary keeps keys of plain Definitioner
+	_, err := cache.NewDefinitioner(u, c).Get(ctx, "brain")
+	require.Nil(t, err)
+	_, err = cache.NewDefinitioner(reference{upstream: u, name: dictionary.Collegiate}, c).Get(ctx, "brain")
+	require.Nil(t, err)
+	require.Equal(t, 1, u.calls)
+
+	_, err = cache.NewDefinitioner(reference{upstream: u, name: dictionary.Learners}, c).Get(ctx, "brain")
+	require.Nil(t, err)
+	require.Equal(t, 2, u.calls)
+}
+
+func TestLRU(t *testing.T) {
+	c := &clock{now: time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)}
+	l := cache.NewLRU(2, time.Hour)
+	l.SetClock(c.Now)
+
+	require.Nil(t, l.Set("a", []byte("1")))
+	require.Nil(t, l.Set("b", []byte("2")))
+	// Use "a", so "b" is the least recently used
+	_, ok, _ := l.Get("a")
+	require.True(t, ok)
+	require.Nil(t, l.Set("c", []byte("3")))
+	require.Equal(t, 2, l.Len())
+
+	_, ok, _ = l.Get("b")
+	require.False(t, ok, "b should be evicted")
+	value, ok, _ := l.Get("a")
+	require.True(t, ok)
+	require.Equal(t, []byte("1"), value)
+
+	c.now = c.now.Add(2 * time.Hour)
+	_, ok, _ = l.Get("a")
+	require.False(t, ok, "a should expire")
+	require.Equal(t, 1, l.Len())
+}
+
+func TestDisk(t *testing.T) {
+	dir := t.TempDir()
+	c := &clock{now: time.Now()}
+	d, err := cache.NewDisk(dir, time.Hour)
+	require.Nil(t, err)
+	d.SetClock(c.Now)
+
+	_, ok, err := d.Get("deepl|EN|PL|brain")
+	require.Nil(t, err)
+	require.False(t, ok)
+
+	require.Nil(t, d.Set("deepl|EN|PL|brain", []byte("mózg")))
+
+	// New instance sees values stored by previous one
+	d, err = cache.NewDisk(dir, time.Hour)
+	require.Nil(t, err)
+	d.SetClock(c.Now)
+	value, ok, err := d.Get("deepl|EN|PL|brain")
+	require.Nil(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte("mózg"), value)
+
+	c.now = c.now.Add(2 * time.Hour)
+	_, ok, err = d.Get("deepl|EN|PL|brain")
+	require.Nil(t, err)
+	require.False(t, ok, "value should expire")
+}
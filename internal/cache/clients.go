@@ -0,0 +1,101 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/internal/merriamw/dictionary"
+	"github.com/a-clap/dictionary/internal/merriamw/thesaurus"
+	"github.com/a-clap/dictionary/internal/mymemory"
+	"strings"
+)
+
+var (
+	_ deepl.Deepler           = &Deepler{}
+	_ dictionary.Definitioner = &Definitioner{}
+	_ thesaurus.Thesauruser   = &Thesauruser{}
+	_ mymemory.GetWord        = &GetWord{}
+)
+
+// Deepler caches responses of deepl.Deepler
+type Deepler struct {
+	*Cache
+	d deepl.Deepler
+}
+
+// NewDeepler wraps d with cache c
+func NewDeepler(d deepl.Deepler, c *Cache) *Deepler {
+	return &Deepler{Cache: c, d: d}
+}
+
+// Query fulfills deepl.Deepler interface
+func (d *Deepler) Query(ctx context.Context, texts []string, sourceLang deepl.SourceLang, targetLang deepl.TargetLang, opts deepl.Options) ([]byte, error) {
+	// Texts are separated with character, which can't be collapsed by collapse
+	text := strings.Join(texts, "\x00")
+	return d.get(key("deepl", text, string(sourceLang), string(targetLang), fmt.Sprintf("%+v", opts)), func() ([]byte, error) {
+		return d.d.Query(ctx, texts, sourceLang, targetLang, opts)
+	})
+}
+
+// Definitioner caches responses of dictionary.Definitioner
+type Definitioner struct {
+	*Cache
+	d dictionary.Definitioner
+}
+
+// NewDefinitioner wraps d with cache c
+func NewDefinitioner(d dictionary.Definitioner, c *Cache) *Definitioner {
+	return &Definitioner{Cache: c, d: d}
+}
+
+// Get fulfills dictionary.Definitioner interface.
+// Responses of dictionaries other than collegiate one, e.g. Learner's, are kept apart
+func (d *Definitioner) Get(ctx context.Context, text string) ([]byte, error) {
+	var params []string
+	if r, ok := d.d.(interface{ Reference() string }); ok && r.Reference() != dictionary.Collegiate {
+		params = append(params, r.Reference())
+	}
+	return d.get(foldedKey("dictionary", text, params...), func() ([]byte, error) {
+		return d.d.Get(ctx, text)
+	})
+}
+
+// Thesauruser caches responses of thesaurus.Thesauruser
+type Thesauruser struct {
+	*Cache
+	t thesaurus.Thesauruser
+}
+
+// NewThesauruser wraps t with cache c
+func NewThesauruser(t thesaurus.Thesauruser, c *Cache) *Thesauruser {
+	return &Thesauruser{Cache: c, t: t}
+}
+
+// Get fulfills thesaurus.Thesauruser interface
+func (t *Thesauruser) Get(ctx context.Context, text string) ([]byte, error) {
+	return t.get(foldedKey("thesaurus", text), func() ([]byte, error) {
+		return t.t.Get(ctx, text)
+	})
+}
+
+// GetWord caches responses of mymemory.GetWord
+type GetWord struct {
+	*Cache
+	g mymemory.GetWord
+}
+
+// NewGetWord wraps g with cache c
+func NewGetWord(g mymemory.GetWord, c *Cache) *GetWord {
+	return &GetWord{Cache: c, g: g}
+}
+
+// Get fulfills mymemory.GetWord interface
+func (g *GetWord) Get(ctx context.Context, text string, from, to mymemory.Language) ([]byte, error) {
+	return g.get(key("mymemory", text, string(from), string(to)), func() ([]byte, error) {
+		return g.g.Get(ctx, text, from, to)
+	})
+}
@@ -0,0 +1,95 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ Backend = &Disk{}
+
+// Disk is Backend, which keeps each value in separate file in dir, each of them valid for ttl
+type Disk struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewDisk is default constructor for Disk, creates dir if needed. ttl <= 0 means values never expire
+func NewDisk(dir string, ttl time.Duration) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+	return &Disk{
+		dir: dir,
+		ttl: ttl,
+		now: time.Now,
+	}, nil
+}
+
+// SetClock replaces source of current time, useful for testing
+func (d *Disk) SetClock(now func() time.Time) {
+	d.now = now
+}
+
+// Get fulfills Backend interface
+func (d *Disk) Get(key string) ([]byte, bool, error) {
+	path := d.path(key)
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	if d.ttl > 0 && d.now().After(info.ModTime().Add(d.ttl)) {
+		return nil, false, os.Remove(path)
+	}
+
+	value, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set fulfills Backend interface
+func (d *Disk) Set(key string, value []byte) error {
+	// Write to temporary file first, so concurrent Get never sees partially written value
+	tmp, err := os.CreateTemp(d.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(value); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	path := d.path(key)
+	now := d.now()
+	if err := os.Chtimes(tmp.Name(), now, now); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// path returns file name for key, keys may contain any characters, so they are hashed
+func (d *Disk) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
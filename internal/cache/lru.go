@@ -0,0 +1,95 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+var _ Backend = &LRU{}
+
+// LRU is in-memory Backend, which keeps at most capacity values, each of them valid for ttl
+type LRU struct {
+	mtx      sync.Mutex
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type lruItem struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRU is default constructor for LRU. ttl <= 0 means values never expire
+func NewLRU(capacity int, ttl time.Duration) *LRU {
+	return &LRU{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		order:    list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+// SetClock replaces source of current time, useful for testing
+func (l *LRU) SetClock(now func() time.Time) {
+	l.now = now
+}
+
+// Get fulfills Backend interface
+func (l *LRU) Get(key string) ([]byte, bool, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	item := elem.Value.(*lruItem)
+	if l.ttl > 0 && l.now().After(item.expires) {
+		l.order.Remove(elem)
+		delete(l.items, key)
+		return nil, false, nil
+	}
+
+	l.order.MoveToFront(elem)
+	return item.value, true, nil
+}
+
+// Set fulfills Backend interface
+func (l *LRU) Set(key string, value []byte) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	expires := l.now().Add(l.ttl)
+	if elem, ok := l.items[key]; ok {
+		item := elem.Value.(*lruItem)
+		item.value, item.expires = value, expires
+		l.order.MoveToFront(elem)
+		return nil
+	}
+
+	l.items[key] = l.order.PushFront(&lruItem{key: key, value: value, expires: expires})
+	for l.capacity > 0 && l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruItem).key)
+	}
+	return nil
+}
+
+// Len returns number of stored values
+func (l *LRU) Len() int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.order.Len()
+}
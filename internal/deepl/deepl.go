@@ -6,16 +6,23 @@ package deepl
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/a-clap/logger"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 )
 
 var Logger logger.Logger = logger.NewNop()
 
+// DefaultTimeout is used by http.Client of DeeplerDefault, when none is provided
+const DefaultTimeout = 10 * time.Second
+
 type SourceLang string
 type TargetLang string
 
@@ -78,12 +85,54 @@ const (
 	TarChinese         TargetLang = "ZH"
 )
 
+var ErrInvalidLang = errors.New("invalid language")
+
+var sourceLangs = []SourceLang{
+	SrcBulgarian, SrcCzech, SrcDanish, SrcGerman, SrcGreek, SrcEnglish, SrcSpanish, SrcEstonian, SrcFinnish,
+	SrcFrench, SrcHungarian, SrcIndonesian, SrcItalian, SrcJapanese, SrcLithuanian, SrcLatvian, SrcDutch,
+	SrcPolish, SrcPortuguese, SrcRomanian, SrcRussian, SrcSlovak, SrcSlovenian, SrcSwedish, SrcTurkish, SrcChinese,
+}
+
+var targetLangs = []TargetLang{
+	TarBulgarian, TarCzech, TarDanish, TarGerman, TarGreek, TarEnglishBritish, TarEnglishAmerican, TarSpanish,
+	TarEstonian, TarFinnish, TarFrench, TarHungarian, TarIndonesian, TarItalian, TarJapanese, TarLithuanian,
+	TarLatvian, TarDutch, TarPolish, TarPortuguese, TarBrazilian, TarRomanian, TarRussian, TarSlovak, TarSlovenian,
+	TarSwedish, TarTurkish, TarChinese,
+}
+
+// ParseSourceLang returns SourceLang for case-insensitive lang, e.x. "pl" -> SrcPolish.
+// Empty lang is valid - DeepL will detect source language on its own
+func ParseSourceLang(lang string) (SourceLang, error) {
+	if len(lang) == 0 {
+		return "", nil
+	}
+	l := SourceLang(strings.ToUpper(lang))
+	for _, elem := range sourceLangs {
+		if elem == l {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("%w: source %s", ErrInvalidLang, lang)
+}
+
+// ParseTargetLang returns TargetLang for case-insensitive lang, e.x. "en-gb" -> TarEnglishBritish
+func ParseTargetLang(lang string) (TargetLang, error) {
+	l := TargetLang(strings.ToUpper(lang))
+	for _, elem := range targetLangs {
+		if elem == l {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("%w: target %s", ErrInvalidLang, lang)
+}
+
 type DeepL struct {
 	Deepler
 }
 
+// Deepler queries DeepL for translation of each text, opts are already validated
 type Deepler interface {
-	Query(text string, sourceLang SourceLang, targetLanguage TargetLang) ([]byte, error)
+	Query(ctx context.Context, texts []string, sourceLang SourceLang, targetLanguage TargetLang, opts Options) ([]byte, error)
 }
 
 func NewDeepL(deepler Deepler) *DeepL {
@@ -91,11 +140,24 @@ func NewDeepL(deepler Deepler) *DeepL {
 }
 
 func NewDeepLDefault(key string) *DeepL {
-	return NewDeepL(NewDeeplerDefault(key))
+	return NewDeepL(NewDeeplerDefault(key, nil))
+}
+
+// Translate translates text with opts
+func (d *DeepL) Translate(ctx context.Context, text string, sourceLang SourceLang, targetLang TargetLang, opts Options) (*Word, error) {
+	return d.TranslateAll(ctx, []string{text}, sourceLang, targetLang, opts)
 }
 
-func (d *DeepL) Translate(text string, sourceLang SourceLang, targetLang TargetLang) (*Word, error) {
-	b, err := d.Query(text, sourceLang, targetLang)
+// TranslateAll translates every text in single request, Word.Translations are in the same order as texts
+func (d *DeepL) TranslateAll(ctx context.Context, texts []string, sourceLang SourceLang, targetLang TargetLang, opts Options) (*Word, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if len(opts.GlossaryID) > 0 && len(sourceLang) == 0 {
+		return nil, fmt.Errorf("%w: glossary requires source language", ErrInvalidOption)
+	}
+
+	b, err := d.Query(ctx, texts, sourceLang, targetLang, opts)
 	if err != nil {
 		return nil, fmt.Errorf("on query %w", err)
 	}
@@ -136,9 +198,25 @@ func (t Translations) SourceLang() string {
 }
 
 type DeeplerDefault struct {
-	values url.Values
+	key    string
+	url    string
+	client *http.Client
+	retry  Retry
 }
 
+// Retry configures sending again requests, which failed with status 429 or 5xx
+type Retry struct {
+	// Attempts is maximum number of requests sent, including the first one
+	Attempts int
+	// Backoff is delay before the first retry, doubled with every next one
+	Backoff time.Duration
+	// MaxBackoff limits delay, zero means no limit. Request isn't retried, when DeepL asks to wait longer
+	MaxBackoff time.Duration
+}
+
+// DefaultRetry is used by DeeplerDefault, unless SetRetry is called
+var DefaultRetry = Retry{Attempts: 3, Backoff: 500 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
 type Translations struct {
 	DetectedSourceLanguage string `json:"detected_source_language"`
 	Text                   string `json:"text"`
@@ -147,22 +225,113 @@ type Word struct {
 	Translations []Translations `json:"translations"`
 }
 
-func NewDeeplerDefault(key string) *DeeplerDefault {
+const (
+	freeURL = "https://api-free.deepl.com"
+	proURL  = "https://api.deepl.com"
+)
+
+// NewDeeplerDefault creates Deepler accessing DeepL API with client,
+// if client is nil, new one with DefaultTimeout is used.
+// Keys of free accounts end with ":fx", any other key uses pro endpoint
+func NewDeeplerDefault(key string, client *http.Client) *DeeplerDefault {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	endpoint := proURL
+	if strings.HasSuffix(key, ":fx") {
+		endpoint = freeURL
+	}
 	return &DeeplerDefault{
-		values: map[string][]string{
-			"auth_key": {key},
-		},
+		key:    key,
+		url:    endpoint,
+		client: client,
+		retry:  DefaultRetry,
+	}
+}
+
+// SetRetry replaces DefaultRetry
+func (a *DeeplerDefault) SetRetry(r Retry) {
+	a.retry = r
+}
+
+func (a *DeeplerDefault) Query(ctx context.Context, texts []string, sourceLang SourceLang, targetLang TargetLang, opts Options) ([]byte, error) {
+	values := url.Values{
+		"text":        texts,
+		"target_lang": {string(targetLang)},
+	}
+	if len(sourceLang) > 0 {
+		values.Set("source_lang", string(sourceLang))
+	}
+	opts.set(values)
+
+	req, err := a.request(ctx, http.MethodPost, "/v2/translate", strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return a.call(req)
+}
+
+// request prepares request to DeepL API path, authorized with header
+func (a *DeeplerDefault) request(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, a.url+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("error on http.NewRequest: %w", err)
 	}
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+a.key)
+	return req, nil
+}
+
+// call sends req, retrying temporary failures according to Retry.
+// Status other than 2xx is returned as *StatusError
+func (a *DeeplerDefault) call(req *http.Request) ([]byte, error) {
+	return a.send(req, (*StatusError).temporary)
 }
 
-func (a *DeeplerDefault) Query(text string, sourceLang SourceLang, targetLang TargetLang) ([]byte, error) {
-	a.values.Set("text", text)
-	a.values.Set("source_lang", string(sourceLang))
-	a.values.Set("target_lang", string(targetLang))
+// send sends req, retrying failures accepted by retryable according to Retry
+func (a *DeeplerDefault) send(req *http.Request, retryable func(*StatusError) bool) ([]byte, error) {
+	backoff := a.retry.Backoff
+	for attempt := 1; ; attempt++ {
+		status, header, body, err := a.do(req)
+		if err != nil {
+			return nil, err
+		}
+		if status >= http.StatusOK && status < http.StatusMultipleChoices {
+			return body, nil
+		}
+
+		statusErr := newStatusError(status, header, body)
+		if !retryable(statusErr) || attempt >= a.retry.Attempts {
+			return nil, statusErr
+		}
+		wait := backoff
+		if a.retry.MaxBackoff > 0 && wait > a.retry.MaxBackoff {
+			wait = a.retry.MaxBackoff
+		}
+		if statusErr.RetryAfter > wait {
+			if a.retry.MaxBackoff > 0 && statusErr.RetryAfter > a.retry.MaxBackoff {
+				return nil, statusErr
+			}
+			wait = statusErr.RetryAfter
+		}
+
+		Logger.Infof("attempt %d of %s %s failed: %v, retrying in %v", attempt, req.Method, req.URL.Path, statusErr, wait)
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, fmt.Errorf("%v, waiting for retry: %w", statusErr, err)
+		}
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
 
-	resp, err := http.PostForm("https://api-free.deepl.com/v2/translate", a.values)
+// do sends req, returns status code, header and whole body of response
+func (a *DeeplerDefault) do(req *http.Request) (int, http.Header, []byte, error) {
+	resp, err := a.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error on http.Post: %w", err)
+		return 0, nil, nil, fmt.Errorf("error on http.%s: %w", req.Method, err)
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -174,9 +343,34 @@ func (a *DeeplerDefault) Query(text string, sourceLang SourceLang, targetLang Ta
 	var buf bytes.Buffer
 	n, err := buf.ReadFrom(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error on reading response body: %w", err)
+		return 0, nil, nil, fmt.Errorf("error on reading response body: %w", err)
 	}
 	Logger.Infof("read %v bytes from resp.Body", n)
 
-	return buf.Bytes(), nil
+	return resp.StatusCode, resp.Header, buf.Bytes(), nil
+}
+
+// rewind returns copy of already sent req, which can be sent again
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("error on rewinding request body: %w", err)
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// sleep waits d, returns early with error when ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
@@ -5,13 +5,17 @@
 package deepl_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/a-clap/dictionary/internal/deepl"
 	"github.com/stretchr/testify/require"
-	"log"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -24,7 +28,7 @@ type fakeAccess struct {
 func init() {
 }
 
-func (f fakeAccess) Query(_ string, sourceLang deepl.SourceLang, _ deepl.TargetLang) ([]byte, error) {
+func (f fakeAccess) Query(_ context.Context, _ []string, sourceLang deepl.SourceLang, _ deepl.TargetLang, _ deepl.Options) ([]byte, error) {
 	if f.generateErr {
 		return nil, fmt.Errorf("generating random err")
 	}
@@ -38,10 +42,8 @@ func (f fakeAccess) Query(_ string, sourceLang deepl.SourceLang, _ deepl.TargetL
 }
 
 func TestDeepL_Translate(t *testing.T) {
-	api, ok := os.LookupEnv("DEEPL_KEY")
-	if !ok {
-		log.Fatalf("DEEPL_KEY Not found")
-	}
+	// Direct calls need real key, the rest runs offline
+	api, hasKey := os.LookupEnv("DEEPL_KEY")
 
 	type in struct {
 		acc  deepl.Deepler
@@ -112,7 +114,7 @@ func TestDeepL_Translate(t *testing.T) {
 			name: "direct call EN -> PL",
 			args: args{
 				in: in{
-					acc:  deepl.NewDeeplerDefault(api),
+					acc:  deepl.NewDeeplerDefault(api, nil),
 					text: "brain",
 					src:  deepl.SrcEnglish,
 					dst:  deepl.TarPolish,
@@ -132,7 +134,7 @@ func TestDeepL_Translate(t *testing.T) {
 			name: "direct call PL -> EN",
 			args: args{
 				in: in{
-					acc:  deepl.NewDeeplerDefault(api),
+					acc:  deepl.NewDeeplerDefault(api, nil),
 					text: "mózg",
 					src:  deepl.SrcPolish,
 					dst:  deepl.TarEnglishBritish,
@@ -151,9 +153,12 @@ func TestDeepL_Translate(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			if _, direct := tt.args.in.acc.(*deepl.DeeplerDefault); direct && !hasKey {
+				t.Skip("DEEPL_KEY not defined in ENV")
+			}
 			d := deepl.NewDeepL(tt.args.in.acc)
 			require.NotNil(t, d)
-			w, err := d.Translate(tt.args.in.text, tt.args.in.src, tt.args.in.dst)
+			w, err := d.Translate(context.Background(), tt.args.in.text, tt.args.in.src, tt.args.in.dst, deepl.Options{})
 
 			if (err != nil) != tt.args.out.err {
 				t.Errorf("%s: unexpected error %#v", t.Name(), err)
@@ -165,3 +170,138 @@ func TestDeepL_Translate(t *testing.T) {
 		})
 	}
 }
+
+// roundTripper records request and responds with status (200 if not set), header and body.
+// If statuses are set, each request consumes the first one instead of status
+type roundTripper struct {
+	request  *http.Request
+	form     url.Values
+	status   int
+	statuses []int
+	header   http.Header
+	body     string
+	calls    int
+}
+
+func (r *roundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	if err := request.Context().Err(); err != nil {
+		return nil, err
+	}
+	r.calls++
+	r.request = request
+	r.form = nil
+	if request.Body != nil {
+		b, err := io.ReadAll(request.Body)
+		if err != nil {
+			return nil, err
+		}
+		if r.form, err = url.ParseQuery(string(b)); err != nil {
+			return nil, err
+		}
+	}
+	status := r.status
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     r.header,
+		Body:       io.NopCloser(strings.NewReader(r.body)),
+		Request:    request,
+	}, nil
+}
+
+func TestDeeplerDefault_Query(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		texts []string
+		src   deepl.SourceLang
+		opts  deepl.Options
+		host  string
+		form  url.Values
+	}{
+		{
+			name:  "free key",
+			key:   "key:fx",
+			texts: []string{"brain"},
+			src:   deepl.SrcEnglish,
+			host:  "api-free.deepl.com",
+			form: url.Values{
+				"text":        {"brain"},
+				"source_lang": {"EN"},
+				"target_lang": {"PL"},
+			},
+		},
+		{
+			name:  "pro key, many texts and every option",
+			key:   "key",
+			texts: []string{"brain", "<b>mind</b>"},
+			opts: deepl.Options{
+				Formality:          deepl.FormalityPreferLess,
+				SplitSentences:     deepl.SplitNoNewlines,
+				PreserveFormatting: true,
+				TagHandling:        deepl.TagHTML,
+				Context:            "biology",
+			},
+			host: "api.deepl.com",
+			form: url.Values{
+				"text":                {"brain", "<b>mind</b>"},
+				"target_lang":         {"PL"},
+				"formality":           {"prefer_less"},
+				"split_sentences":     {"nonewlines"},
+				"preserve_formatting": {"1"},
+				"tag_handling":        {"html"},
+				"context":             {"biology"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &roundTripper{body: `{"translations":[{"text":"mózg"}]}`}
+			d := deepl.NewDeeplerDefault(tt.key, &http.Client{Transport: rt})
+
+			got, err := d.Query(context.Background(), tt.texts, tt.src, deepl.TarPolish, tt.opts)
+			require.Nil(t, err)
+			require.Equal(t, rt.body, string(got))
+			require.Equal(t, http.MethodPost, rt.request.Method)
+			require.Equal(t, tt.host, rt.request.URL.Host)
+			require.Equal(t, "DeepL-Auth-Key "+tt.key, rt.request.Header.Get("Authorization"))
+			require.Equal(t, "/v2/translate", rt.request.URL.Path)
+			require.Equal(t, tt.form, rt.form)
+		})
+	}
+
+	d := deepl.NewDeeplerDefault("key", &http.Client{Transport: &roundTripper{}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := d.Query(ctx, []string{"brain"}, deepl.SrcEnglish, deepl.TarPolish, deepl.Options{})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDeepL_TranslateAll(t *testing.T) {
+	rt := &roundTripper{body: `{"translations":[{"text":"mózg"},{"text":"umysł"}]}`}
+	d := deepl.NewDeepL(deepl.NewDeeplerDefault("key", &http.Client{Transport: rt}))
+
+	w, err := d.TranslateAll(context.Background(), []string{"brain", "mind"}, deepl.SrcEnglish, deepl.TarPolish, deepl.Options{GlossaryID: "id"})
+	require.Nil(t, err)
+	require.Equal(t, []string{"mózg", "umysł"}, w.Translation())
+	require.Equal(t, "id", rt.form.Get("glossary_id"))
+
+	invalid := []deepl.Options{
+		{Formality: "very"},
+		{SplitSentences: "2"},
+		{TagHandling: "json"},
+	}
+	for _, opts := range invalid {
+		_, err := d.Translate(context.Background(), "brain", deepl.SrcEnglish, deepl.TarPolish, opts)
+		require.ErrorIs(t, err, deepl.ErrInvalidOption, opts)
+	}
+
+	// Glossary can't be used without source language
+	_, err = d.Translate(context.Background(), "brain", "", deepl.TarPolish, deepl.Options{GlossaryID: "id"})
+	require.ErrorIs(t, err, deepl.ErrInvalidOption)
+}
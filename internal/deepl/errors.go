@@ -0,0 +1,100 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package deepl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrAuth means DeepL rejected key
+	ErrAuth = errors.New("deepl authorization failed")
+	// ErrRateLimited means too many requests were sent, StatusError.RetryAfter may tell when to try again
+	ErrRateLimited = errors.New("deepl rate limit reached")
+	// ErrQuotaExceeded means character limit of account is reached
+	ErrQuotaExceeded = errors.New("deepl quota exceeded")
+	// ErrUpstream is any other failure reported by DeepL
+	ErrUpstream = errors.New("deepl upstream error")
+)
+
+// StatusQuotaExceeded is non-standard status code, which DeepL uses when character limit is reached
+const StatusQuotaExceeded = 456
+
+// StatusError is returned, when DeepL responds with status other than 2xx.
+// It wraps ErrAuth, ErrRateLimited, ErrQuotaExceeded or ErrUpstream, depending on Status
+type StatusError struct {
+	Status  int
+	Message string
+	// RetryAfter is delay requested by DeepL in Retry-After header, zero if there wasn't any
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%v: status code %d: %s", e.Unwrap(), e.Status, e.Message)
+}
+
+func (e *StatusError) Unwrap() error {
+	switch e.Status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrAuth
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case StatusQuotaExceeded:
+		return ErrQuotaExceeded
+	default:
+		return ErrUpstream
+	}
+}
+
+// temporary returns true, if request may succeed when sent again
+func (e *StatusError) temporary() bool {
+	return e.Status == http.StatusTooManyRequests || e.Status >= http.StatusInternalServerError
+}
+
+// rateLimited returns true, if DeepL refused request without processing it
+func (e *StatusError) rateLimited() bool {
+	return e.Status == http.StatusTooManyRequests
+}
+
+// newStatusError creates StatusError from response with status code and body
+func newStatusError(status int, header http.Header, body []byte) *StatusError {
+	e := &StatusError{
+		Status:     status,
+		Message:    string(bytes.TrimSpace(body)),
+		RetryAfter: retryAfter(header.Get("Retry-After")),
+	}
+	var msg struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &msg); err == nil && len(msg.Message) > 0 {
+		e.Message = msg.Message
+	}
+	return e
+}
+
+// retryAfter parses value of Retry-After header, which is either number of seconds or HTTP date
+func retryAfter(value string) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
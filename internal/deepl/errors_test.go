@@ -0,0 +1,106 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package deepl_test
+
+import (
+	"context"
+	"errors"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDeeplerDefault_Query_errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		header     http.Header
+		err        error
+		calls      int
+		retryAfter time.Duration
+	}{
+		{
+			name:     "invalid key",
+			statuses: []int{http.StatusForbidden},
+			err:      deepl.ErrAuth,
+			calls:    1,
+		},
+		{
+			name:     "quota exceeded",
+			statuses: []int{deepl.StatusQuotaExceeded},
+			err:      deepl.ErrQuotaExceeded,
+			calls:    1,
+		},
+		{
+			name:     "bad request isn't retried",
+			statuses: []int{http.StatusBadRequest},
+			err:      deepl.ErrUpstream,
+			calls:    1,
+		},
+		{
+			name:     "retried until success",
+			statuses: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK},
+			calls:    3,
+		},
+		{
+			name:     "retries exhausted",
+			statuses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			err:      deepl.ErrUpstream,
+			calls:    3,
+		},
+		{
+			name:       "retry after is too long",
+			statuses:   []int{http.StatusTooManyRequests, http.StatusOK},
+			header:     http.Header{"Retry-After": {"120"}},
+			err:        deepl.ErrRateLimited,
+			calls:      1,
+			retryAfter: 2 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &roundTripper{statuses: tt.statuses, header: tt.header, body: `{"message":"some message"}`}
+			d := deepl.NewDeeplerDefault("key", &http.Client{Transport: rt})
+			d.SetRetry(deepl.Retry{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+
+			_, err := d.Query(context.Background(), []string{"brain"}, deepl.SrcEnglish, deepl.TarPolish, deepl.Options{})
+			require.Equal(t, tt.calls, rt.calls)
+			// Body is sent again with every retry
+			require.Equal(t, "brain", rt.form.Get("text"))
+			if tt.err == nil {
+				require.Nil(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.err)
+			var statusErr *deepl.StatusError
+			require.True(t, errors.As(err, &statusErr))
+			require.Equal(t, "some message", statusErr.Message)
+			require.Equal(t, tt.retryAfter, statusErr.RetryAfter)
+		})
+	}
+}
+
+func TestDeeplerDefault_Query_cancelledRetry(t *testing.T) {
+	rt := &roundTripper{status: http.StatusTooManyRequests}
+	d := deepl.NewDeeplerDefault("key", &http.Client{Transport: rt})
+	d.SetRetry(deepl.Retry{Attempts: 3, Backoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := d.Query(ctx, []string{"brain"}, deepl.SrcEnglish, deepl.TarPolish, deepl.Options{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 1, rt.calls)
+}
+
+func TestDeepL_Translate_typedErrors(t *testing.T) {
+	rt := &roundTripper{status: http.StatusUnauthorized, body: "Unauthorized"}
+	d := deepl.NewDeepL(deepl.NewDeeplerDefault("key", &http.Client{Transport: rt}))
+
+	_, err := d.Translate(context.Background(), "brain", deepl.SrcEnglish, deepl.TarPolish, deepl.Options{})
+	require.ErrorIs(t, err, deepl.ErrAuth)
+	require.Contains(t, err.Error(), "Unauthorized")
+}
@@ -0,0 +1,212 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package deepl
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// EntriesFormat is format of glossary entries
+type EntriesFormat string
+
+const (
+	FormatTSV EntriesFormat = "tsv"
+	FormatCSV EntriesFormat = "csv"
+)
+
+var ErrInvalidEntry = errors.New("invalid glossary entry")
+
+// Glossary describes glossary stored by DeepL, languages are lowercase codes without region, e.g. "en"
+type Glossary struct {
+	ID           string    `json:"glossary_id"`
+	Name         string    `json:"name"`
+	Ready        bool      `json:"ready"`
+	SourceLang   string    `json:"source_lang"`
+	TargetLang   string    `json:"target_lang"`
+	CreationTime time.Time `json:"creation_time"`
+	EntryCount   int       `json:"entry_count"`
+}
+
+// GlossaryEntry is single term and its translation
+type GlossaryEntry struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// Glossarier manages glossaries, see https://www.deepl.com/docs-api/glossaries
+type Glossarier interface {
+	// CreateGlossary creates glossary from entries in format, target is language without region, e.g. "EN"
+	CreateGlossary(ctx context.Context, name string, source, target SourceLang, entries string, format EntriesFormat) (*Glossary, error)
+	// Glossaries lists every glossary of account
+	Glossaries(ctx context.Context) ([]Glossary, error)
+	// GlossaryEntries returns entries of glossary with id, in TSV format
+	GlossaryEntries(ctx context.Context, id string) (string, error)
+	// DeleteGlossary removes glossary with id
+	DeleteGlossary(ctx context.Context, id string) error
+}
+
+var _ Glossarier = &DeeplerDefault{}
+
+// Base returns language without region, which is used by glossaries, e.g. SrcEnglish for TarEnglishBritish
+func (t TargetLang) Base() SourceLang {
+	base, _, _ := strings.Cut(string(t), "-")
+	return SourceLang(base)
+}
+
+// EncodeEntries returns entries in format, which can be passed to CreateGlossary
+func EncodeEntries(entries []GlossaryEntry, format EntriesFormat) (string, error) {
+	for _, e := range entries {
+		if err := validateEntry(e); err != nil {
+			return "", err
+		}
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case FormatTSV:
+		for _, e := range entries {
+			buf.WriteString(e.Source + "\t" + e.Target + "\n")
+		}
+	case FormatCSV:
+		w := csv.NewWriter(&buf)
+		for _, e := range entries {
+			if err := w.Write([]string{e.Source, e.Target}); err != nil {
+				return "", err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("%w: format %s", ErrInvalidOption, format)
+	}
+	return buf.String(), nil
+}
+
+// ParseEntries reads entries in format, e.g. returned by GlossaryEntries
+func ParseEntries(data string, format EntriesFormat) ([]GlossaryEntry, error) {
+	var records [][]string
+	switch format {
+	case FormatTSV:
+		for _, line := range strings.Split(data, "\n") {
+			line = strings.TrimSuffix(line, "\r")
+			if len(line) == 0 {
+				continue
+			}
+			records = append(records, strings.Split(line, "\t"))
+		}
+	case FormatCSV:
+		r := csv.NewReader(strings.NewReader(data))
+		r.FieldsPerRecord = -1
+		var err error
+		if records, err = r.ReadAll(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: format %s", ErrInvalidOption, format)
+	}
+
+	entries := make([]GlossaryEntry, 0, len(records))
+	for _, r := range records {
+		if len(r) != 2 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidEntry, r)
+		}
+		e := GlossaryEntry{Source: r[0], Target: r[1]}
+		if err := validateEntry(e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// validateEntry checks DeepL requirements: terms can't be empty, can't contain tabs nor new lines
+func validateEntry(e GlossaryEntry) error {
+	for _, term := range []string{e.Source, e.Target} {
+		if len(strings.TrimSpace(term)) == 0 || strings.ContainsAny(term, "\t\r\n") {
+			return fmt.Errorf("%w: %q -> %q", ErrInvalidEntry, e.Source, e.Target)
+		}
+	}
+	return nil
+}
+
+// CreateGlossary fulfills Glossarier interface. Glossary may be created even if DeepL responds with 5xx,
+// so only 429 is retried - otherwise retry could create duplicate
+func (a *DeeplerDefault) CreateGlossary(ctx context.Context, name string, source, target SourceLang, entries string, format EntriesFormat) (*Glossary, error) {
+	values := url.Values{
+		"name":           {name},
+		"source_lang":    {strings.ToLower(string(source))},
+		"target_lang":    {strings.ToLower(string(target))},
+		"entries":        {entries},
+		"entries_format": {string(format)},
+	}
+	req, err := a.request(ctx, http.MethodPost, "/v2/glossaries", strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := a.send(req, (*StatusError).rateLimited)
+	if err != nil {
+		return nil, err
+	}
+	g := &Glossary{}
+	if err := json.Unmarshal(body, g); err != nil {
+		return nil, fmt.Errorf("failed to parse json %w", err)
+	}
+	return g, nil
+}
+
+// Glossaries fulfills Glossarier interface
+func (a *DeeplerDefault) Glossaries(ctx context.Context) ([]Glossary, error) {
+	req, err := a.request(ctx, http.MethodGet, "/v2/glossaries", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := a.call(req)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Glossaries []Glossary `json:"glossaries"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse json %w", err)
+	}
+	return resp.Glossaries, nil
+}
+
+// GlossaryEntries fulfills Glossarier interface
+func (a *DeeplerDefault) GlossaryEntries(ctx context.Context, id string) (string, error) {
+	req, err := a.request(ctx, http.MethodGet, "/v2/glossaries/"+url.PathEscape(id)+"/entries", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/tab-separated-values")
+
+	body, err := a.call(req)
+	return string(body), err
+}
+
+// DeleteGlossary fulfills Glossarier interface
+func (a *DeeplerDefault) DeleteGlossary(ctx context.Context, id string) error {
+	req, err := a.request(ctx, http.MethodDelete, "/v2/glossaries/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	_, err = a.call(req)
+	return err
+}
@@ -0,0 +1,126 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package deepl_test
+
+import (
+	"context"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEncodeEntries(t *testing.T) {
+	entries := []deepl.GlossaryEntry{
+		{Source: "brain", Target: "mózg"},
+		{Source: "a, b", Target: `"c"`},
+	}
+
+	tsv, err := deepl.EncodeEntries(entries, deepl.FormatTSV)
+	require.Nil(t, err)
+	require.Equal(t, "brain\tmózg\na, b\t\"c\"\n", tsv)
+
+	csv, err := deepl.EncodeEntries(entries, deepl.FormatCSV)
+	require.Nil(t, err)
+	require.Equal(t, "brain,mózg\n\"a, b\",\"\"\"c\"\"\"\n", csv)
+
+	for _, format := range []deepl.EntriesFormat{deepl.FormatTSV, deepl.FormatCSV} {
+		data, err := deepl.EncodeEntries(entries, format)
+		require.Nil(t, err)
+		parsed, err := deepl.ParseEntries(data, format)
+		require.Nil(t, err)
+		require.Equal(t, entries, parsed, format)
+	}
+
+	for _, e := range []deepl.GlossaryEntry{{Source: "", Target: "x"}, {Source: "a\tb", Target: "x"}, {Source: "x", Target: "a\nb"}} {
+		_, err := deepl.EncodeEntries([]deepl.GlossaryEntry{e}, deepl.FormatTSV)
+		require.ErrorIs(t, err, deepl.ErrInvalidEntry)
+	}
+	_, err = deepl.ParseEntries("brain\n", deepl.FormatTSV)
+	require.ErrorIs(t, err, deepl.ErrInvalidEntry)
+	_, err = deepl.EncodeEntries(entries, "xml")
+	require.ErrorIs(t, err, deepl.ErrInvalidOption)
+}
+
+func TestDeeplerDefault_glossaries(t *testing.T) {
+	ctx := context.Background()
+	rt := &roundTripper{}
+	d := deepl.NewDeeplerDefault("key:fx", &http.Client{Transport: rt})
+
+	rt.status = http.StatusCreated
+	rt.body = `{"glossary_id":"id","name":"adam","ready":true,"source_lang":"en","target_lang":"pl","creation_time":"2022-09-01T10:00:00.000Z","entry_count":1}`
+	g, err := d.CreateGlossary(ctx, "adam", deepl.SrcEnglish, deepl.TarEnglishBritish.Base(), "brain\tmózg\n", deepl.FormatTSV)
+	require.Nil(t, err)
+	require.Equal(t, "id", g.ID)
+	require.Equal(t, 1, g.EntryCount)
+	require.Equal(t, http.MethodPost, rt.request.Method)
+	require.Equal(t, "/v2/glossaries", rt.request.URL.Path)
+	require.Equal(t, "en", rt.form.Get("source_lang"))
+	require.Equal(t, "en", rt.form.Get("target_lang"))
+	require.Equal(t, "brain\tmózg\n", rt.form.Get("entries"))
+	require.Equal(t, "tsv", rt.form.Get("entries_format"))
+
+	rt.status = http.StatusOK
+	rt.body = `{"glossaries":[{"glossary_id":"id","entry_count":1},{"glossary_id":"other"}]}`
+	list, err := d.Glossaries(ctx)
+	require.Nil(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, "other", list[1].ID)
+	require.Equal(t, http.MethodGet, rt.request.Method)
+
+	rt.body = "brain\tmózg\n"
+	entries, err := d.GlossaryEntries(ctx, "id")
+	require.Nil(t, err)
+	require.Equal(t, rt.body, entries)
+	require.Equal(t, "/v2/glossaries/id/entries", rt.request.URL.Path)
+	require.Equal(t, "text/tab-separated-values", rt.request.Header.Get("Accept"))
+
+	rt.status, rt.body = http.StatusNoContent, ""
+	require.Nil(t, d.DeleteGlossary(ctx, "id"))
+	require.Equal(t, http.MethodDelete, rt.request.Method)
+	require.Equal(t, "/v2/glossaries/id", rt.request.URL.Path)
+
+	rt.status, rt.body = http.StatusNotFound, `{"message":"Not found"}`
+	err = d.DeleteGlossary(ctx, "id")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Not found")
+}
+
+func TestDeeplerDefault_CreateGlossary_retry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		calls    int
+		err      error
+	}{
+		{
+			name:     "server error isn't retried",
+			statuses: []int{http.StatusBadGateway, http.StatusCreated},
+			calls:    1,
+			err:      deepl.ErrUpstream,
+		},
+		{
+			name:     "rate limit is retried",
+			statuses: []int{http.StatusTooManyRequests, http.StatusCreated},
+			calls:    2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &roundTripper{statuses: tt.statuses, body: `{"glossary_id":"id"}`}
+			d := deepl.NewDeeplerDefault("key:fx", &http.Client{Transport: rt})
+			d.SetRetry(deepl.Retry{Attempts: 3, Backoff: time.Millisecond})
+
+			_, err := d.CreateGlossary(context.Background(), "adam", deepl.SrcEnglish, deepl.TarPolish.Base(), "brain\tmózg\n", deepl.FormatTSV)
+			require.Equal(t, tt.calls, rt.calls)
+			if tt.err == nil {
+				require.Nil(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
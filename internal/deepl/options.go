@@ -0,0 +1,90 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package deepl
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Formality sets whether translation should lean towards formal or informal language
+type Formality string
+
+const (
+	FormalityDefault    Formality = "default"
+	FormalityMore       Formality = "more"
+	FormalityLess       Formality = "less"
+	FormalityPreferMore Formality = "prefer_more"
+	FormalityPreferLess Formality = "prefer_less"
+)
+
+// SplitSentences sets whether text should be split into sentences
+type SplitSentences string
+
+const (
+	SplitNone       SplitSentences = "0"
+	SplitAll        SplitSentences = "1"
+	SplitNoNewlines SplitSentences = "nonewlines"
+)
+
+// TagHandling sets which kind of tags should be handled
+type TagHandling string
+
+const (
+	TagXML  TagHandling = "xml"
+	TagHTML TagHandling = "html"
+)
+
+var ErrInvalidOption = errors.New("invalid option")
+
+// Options are optional parameters of translation, zero value of each field means DeepL default
+type Options struct {
+	Formality          Formality
+	SplitSentences     SplitSentences
+	PreserveFormatting bool
+	TagHandling        TagHandling
+	// Context is additional text, which influences translation, but is not translated itself
+	Context string
+	// GlossaryID is id of glossary used for translation, it requires SourceLang to be set
+	GlossaryID string
+}
+
+// validate checks if every field has one of allowed values
+func (o Options) validate() error {
+	switch o.Formality {
+	case "", FormalityDefault, FormalityMore, FormalityLess, FormalityPreferMore, FormalityPreferLess:
+	default:
+		return fmt.Errorf("%w: formality %s", ErrInvalidOption, o.Formality)
+	}
+	switch o.SplitSentences {
+	case "", SplitNone, SplitAll, SplitNoNewlines:
+	default:
+		return fmt.Errorf("%w: split_sentences %s", ErrInvalidOption, o.SplitSentences)
+	}
+	switch o.TagHandling {
+	case "", TagXML, TagHTML:
+	default:
+		return fmt.Errorf("%w: tag_handling %s", ErrInvalidOption, o.TagHandling)
+	}
+	return nil
+}
+
+// set adds non-zero options to values
+func (o Options) set(values url.Values) {
+	set := func(key, value string) {
+		if len(value) > 0 {
+			values.Set(key, value)
+		}
+	}
+	set("formality", string(o.Formality))
+	set("split_sentences", string(o.SplitSentences))
+	if o.PreserveFormatting {
+		values.Set("preserve_formatting", "1")
+	}
+	set("tag_handling", string(o.TagHandling))
+	set("context", o.Context)
+	set("glossary_id", o.GlossaryID)
+}
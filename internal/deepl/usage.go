@@ -0,0 +1,51 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package deepl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Usage is number of characters translated in current billing period, together with limit of account
+type Usage struct {
+	CharacterCount int64 `json:"character_count"`
+	CharacterLimit int64 `json:"character_limit"`
+}
+
+// Usager returns usage of DeepL account
+type Usager interface {
+	Usage(ctx context.Context) (*Usage, error)
+}
+
+var _ Usager = &DeeplerDefault{}
+
+// Left returns number of characters, which can still be translated in current billing period
+func (u Usage) Left() int64 {
+	if u.CharacterCount >= u.CharacterLimit {
+		return 0
+	}
+	return u.CharacterLimit - u.CharacterCount
+}
+
+// Usage fulfills Usager interface
+func (a *DeeplerDefault) Usage(ctx context.Context) (*Usage, error) {
+	req, err := a.request(ctx, http.MethodGet, "/v2/usage", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := a.call(req)
+	if err != nil {
+		return nil, err
+	}
+	u := &Usage{}
+	if err := json.Unmarshal(body, u); err != nil {
+		return nil, fmt.Errorf("failed to parse json %w", err)
+	}
+	return u, nil
+}
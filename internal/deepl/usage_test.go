@@ -0,0 +1,33 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package deepl_test
+
+import (
+	"context"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestDeeplerDefault_Usage(t *testing.T) {
+	rt := &roundTripper{body: `{"character_count":180118,"character_limit":500000}`}
+	d := deepl.NewDeeplerDefault("key:fx", &http.Client{Transport: rt})
+
+	u, err := d.Usage(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, &deepl.Usage{CharacterCount: 180118, CharacterLimit: 500000}, u)
+	require.EqualValues(t, 319882, u.Left())
+	require.Equal(t, http.MethodGet, rt.request.Method)
+	require.Equal(t, "/v2/usage", rt.request.URL.Path)
+	require.Equal(t, "DeepL-Auth-Key key:fx", rt.request.Header.Get("Authorization"))
+
+	require.EqualValues(t, 0, deepl.Usage{CharacterCount: 10, CharacterLimit: 5}.Left())
+
+	rt.status, rt.body = http.StatusForbidden, `{"message":"Wrong key"}`
+	_, err = d.Usage(context.Background())
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Wrong key")
+}
@@ -0,0 +1,163 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+// Package kaikki imports Wiktionary extracts made by https://kaikki.org (JSONL, one word entry per line) into Store,
+// which then serves as offline dictionary and thesaurus with the same responses as Merriam-Webster
+package kaikki
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/a-clap/logger"
+	"io"
+	"strings"
+)
+
+var Logger logger.Logger = logger.NewNop()
+
+var (
+	// ErrInvalid means input isn't valid Kaikki JSONL
+	ErrInvalid = errors.New("invalid kaikki entry")
+	// ErrIO is failure of Store
+	ErrIO = errors.New("kaikki io error")
+)
+
+// batch is number of entries passed to Store at once
+const batch = 1000
+
+// Entry is single word entry of Kaikki extract, only fields used by package are decoded,
+// see https://kaikki.org/dictionary/rawdata.html
+type Entry struct {
+	Word          string  `json:"word"`
+	Pos           string  `json:"pos"`
+	LangCode      string  `json:"lang_code"`
+	Senses        []Sense `json:"senses"`
+	Sounds        []Sound `json:"sounds"`
+	Synonyms      []Link  `json:"synonyms"`
+	Antonyms      []Link  `json:"antonyms"`
+	Forms         []Form  `json:"forms"`
+	EtymologyText string  `json:"etymology_text"`
+}
+
+// Sense is single meaning of Entry
+type Sense struct {
+	// Glosses are definitions, from the most general one, e.g. of parent sense
+	Glosses  []string  `json:"glosses"`
+	Examples []Example `json:"examples"`
+	Synonyms []Link    `json:"synonyms"`
+	Antonyms []Link    `json:"antonyms"`
+	Related  []Link    `json:"related"`
+	Tags     []string  `json:"tags"`
+}
+
+// Example is usage example of Sense
+type Example struct {
+	Text string `json:"text"`
+}
+
+// Link points to other word. Links of Entry may name gloss of Sense, which they belong to
+type Link struct {
+	Word  string `json:"word"`
+	Sense string `json:"sense"`
+}
+
+// Sound is pronunciation of Entry, either IPA or audio file
+type Sound struct {
+	Ipa  string   `json:"ipa"`
+	Tags []string `json:"tags"`
+}
+
+// Form is inflected form of Entry, e.g. plural
+type Form struct {
+	Form string   `json:"form"`
+	Tags []string `json:"tags"`
+}
+
+// Store keeps imported entries, indexed by case-insensitive word
+type Store interface {
+	// Replace replaces every entry with entries, which fill passes to add, in order.
+	// Replacement must be atomic: if fill or add returns error, previous entries are kept untouched
+	Replace(fill func(add func(entries []Entry) error) error) error
+	// Entries returns entries of word in order, in which they were added
+	Entries(word string) ([]Entry, error)
+}
+
+// Import replaces content of s with entries read from r. Only entries with langCode are kept, unless it is empty.
+// Returns number of imported entries. On error nothing is imported, s keeps its previous content
+func Import(r io.Reader, s Store, langCode string) (int, error) {
+	var (
+		count   int
+		readErr error
+	)
+	err := s.Replace(func(add func(entries []Entry) error) error {
+		count, readErr = read(r, add, langCode)
+		return readErr
+	})
+	if readErr != nil {
+		return 0, readErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: Replace: %v", ErrIO, err)
+	}
+	Logger.Infof("imported %d entries", count)
+	return count, nil
+}
+
+// read passes entries read from r to add in batches, returns number of entries passed
+func read(r io.Reader, add func(entries []Entry) error, langCode string) (int, error) {
+	var (
+		entries []Entry
+		count   int
+	)
+	flush := func() error {
+		if len(entries) == 0 {
+			return nil
+		}
+		if err := add(entries); err != nil {
+			return fmt.Errorf("%w: Add: %v", ErrIO, err)
+		}
+		count += len(entries)
+		entries = entries[:0]
+		return nil
+	}
+
+	// Single line may be much longer than bufio.Scanner accepts by default
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return count, fmt.Errorf("read line %d: %w", line, err)
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			var e Entry
+			if jsonErr := json.Unmarshal(data, &e); jsonErr != nil {
+				return count, fmt.Errorf("%w: line %d: %v", ErrInvalid, line, jsonErr)
+			}
+			if len(e.Word) > 0 && (len(langCode) == 0 || strings.EqualFold(e.LangCode, langCode)) {
+				entries = append(entries, e)
+			}
+			if len(entries) == batch {
+				if err := flush(); err != nil {
+					return count, err
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	if err := flush(); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// key is index of word in Store
+func key(word string) string {
+	return strings.ToLower(strings.TrimSpace(word))
+}
@@ -0,0 +1,157 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package kaikki_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/kaikki"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// extract is shortened Kaikki JSONL, with entry of other language and synonyms on both levels
+const extract = `{"word": "brain", "pos": "noun", "lang_code": "en", "etymology_text": "From Middle English brayn.", "sounds": [{"ipa": "/bɹeɪn/"}, {"audio": "en-us-brain.ogg"}, {"ipa": "/bɹeɪn/", "tags": ["UK"]}], "forms": [{"form": "brains", "tags": ["plural"]}, {"form": "en-noun", "tags": ["inflection-template"]}], "synonyms": [{"word": "mind", "sense": "intellect"}, {"word": "encephalon"}], "senses": [{"glosses": ["The control center of the central nervous system."], "examples": [{"text": "The brain is protected by the skull."}]}, {"glosses": ["Intellect."], "tags": ["figuratively"], "synonyms": [{"word": "intelligence"}], "antonyms": [{"word": "stupidity"}]}, {"glosses": ["Intellect.", "A very smart person."], "tags": ["informal"]}]}
+{"word": "brain", "pos": "verb", "lang_code": "en", "forms": [{"form": "brains"}, {"form": "braining"}, {"form": "brained"}], "senses": [{"glosses": ["To dash out the brains of."]}]}
+{"word": "mózg", "pos": "noun", "lang_code": "pl", "senses": [{"glosses": ["brain"]}]}
+
+{"word": "Brain", "pos": "name", "lang_code": "en", "senses": [{"glosses": ["A surname."]}]}
+`
+
+func stores(t *testing.T) map[string]kaikki.Store {
+	s, err := kaikki.NewSQLiteStore(filepath.Join(t.TempDir(), "kaikki.db"))
+	require.Nil(t, err)
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+	return map[string]kaikki.Store{
+		"memory": kaikki.NewMemoryStore(),
+		"sqlite": s,
+	}
+}
+
+func TestImport(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			n, err := kaikki.Import(strings.NewReader(extract), s, "en")
+			require.Nil(t, err)
+			require.Equal(t, 3, n)
+
+			entries, err := s.Entries(" BRAIN ")
+			require.Nil(t, err)
+			require.Len(t, entries, 3)
+			require.Equal(t, "noun", entries[0].Pos)
+			require.Equal(t, "verb", entries[1].Pos)
+			require.Equal(t, "Brain", entries[2].Word)
+			require.Equal(t, []string{"Intellect.", "A very smart person."}, entries[0].Senses[2].Glosses)
+			require.Equal(t, "mind", entries[0].Synonyms[0].Word)
+
+			entries, err = s.Entries("mózg")
+			require.Nil(t, err)
+			require.Empty(t, entries)
+
+			// Import replaces previous entries
+			n, err = kaikki.Import(strings.NewReader(extract), s, "")
+			require.Nil(t, err)
+			require.Equal(t, 4, n)
+			entries, err = s.Entries("brain")
+			require.Nil(t, err)
+			require.Len(t, entries, 3)
+			entries, err = s.Entries("mózg")
+			require.Nil(t, err)
+			require.Len(t, entries, 1)
+		})
+	}
+}
+
+func TestImport_invalid(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			_, err := kaikki.Import(strings.NewReader(extract), s, "")
+			require.Nil(t, err)
+
+			// Invalid line after many batches doesn't leave partial content behind
+			truncated := strings.Repeat(`{"word": "cerebrum", "lang_code": "en"}`+"\n", 2500) + "{\"word\": \n"
+			n, err := kaikki.Import(strings.NewReader(truncated), s, "en")
+			require.True(t, errors.Is(err, kaikki.ErrInvalid), err)
+			require.Contains(t, err.Error(), "line 2501")
+			require.Zero(t, n)
+
+			entries, err := s.Entries("cerebrum")
+			require.Nil(t, err)
+			require.Empty(t, entries)
+			entries, err = s.Entries("mózg")
+			require.Nil(t, err)
+			require.Len(t, entries, 1)
+		})
+	}
+}
+
+// failingReader fails after returning data
+type failingReader struct {
+	data string
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if len(f.data) == 0 {
+		return 0, fmt.Errorf("connection reset")
+	}
+	n := copy(p, f.data)
+	f.data = f.data[n:]
+	return n, nil
+}
+
+func TestImport_read(t *testing.T) {
+	s := kaikki.NewMemoryStore()
+	_, err := kaikki.Import(strings.NewReader(extract), s, "en")
+	require.Nil(t, err)
+
+	_, err = kaikki.Import(&failingReader{data: extract}, s, "")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "connection reset")
+	entries, err := s.Entries("brain")
+	require.Nil(t, err)
+	require.Len(t, entries, 3)
+}
+
+// failingStore fails every Replace on its add
+type failingStore struct {
+	*kaikki.MemoryStore
+}
+
+func (f failingStore) Replace(fill func(add func(entries []kaikki.Entry) error) error) error {
+	return fill(func([]kaikki.Entry) error {
+		return fmt.Errorf("disk full")
+	})
+}
+
+func TestImport_store(t *testing.T) {
+	_, err := kaikki.Import(strings.NewReader(extract), failingStore{kaikki.NewMemoryStore()}, "en")
+	require.True(t, errors.Is(err, kaikki.ErrIO), err)
+}
+
+func TestSQLiteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kaikki.db")
+	s, err := kaikki.NewSQLiteStore(path)
+	require.Nil(t, err)
+	_, err = kaikki.Import(strings.NewReader(extract), s, "en")
+	require.Nil(t, err)
+	require.Nil(t, s.Close())
+
+	// Entries survive reopening
+	s, err = kaikki.NewSQLiteStore(path)
+	require.Nil(t, err)
+	defer func() {
+		_ = s.Close()
+	}()
+	entries, err := s.Entries("brain")
+	require.Nil(t, err)
+	require.Len(t, entries, 3)
+
+	_, err = kaikki.NewSQLiteStore(filepath.Join(t.TempDir(), "not", "existing", "dir.db"))
+	require.NotNil(t, err)
+}
@@ -0,0 +1,49 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package kaikki
+
+import (
+	"sync"
+)
+
+var _ Store = &MemoryStore{}
+
+// MemoryStore satisfies Store interface, keeps everything in memory
+type MemoryStore struct {
+	mtx     sync.RWMutex
+	entries map[string][]Entry
+}
+
+// NewMemoryStore is default constructor for MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string][]Entry)}
+}
+
+// Replace fulfills Store interface, entries are collected aside and swapped in only when fill succeeds
+func (m *MemoryStore) Replace(fill func(add func(entries []Entry) error) error) error {
+	replaced := make(map[string][]Entry)
+	err := fill(func(entries []Entry) error {
+		for _, e := range entries {
+			k := key(e.Word)
+			replaced[k] = append(replaced[k], e)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.entries = replaced
+	return nil
+}
+
+// Entries fulfills Store interface
+func (m *MemoryStore) Entries(word string) ([]Entry, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	return append([]Entry(nil), m.entries[key(word)]...), nil
+}
@@ -0,0 +1,375 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package kaikki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/merriamw/dictionary"
+	"github.com/a-clap/dictionary/internal/merriamw/thesaurus"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ dictionary.Definitioner = &Definitioner{}
+	_ thesaurus.Thesauruser   = &Thesauruser{}
+)
+
+// shortdefs is maximum number of short definitions of entry, the same as Merriam-Webster returns
+const shortdefs = 3
+
+var (
+	// offensiveTags mark sense, which makes whole entry offensive
+	offensiveTags = []string{"offensive", "vulgar", "derogatory", "slur"}
+	// formTags mark forms, which aren't inflections of word, but data of Wiktionary templates
+	formTags = []string{"table-tags", "inflection-template", "class", "canonical", "romanization"}
+)
+
+// Definitioner responds with entries of Store in format of Merriam-Webster's Collegiate Dictionary,
+// so it can be used by dictionary.NewDictionary
+type Definitioner struct {
+	store Store
+}
+
+// Thesauruser responds with entries of Store in format of Merriam-Webster's Thesaurus, so it can be used by thesaurus.NewThesaurus
+type Thesauruser struct {
+	store Store
+}
+
+// NewDefinitioner is default constructor for Definitioner
+func NewDefinitioner(s Store) *Definitioner {
+	return &Definitioner{store: s}
+}
+
+// NewThesauruser is default constructor for Thesauruser
+func NewThesauruser(s Store) *Thesauruser {
+	return &Thesauruser{store: s}
+}
+
+// Get fulfills dictionary.Definitioner interface. Unknown text results in empty array, as there are no suggestions
+func (d *Definitioner) Get(ctx context.Context, text string) ([]byte, error) {
+	entries, err := entries(ctx, d.store, text)
+	if err != nil {
+		return nil, err
+	}
+	definitions := make([]mwDefinition, len(entries))
+	for i, e := range entries {
+		id := e.Word
+		// Homographs are numbered, like on Merriam-Webster
+		if len(entries) > 1 {
+			id += ":" + strconv.Itoa(i+1)
+		}
+		definitions[i] = definition(id, e)
+	}
+	return json.Marshal(definitions)
+}
+
+// Get fulfills thesaurus.Thesauruser interface. Only entries with synonyms, antonyms or related words are returned
+func (t *Thesauruser) Get(ctx context.Context, text string) ([]byte, error) {
+	entries, err := entries(ctx, t.store, text)
+	if err != nil {
+		return nil, err
+	}
+	words := make([]mwWord, 0, len(entries))
+	for _, e := range entries {
+		if w, ok := word(e); ok {
+			words = append(words, w)
+		}
+	}
+	return json.Marshal(words)
+}
+
+func entries(ctx context.Context, s Store, text string) ([]Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	e, err := s.Entries(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: Entries: %v", ErrIO, err)
+	}
+	return e, nil
+}
+
+// mwDefinition is marshalled to format parsed by dictionary.Definition
+type mwDefinition struct {
+	Meta struct {
+		Id        string   `json:"id"`
+		Stems     []string `json:"stems"`
+		Offensive bool     `json:"offensive"`
+	} `json:"meta"`
+	Hwi struct {
+		Hw  string `json:"hw"`
+		Prs []struct {
+			Ipa string `json:"ipa"`
+		} `json:"prs,omitempty"`
+	} `json:"hwi"`
+	Fl  string  `json:"fl"`
+	Def []mwDef `json:"def,omitempty"`
+	Ins []struct {
+		If string `json:"if"`
+	} `json:"ins,omitempty"`
+	Et    [][2]string `json:"et,omitempty"`
+	Suppl struct {
+		Examples []mwText `json:"examples,omitempty"`
+	} `json:"suppl"`
+	Shortdef []string `json:"shortdef"`
+}
+
+// mwWord is marshalled to format parsed by thesaurus.Word
+type mwWord struct {
+	Meta struct {
+		Id        string     `json:"id"`
+		Syns      [][]string `json:"syns"`
+		Ants      [][]string `json:"ants"`
+		Offensive bool       `json:"offensive"`
+	} `json:"meta"`
+	Fl       string   `json:"fl"`
+	Def      []mwDef  `json:"def"`
+	Shortdef []string `json:"shortdef"`
+}
+
+type mwDef struct {
+	// Sseq is array of sequences, each of them is array of ["sense", mwSense] pairs
+	Sseq [][][2]interface{} `json:"sseq"`
+}
+
+type mwSense struct {
+	Sn      string             `json:"sn,omitempty"`
+	Lbs     []string           `json:"lbs,omitempty"`
+	Dt      [][2]interface{}   `json:"dt,omitempty"`
+	SynList [][]mwThesaurusRef `json:"syn_list,omitempty"`
+	RelList [][]mwThesaurusRef `json:"rel_list,omitempty"`
+	AntList [][]mwThesaurusRef `json:"ant_list,omitempty"`
+}
+
+type mwThesaurusRef struct {
+	Wd string `json:"wd"`
+}
+
+type mwText struct {
+	T string `json:"t"`
+}
+
+// definition converts e to dictionary entry
+func definition(id string, e Entry) mwDefinition {
+	var d mwDefinition
+	d.Meta.Id = id
+	d.Meta.Stems = []string{e.Word}
+	d.Meta.Offensive = offensive(e)
+	d.Hwi.Hw = e.Word
+	d.Fl = e.Pos
+	for _, ipa := range ipas(e) {
+		d.Hwi.Prs = append(d.Hwi.Prs, struct {
+			Ipa string `json:"ipa"`
+		}{Ipa: ipa})
+	}
+	for _, form := range inflections(e) {
+		d.Ins = append(d.Ins, struct {
+			If string `json:"if"`
+		}{If: form})
+		d.Meta.Stems = appendUnique(d.Meta.Stems, form)
+	}
+	if len(e.EtymologyText) > 0 {
+		d.Et = [][2]string{{"text", e.EtymologyText}}
+	}
+
+	var sseq [][][2]interface{}
+	d.Shortdef = []string{}
+	for i, s := range e.Senses {
+		text := gloss(s)
+		if len(text) == 0 {
+			continue
+		}
+		if len(d.Shortdef) < shortdefs {
+			d.Shortdef = append(d.Shortdef, text)
+		}
+
+		dt := [][2]interface{}{{"text", text}}
+		if examples := examples(s); len(examples) > 0 {
+			dt = append(dt, [2]interface{}{"vis", examples})
+			d.Suppl.Examples = append(d.Suppl.Examples, examples...)
+		}
+		sseq = append(sseq, [][2]interface{}{{"sense", mwSense{Sn: strconv.Itoa(i + 1), Lbs: s.Tags, Dt: dt}}})
+	}
+	if len(sseq) > 0 {
+		d.Def = []mwDef{{Sseq: sseq}}
+	}
+	return d
+}
+
+// word converts e to thesaurus entry, false means e has no word lists
+func word(e Entry) (mwWord, bool) {
+	var w mwWord
+	w.Meta.Id = e.Word
+	w.Meta.Syns = [][]string{}
+	w.Meta.Ants = [][]string{}
+	w.Meta.Offensive = offensive(e)
+	w.Fl = e.Pos
+	w.Shortdef = []string{}
+
+	// Entry-level links name gloss of their sense, they belong to the first sense otherwise
+	synonyms, antonyms := make([][]string, len(e.Senses)), make([][]string, len(e.Senses))
+	for i, s := range e.Senses {
+		synonyms[i] = links(s.Synonyms)
+		antonyms[i] = links(s.Antonyms)
+	}
+	for _, l := range e.Synonyms {
+		if i := senseOf(e, l); i >= 0 {
+			synonyms[i] = appendUnique(synonyms[i], l.Word)
+		}
+	}
+	for _, l := range e.Antonyms {
+		if i := senseOf(e, l); i >= 0 {
+			antonyms[i] = appendUnique(antonyms[i], l.Word)
+		}
+	}
+
+	var sseq [][][2]interface{}
+	for i, s := range e.Senses {
+		related := links(s.Related)
+		if len(synonyms[i]) == 0 && len(antonyms[i]) == 0 && len(related) == 0 {
+			continue
+		}
+		text := gloss(s)
+		w.Shortdef = append(w.Shortdef, text)
+		w.Meta.Syns = append(w.Meta.Syns, nonNil(synonyms[i]))
+		w.Meta.Ants = append(w.Meta.Ants, nonNil(antonyms[i]))
+
+		sn := mwSense{
+			Sn:      strconv.Itoa(len(sseq) + 1),
+			Dt:      [][2]interface{}{{"text", text}},
+			SynList: wordList(synonyms[i]),
+			RelList: wordList(related),
+			AntList: wordList(antonyms[i]),
+		}
+		if examples := examples(s); len(examples) > 0 {
+			sn.Dt = append(sn.Dt, [2]interface{}{"vis", examples})
+		}
+		sseq = append(sseq, [][2]interface{}{{"sense", sn}})
+	}
+	if len(sseq) == 0 {
+		return mwWord{}, false
+	}
+	w.Def = []mwDef{{Sseq: sseq}}
+	return w, true
+}
+
+// gloss returns the most specific gloss of s, the first ones belong to parent senses
+func gloss(s Sense) string {
+	if len(s.Glosses) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(s.Glosses[len(s.Glosses)-1])
+}
+
+func examples(s Sense) []mwText {
+	var examples []mwText
+	for _, elem := range s.Examples {
+		if text := strings.TrimSpace(elem.Text); len(text) > 0 {
+			examples = append(examples, mwText{T: text})
+		}
+	}
+	return examples
+}
+
+// senseOf returns index of sense, which gloss is named by l. The first sense with gloss is returned, if l doesn't name any,
+// -1 if there is none
+func senseOf(e Entry, l Link) int {
+	first := -1
+	for i, s := range e.Senses {
+		text := gloss(s)
+		if len(text) == 0 {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		if len(l.Sense) > 0 && strings.Contains(strings.ToLower(text), strings.ToLower(l.Sense)) {
+			return i
+		}
+	}
+	return first
+}
+
+func ipas(e Entry) []string {
+	var ipas []string
+	for _, s := range e.Sounds {
+		if len(s.Ipa) > 0 {
+			ipas = appendUnique(ipas, s.Ipa)
+		}
+	}
+	return ipas
+}
+
+func inflections(e Entry) []string {
+	var forms []string
+	for _, f := range e.Forms {
+		if len(f.Form) == 0 || f.Form == e.Word || hasTag(f.Tags, formTags...) {
+			continue
+		}
+		forms = appendUnique(forms, f.Form)
+	}
+	return forms
+}
+
+func offensive(e Entry) bool {
+	for _, s := range e.Senses {
+		if hasTag(s.Tags, offensiveTags...) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTag(tags []string, wanted ...string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func links(l []Link) []string {
+	var words []string
+	for _, elem := range l {
+		if len(elem.Word) > 0 {
+			words = appendUnique(words, elem.Word)
+		}
+	}
+	return words
+}
+
+// wordList puts every word into separate group, Kaikki doesn't group them
+func wordList(words []string) [][]mwThesaurusRef {
+	if len(words) == 0 {
+		return nil
+	}
+	list := make([][]mwThesaurusRef, len(words))
+	for i, elem := range words {
+		list[i] = []mwThesaurusRef{{Wd: elem}}
+	}
+	return list
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, elem := range list {
+		if elem == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -0,0 +1,109 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package kaikki_test
+
+import (
+	"context"
+	"github.com/a-clap/dictionary/internal/kaikki"
+	"github.com/a-clap/dictionary/internal/merriamw/dictionary"
+	"github.com/a-clap/dictionary/internal/merriamw/thesaurus"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func importExtract(t *testing.T) kaikki.Store {
+	s := kaikki.NewMemoryStore()
+	_, err := kaikki.Import(strings.NewReader(extract), s, "en")
+	require.Nil(t, err)
+	return s
+}
+
+func TestDefinitioner(t *testing.T) {
+	d := dictionary.NewDictionary(kaikki.NewDefinitioner(importExtract(t)))
+
+	defs, suggestions, err := d.Definition(context.Background(), "brain")
+	require.Nil(t, err)
+	require.Nil(t, suggestions)
+	require.Len(t, defs, 3)
+
+	noun := defs[0]
+	require.Equal(t, "brain:1", noun.Meta.Id)
+	require.Equal(t, "brain", noun.Text())
+	require.Equal(t, "noun", noun.Function())
+	require.False(t, noun.IsOffensive())
+	require.Equal(t, []string{"The control center of the central nervous system.", "Intellect.", "A very smart person."}, noun.Definition())
+	require.Equal(t, []string{"The brain is protected by the skull."}, noun.Examples())
+	require.Equal(t, []string{"brains"}, noun.Inflections())
+	require.Equal(t, []string{"brain", "brains"}, noun.Stems())
+	require.Equal(t, []string{"From Middle English brayn."}, noun.Etymology())
+	require.Equal(t, []dictionary.Pronunciation{{PhoneticNotation: "/bɹeɪn/"}}, noun.Audio())
+
+	senses := noun.Senses().Senses()
+	require.Len(t, senses, 3)
+	require.Equal(t, "1", senses[0].Number)
+	require.Equal(t, []string{"The brain is protected by the skull."}, senses[0].Examples)
+	require.Equal(t, []string{"figuratively"}, senses[1].Labels)
+
+	require.Equal(t, "verb", defs[1].Function())
+	require.Equal(t, []string{"brains", "braining", "brained"}, defs[1].Inflections())
+	require.Equal(t, "Brain", defs[2].Text())
+
+	defs, suggestions, err = d.Definition(context.Background(), "cerebrum")
+	require.Nil(t, err)
+	require.Nil(t, suggestions)
+	require.Empty(t, defs)
+}
+
+func TestThesauruser(t *testing.T) {
+	th := thesaurus.NewThesaurus(kaikki.NewThesauruser(importExtract(t)))
+
+	words, err := th.Translate(context.Background(), "brain")
+	require.Nil(t, err)
+	// Verb and name have no synonyms
+	require.Len(t, words, 1)
+	w := words[0]
+	require.Equal(t, "brain", w.Text())
+	require.Equal(t, "noun", w.Function())
+	require.Equal(t, [][]string{{"encephalon"}, {"intelligence", "mind"}}, w.Synonyms())
+	require.Equal(t, [][]string{{}, {"stupidity"}}, w.Antonyms())
+
+	senses := w.Senses()
+	require.Len(t, senses, 2)
+	require.Equal(t, "The control center of the central nervous system.", senses[0].Shortdef)
+	require.Equal(t, []string{"The brain is protected by the skull."}, senses[0].Examples)
+	require.Equal(t, []string{"intelligence", "mind"}, senses[1].Synonyms)
+	require.Equal(t, []string{"stupidity"}, senses[1].Antonyms)
+
+	words, err = th.Translate(context.Background(), "cerebrum")
+	require.Nil(t, err)
+	require.Empty(t, words)
+}
+
+func TestMonolingual(t *testing.T) {
+	s := importExtract(t)
+	m := translator.NewMerriamWebster(
+		dictionary.NewDictionary(kaikki.NewDefinitioner(s)),
+		thesaurus.NewThesaurus(kaikki.NewThesauruser(s)),
+	)
+
+	dict, suggestions, err := m.Define(context.Background(), "brain")
+	require.Nil(t, err)
+	require.Nil(t, suggestions)
+	require.Len(t, dict.Defs, 2)
+	require.Equal(t, []string{"Brain"}, dict.Synonyms)
+	require.Equal(t, "To dash out the brains of.", dict.Defs[1].Senses[0][0].Text)
+
+	entries, err := m.Thesaurus(context.Background(), "brain")
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "Intellect.", entries[0].Senses[1].Definition)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = m.Define(ctx, "brain")
+	require.NotNil(t, err)
+}
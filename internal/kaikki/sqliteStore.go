@@ -0,0 +1,107 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package kaikki
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/a-clap/dictionary/internal/sqlitedb"
+)
+
+var _ Store = &SQLiteStore{}
+
+// migrations of entries table, applied by sqlitedb.Open
+var migrations = []string{
+	`CREATE TABLE entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		key TEXT NOT NULL,
+		data BLOB NOT NULL
+	);
+	CREATE INDEX entries_key ON entries (key);`,
+}
+
+// SQLiteStore satisfies Store interface, keeps entries in sqlite database, so they are imported only once
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) database at path and migrates it to the newest schema
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sqlitedb.Open(path, migrations)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Close closes underlying database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// Replace fulfills Store interface, old entries are deleted and new ones added in single transaction,
+// which is rolled back on any error
+func (s *SQLiteStore) Replace(fill func(add func(entries []Entry) error) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM entries`); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO entries (key, data) VALUES (?, ?)`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	err = fill(func(entries []Entry) error {
+		for _, e := range entries {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if _, err := stmt.Exec(key(e.Word), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		err = stmt.Close()
+	}
+	if err != nil {
+		_ = stmt.Close()
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Entries fulfills Store interface
+func (s *SQLiteStore) Entries(word string) ([]Entry, error) {
+	rows, err := s.db.Query(`SELECT data FROM entries WHERE key = ? ORDER BY id`, key(word))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var entries []Entry
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,148 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+// Package audio fetches Merriam-Webster pronunciations and keeps them on disk, see https://www.dictionaryapi.com/products/json#sec-2.prs
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/a-clap/logger"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var Logger logger.Logger = logger.NewNop()
+
+var (
+	// ErrInvalid means filename or format is not valid
+	ErrInvalid = errors.New("invalid audio")
+	// ErrNotFound means Merriam-Webster doesn't have requested file
+	ErrNotFound = errors.New("audio not found")
+	// ErrUpstream is any other failure of Merriam-Webster
+	ErrUpstream = errors.New("audio upstream error")
+	// ErrIO is failure of cache
+	ErrIO = errors.New("audio io error")
+)
+
+// DefaultTimeout is used by http.Client of DefaultFetcher, when none is provided
+const DefaultTimeout = 10 * time.Second
+
+// Format of audio file, MW provides every pronunciation in each of them
+type Format string
+
+const (
+	MP3 Format = "mp3"
+	OGG Format = "ogg"
+	WAV Format = "wav"
+)
+
+// Formats lists every supported Format, MP3 is the default one
+var Formats = []Format{MP3, OGG, WAV}
+
+// ParseFormat returns Format for case-insensitive name, e.g. "OGG"
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(name, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w: format %s", ErrInvalid, name)
+}
+
+// ContentType returns MIME type of format
+func (f Format) ContentType() string {
+	switch f {
+	case OGG:
+		return "audio/ogg"
+	case WAV:
+		return "audio/wav"
+	default:
+		return "audio/mpeg"
+	}
+}
+
+// URL returns address of filename in format on MW server, filename is "audio" of pronunciation, e.g. "brain001"
+func URL(filename string, format Format) string {
+	const AudioUrl = `https://media.merriam-webster.com/audio/prons/en/us/%s/%s/%s.%s`
+	return fmt.Sprintf(AudioUrl, format, subdirectory(filename), filename, format)
+}
+
+// subdirectory returns directory of filename on MW server
+func subdirectory(filename string) string {
+	switch {
+	case len(filename) == 0:
+		return ""
+	case strings.HasPrefix(filename, "bix"):
+		return "bix"
+	case strings.HasPrefix(filename, "gg"):
+		return "gg"
+	case unicode.IsNumber(rune(filename[0])) || unicode.IsPunct(rune(filename[0])):
+		return "number"
+	default:
+		return string(filename[0])
+	}
+}
+
+// Validate checks, whether filename looks like MW one, so it can be safely used as path
+func Validate(filename string) error {
+	if len(filename) == 0 {
+		return fmt.Errorf("%w: empty filename", ErrInvalid)
+	}
+	for _, r := range filename {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			return fmt.Errorf("%w: filename %s", ErrInvalid, filename)
+		}
+	}
+	return nil
+}
+
+// Fetcher downloads audio files from upstream
+type Fetcher interface {
+	Fetch(ctx context.Context, filename string, format Format) (io.ReadCloser, error)
+}
+
+// DefaultFetcher downloads audio files from MW server
+type DefaultFetcher struct {
+	client *http.Client
+}
+
+// NewDefaultFetcher is default constructor for DefaultFetcher,
+// if client is nil, new one with DefaultTimeout is used
+func NewDefaultFetcher(client *http.Client) *DefaultFetcher {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &DefaultFetcher{client: client}
+}
+
+// Fetch fulfills Fetcher interface, caller must close returned body
+func (d *DefaultFetcher) Fetch(ctx context.Context, filename string, format Format) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL(filename, format), nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request failed %v", err)
+	}
+
+	response, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: get failed %v", ErrUpstream, err)
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return response.Body, nil
+	case http.StatusNotFound:
+		err = fmt.Errorf("%w: %s.%s", ErrNotFound, filename, format)
+	default:
+		err = fmt.Errorf("%w: status code %s", ErrUpstream, response.Status)
+	}
+	if err := response.Body.Close(); err != nil {
+		Logger.Debugf("error on Body.Close() %#v", err)
+	}
+	return nil, err
+}
@@ -0,0 +1,83 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package audio_test
+
+import (
+	"context"
+	"github.com/a-clap/dictionary/internal/merriamw/audio"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// roundTripper records request and responds with status and body
+type roundTripper struct {
+	request *http.Request
+	status  int
+	body    string
+}
+
+func (r *roundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	r.request = request
+	return &http.Response{
+		StatusCode: r.status,
+		Status:     http.StatusText(r.status),
+		Body:       io.NopCloser(strings.NewReader(r.body)),
+		Request:    request,
+	}, nil
+}
+
+func TestURL(t *testing.T) {
+	tests := []struct {
+		filename string
+		format   audio.Format
+		want     string
+	}{
+		{filename: "brain001", format: audio.MP3, want: "https://media.merriam-webster.com/audio/prons/en/us/mp3/b/brain001.mp3"},
+		{filename: "bixbrain", format: audio.OGG, want: "https://media.merriam-webster.com/audio/prons/en/us/ogg/bix/bixbrain.ogg"},
+		{filename: "ggbrain", format: audio.WAV, want: "https://media.merriam-webster.com/audio/prons/en/us/wav/gg/ggbrain.wav"},
+		{filename: "3d000001", format: audio.MP3, want: "https://media.merriam-webster.com/audio/prons/en/us/mp3/number/3d000001.mp3"},
+		{filename: "_brain", format: audio.MP3, want: "https://media.merriam-webster.com/audio/prons/en/us/mp3/number/_brain.mp3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			require.Equal(t, tt.want, audio.URL(tt.filename, tt.format))
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := audio.ParseFormat("OGG")
+	require.Nil(t, err)
+	require.Equal(t, audio.OGG, f)
+	require.Equal(t, "audio/ogg", f.ContentType())
+	require.Equal(t, "audio/mpeg", audio.MP3.ContentType())
+
+	_, err = audio.ParseFormat("flac")
+	require.ErrorIs(t, err, audio.ErrInvalid)
+}
+
+func TestDefaultFetcher_Fetch(t *testing.T) {
+	rt := &roundTripper{status: http.StatusOK, body: "ID3"}
+	f := audio.NewDefaultFetcher(&http.Client{Transport: rt})
+
+	body, err := f.Fetch(context.Background(), "brain001", audio.OGG)
+	require.Nil(t, err)
+	data, err := io.ReadAll(body)
+	require.Nil(t, err)
+	require.Nil(t, body.Close())
+	require.Equal(t, "ID3", string(data))
+	require.Equal(t, "https://media.merriam-webster.com/audio/prons/en/us/ogg/b/brain001.ogg", rt.request.URL.String())
+
+	rt.status = http.StatusNotFound
+	_, err = f.Fetch(context.Background(), "brain001", audio.OGG)
+	require.ErrorIs(t, err, audio.ErrNotFound)
+
+	rt.status = http.StatusBadGateway
+	_, err = f.Fetch(context.Background(), "brain001", audio.OGG)
+	require.ErrorIs(t, err, audio.ErrUpstream)
+}
@@ -0,0 +1,215 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package audio
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ Opener = &Cache{}
+
+// MaxSize is the biggest audio file, which is accepted from upstream
+const MaxSize = 10 << 20
+
+// Opener provides audio files, which can be streamed with range requests
+type Opener interface {
+	OpenAudio(ctx context.Context, filename string, format Format) (*File, error)
+}
+
+// File is opened audio file, caller must close it
+type File struct {
+	io.ReadSeekCloser
+	// Hash is hex encoded SHA-256 of content
+	Hash    string
+	ModTime time.Time
+}
+
+// Cache is content-addressed disk store of audio files. Files are kept in dir/objects under hash of their content,
+// dir/index maps filename and format to that hash. Pronunciations don't change, so they never expire
+type Cache struct {
+	dir     string
+	fetcher Fetcher
+}
+
+// NewCache is default constructor for Cache, creates dir if needed
+func NewCache(dir string, fetcher Fetcher) (*Cache, error) {
+	for _, sub := range []string{"objects", "index"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("create audio dir: %w", err)
+		}
+	}
+	return &Cache{dir: dir, fetcher: fetcher}, nil
+}
+
+// OpenAudio fulfills Opener interface, file is fetched from upstream, unless it is already cached
+func (c *Cache) OpenAudio(ctx context.Context, filename string, format Format) (*File, error) {
+	if err := Validate(filename); err != nil {
+		return nil, err
+	}
+	if _, err := ParseFormat(string(format)); err != nil {
+		return nil, err
+	}
+
+	hash, err := c.lookup(filename, format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: lookup %s.%s: %v", ErrIO, filename, format, err)
+	}
+	if len(hash) > 0 {
+		f, err := c.open(hash)
+		if err == nil {
+			return f, nil
+		}
+		// Object might have been removed by hand, it is fetched again
+		Logger.Errorf("failed to open %s.%s: %v", filename, format, err)
+	}
+
+	if hash, err = c.fetch(ctx, filename, format); err != nil {
+		return nil, err
+	}
+	f, err := c.open(hash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: open %s.%s: %v", ErrIO, filename, format, err)
+	}
+	return f, nil
+}
+
+// lookup returns hash of filename in format, empty if it isn't cached
+func (c *Cache) lookup(filename string, format Format) (string, error) {
+	data, err := os.ReadFile(c.indexPath(filename, format))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	hash := string(bytes.TrimSpace(data))
+	if _, err := hex.DecodeString(hash); err != nil || len(hash) != 2*sha256.Size {
+		Logger.Errorf("corrupted index of %s.%s: %s", filename, format, hash)
+		return "", nil
+	}
+	return hash, nil
+}
+
+// fetch downloads filename in format to objects and indexes it, returns its hash
+func (c *Cache) fetch(ctx context.Context, filename string, format Format) (string, error) {
+	body, err := c.fetcher.Fetch(ctx, filename, format)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := body.Close(); err != nil {
+			Logger.Debugf("error on Body.Close() %#v", err)
+		}
+	}()
+
+	// Content is hashed meanwhile it is written, so it is read only once
+	sum := sha256.New()
+	tmp, err := c.tempFile(io.TeeReader(io.LimitReader(body, MaxSize+1), sum))
+	if err != nil {
+		return "", err
+	}
+	if tmp.size > MaxSize {
+		_ = os.Remove(tmp.name)
+		return "", fmt.Errorf("%w: %s.%s exceeds %d bytes", ErrUpstream, filename, format, MaxSize)
+	}
+
+	hash := hex.EncodeToString(sum.Sum(nil))
+	path := c.objectPath(hash)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		_ = os.Remove(tmp.name)
+		return "", fmt.Errorf("%w: %v", ErrIO, err)
+	}
+	if err := os.Rename(tmp.name, path); err != nil {
+		_ = os.Remove(tmp.name)
+		return "", fmt.Errorf("%w: %v", ErrIO, err)
+	}
+
+	index, err := c.tempFile(bytes.NewBufferString(hash))
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.indexPath(filename, format)), 0o755); err != nil {
+		_ = os.Remove(index.name)
+		return "", fmt.Errorf("%w: %v", ErrIO, err)
+	}
+	if err := os.Rename(index.name, c.indexPath(filename, format)); err != nil {
+		_ = os.Remove(index.name)
+		return "", fmt.Errorf("%w: %v", ErrIO, err)
+	}
+	return hash, nil
+}
+
+// temp is written temporary file
+type temp struct {
+	name string
+	size int64
+}
+
+// tempFile writes r to temporary file in dir, so concurrent readers never see partially written file.
+// Errors of r wrap ErrUpstream, other ones wrap ErrIO
+func (c *Cache) tempFile(r io.Reader) (temp, error) {
+	f, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return temp{}, fmt.Errorf("%w: %v", ErrIO, err)
+	}
+	src := &reader{Reader: r}
+	size, err := f.ReadFrom(src)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		if src.err != nil {
+			return temp{}, fmt.Errorf("%w: read: %v", ErrUpstream, src.err)
+		}
+		return temp{}, fmt.Errorf("%w: %v", ErrIO, err)
+	}
+	return temp{name: f.Name(), size: size}, nil
+}
+
+// reader remembers error of underlying Reader, to tell it apart from write errors
+type reader struct {
+	io.Reader
+	err error
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+func (c *Cache) open(hash string) (*File, error) {
+	f, err := os.Open(c.objectPath(hash))
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &File{ReadSeekCloser: f, Hash: hash, ModTime: info.ModTime()}, nil
+}
+
+// objectPath spreads objects over subdirectories named after the first two characters of hash
+func (c *Cache) objectPath(hash string) string {
+	return filepath.Join(c.dir, "objects", hash[:2], hash)
+}
+
+func (c *Cache) indexPath(filename string, format Format) string {
+	return filepath.Join(c.dir, "index", string(format), filename)
+}
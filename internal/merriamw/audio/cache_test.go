@@ -0,0 +1,117 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package audio_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/merriamw/audio"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fetcher responds with files[filename.format] and counts calls
+type fetcher struct {
+	files map[string]string
+	calls int
+}
+
+func (f *fetcher) Fetch(_ context.Context, filename string, format audio.Format) (io.ReadCloser, error) {
+	f.calls++
+	data, ok := f.files[filename+"."+string(format)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", audio.ErrNotFound, filename)
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+// read reads whole f and closes it
+func read(t *testing.T, f *audio.File) string {
+	data, err := io.ReadAll(f)
+	require.Nil(t, err)
+	require.Nil(t, f.Close())
+	return string(data)
+}
+
+func TestCache_OpenAudio(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	u := &fetcher{files: map[string]string{
+		"brain001.mp3": "brain mp3",
+		"brain001.ogg": "brain ogg",
+		"brain002.mp3": "brain mp3",
+	}}
+	c, err := audio.NewCache(dir, u)
+	require.Nil(t, err)
+
+	f, err := c.OpenAudio(ctx, "brain001", audio.MP3)
+	require.Nil(t, err)
+	hash := f.Hash
+	require.Len(t, hash, 64)
+	require.Equal(t, "brain mp3", read(t, f))
+
+	// Second time it is read from disk
+	f, err = c.OpenAudio(ctx, "brain001", audio.MP3)
+	require.Nil(t, err)
+	require.Equal(t, "brain mp3", read(t, f))
+	require.Equal(t, 1, u.calls)
+
+	// Formats are different files
+	f, err = c.OpenAudio(ctx, "brain001", audio.OGG)
+	require.Nil(t, err)
+	require.Equal(t, "brain ogg", read(t, f))
+	require.NotEqual(t, hash, f.Hash)
+
+	// The same content is stored once
+	f, err = c.OpenAudio(ctx, "brain002", audio.MP3)
+	require.Nil(t, err)
+	require.Equal(t, hash, f.Hash)
+	require.Nil(t, f.Close())
+	objects, err := filepath.Glob(filepath.Join(dir, "objects", "*", "*"))
+	require.Nil(t, err)
+	require.Len(t, objects, 2)
+	require.Equal(t, 3, u.calls)
+
+	// Cache survives restart
+	c, err = audio.NewCache(dir, u)
+	require.Nil(t, err)
+	f, err = c.OpenAudio(ctx, "brain001", audio.MP3)
+	require.Nil(t, err)
+	require.Equal(t, "brain mp3", read(t, f))
+	require.Equal(t, 3, u.calls)
+
+	// Removed object is fetched again
+	require.Nil(t, os.Remove(filepath.Join(dir, "objects", hash[:2], hash)))
+	f, err = c.OpenAudio(ctx, "brain001", audio.MP3)
+	require.Nil(t, err)
+	require.Equal(t, "brain mp3", read(t, f))
+	require.Equal(t, 4, u.calls)
+}
+
+func TestCache_OpenAudio_errors(t *testing.T) {
+	ctx := context.Background()
+	u := &fetcher{}
+	c, err := audio.NewCache(t.TempDir(), u)
+	require.Nil(t, err)
+
+	for _, filename := range []string{"", "../brain", "brain.mp3", "brain/001", "mózg"} {
+		_, err = c.OpenAudio(ctx, filename, audio.MP3)
+		require.ErrorIs(t, err, audio.ErrInvalid, filename)
+	}
+	_, err = c.OpenAudio(ctx, "brain001", "flac")
+	require.ErrorIs(t, err, audio.ErrInvalid)
+	require.Zero(t, u.calls)
+
+	// Missing files are not cached
+	_, err = c.OpenAudio(ctx, "brain001", audio.MP3)
+	require.ErrorIs(t, err, audio.ErrNotFound)
+	_, err = c.OpenAudio(ctx, "brain001", audio.MP3)
+	require.ErrorIs(t, err, audio.ErrNotFound)
+	require.Equal(t, 2, u.calls)
+}
@@ -6,33 +6,51 @@ package dictionary
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/a-clap/dictionary/internal/merriamw/audio"
+	"github.com/a-clap/dictionary/internal/merriamw/sense"
 	"github.com/a-clap/logger"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
-	"unicode"
+	"time"
 )
 
 var Logger logger.Logger = logger.NewNop()
 
+// DefaultTimeout is used by http.Client of DefaultGetDefinition, when none is provided
+const DefaultTimeout = 10 * time.Second
+
+const (
+	// Collegiate is reference of Merriam-Webster's Collegiate Dictionary
+	Collegiate = "collegiate"
+	// Learners is reference of Merriam-Webster's Learner's Dictionary, which has simpler definitions, suited for non-native speakers
+	Learners = "learners"
+)
+
 type Dictionary struct {
 	Definitioner
 }
 
 type Definitioner interface {
-	Get(text string) ([]byte, error)
+	Get(ctx context.Context, text string) ([]byte, error)
 }
 
 type Pronunciation struct {
 	PhoneticNotation string
-	Url              string
+	// Url is address of MP3 file, see audio.URL for other formats
+	Url string
+	// Filename is name of audio file on MW server, without extension
+	Filename string
 }
 
 type DefaultGetDefinition struct {
-	key string
+	key       string
+	reference string
+	client    *http.Client
 }
 
 type Suggestions struct {
@@ -40,7 +58,12 @@ type Suggestions struct {
 }
 
 func NewDictDefault(key string) *Dictionary {
-	return NewDictionary(NewDefaultGetDefinition(key))
+	return NewDictionary(NewDefaultGetDefinition(key, nil))
+}
+
+// NewLearnersDefault creates Dictionary using Learner's Dictionary, responses share format with collegiate one
+func NewLearnersDefault(key string) *Dictionary {
+	return NewDictionary(NewLearnersGetDefinition(key, nil))
 }
 
 func NewDictionary(getDefinition Definitioner) *Dictionary {
@@ -52,8 +75,8 @@ func NewDictionary(getDefinition Definitioner) *Dictionary {
 // Definition return possible slice of Definition for passed argument.
 // If it couldn't find exact Definition, function may returned slice with Suggestions - if there is a typo in word.
 // Otherwise error
-func (d Dictionary) Definition(text string) (data []*Definition, suggestions *Suggestions, err error) {
-	resp, err := d.Get(text)
+func (d Dictionary) Definition(ctx context.Context, text string) (data []*Definition, suggestions *Suggestions, err error) {
+	resp, err := d.Get(ctx, text)
 	if err != nil {
 		err = fmt.Errorf("error on get %w", err)
 		Logger.Errorf("error on get %v", err)
@@ -87,6 +110,87 @@ func (w *Definition) Definition() []string {
 	return w.Shortdef
 }
 
+// Senses returns structured senses of every definition section, e.g. of transitive and intransitive verb
+func (w *Definition) Senses() sense.Sseq {
+	var s sense.Sseq
+	for _, elem := range w.Def {
+		s = append(s, elem.Sseq...)
+	}
+	return s
+}
+
+// RunOn is word derived from headword, e.g. "brainless" from "brain"
+type RunOn struct {
+	Word     string `json:"word"`
+	Function string `json:"function"`
+}
+
+// RunOns returns undefined run-on entries, e.g. adjective "brainless" of "brain"
+func (w *Definition) RunOns() []RunOn {
+	runOns := make([]RunOn, len(w.Uros))
+	for i, elem := range w.Uros {
+		runOns[i] = RunOn{
+			Word:     syllables(elem.Ure),
+			Function: elem.Fl,
+		}
+	}
+	return runOns
+}
+
+// Inflections returns inflected forms of word, e.g. "brained" and "braining"
+func (w *Definition) Inflections() []string {
+	inflections := make([]string, 0, len(w.Ins))
+	for _, elem := range w.Ins {
+		// Cutback inflections (e.g. "-ed") are shorter forms of the full ones
+		if len(elem.If) > 0 {
+			inflections = append(inflections, syllables(elem.If))
+		}
+	}
+	return inflections
+}
+
+// Stems returns every form of word, under which the entry can be found
+func (w *Definition) Stems() []string {
+	return w.Meta.Stems
+}
+
+// Etymology returns origin of word, supplemental notes follow it. Texts contain MW markup
+func (w *Definition) Etymology() []string {
+	var etymology []string
+	for _, elem := range w.Et {
+		if len(elem) != 2 {
+			continue
+		}
+		var kind string
+		if err := json.Unmarshal(elem[0], &kind); err != nil {
+			continue
+		}
+		switch kind {
+		case "text":
+			var text string
+			if err := json.Unmarshal(elem[1], &text); err == nil {
+				etymology = append(etymology, text)
+			}
+		case "et_snote":
+			var notes [][]string
+			if err := json.Unmarshal(elem[1], &notes); err != nil {
+				continue
+			}
+			for _, note := range notes {
+				if len(note) == 2 && note[0] == "t" {
+					etymology = append(etymology, note[1])
+				}
+			}
+		}
+	}
+	return etymology
+}
+
+// FirstUse returns date of the first known use of word, e.g. "before 12th century". It may contain MW markup
+func (w *Definition) FirstUse() string {
+	return w.Date
+}
+
 // Text returns word, which translation belongs to
 func (w *Definition) Text() string {
 	// MerriamW sometimes adds unique number to each word after ":". We can get this unique number and associate words as homographs
@@ -104,33 +208,30 @@ func (w *Definition) Examples() []string {
 
 // Audio returns possible pronunciations for word
 func (w *Definition) Audio() []Pronunciation {
-	const AudioUrl = `https://media.merriam-webster.com/audio/prons/en/us/mp3/%s/%s.mp3`
-
 	prons := make([]Pronunciation, len(w.Hwi.Prs))
 	for i, elem := range w.Hwi.Prs {
 		pron := Pronunciation{
 			PhoneticNotation: elem.Mw,
+			Filename:         elem.Sound.Audio,
+		}
+		// Learner's Dictionary uses IPA instead of MW notation
+		if len(pron.PhoneticNotation) == 0 {
+			pron.PhoneticNotation = elem.Ipa
 		}
 
-		filename := elem.Sound.Audio
-		if len(filename) > 0 {
-			dir := ""
-			if strings.HasPrefix(filename, "bix") {
-				dir = "bix"
-			} else if strings.HasPrefix(filename, "gg") {
-				dir = "gg"
-			} else if unicode.IsNumber(rune(filename[0])) || unicode.IsPunct(rune(filename[0])) {
-				dir = "number"
-			} else {
-				dir = string(filename[0])
-			}
-			pron.Url = fmt.Sprintf(AudioUrl, dir, filename)
+		if len(pron.Filename) > 0 {
+			pron.Url = audio.URL(pron.Filename, audio.MP3)
 		}
 		prons[i] = pron
 	}
 	return prons
 }
 
+// syllables removes syllable separators from word, e.g. "brain*less" -> "brainless"
+func syllables(word string) string {
+	return strings.ReplaceAll(word, "*", "")
+}
+
 // IsOffensive returns true, whether word is considered as offensive
 func (w *Definition) IsOffensive() bool {
 	return w.Meta.Offensive
@@ -141,22 +242,44 @@ func (w *Definition) Function() string {
 	return w.Fl
 }
 
-// NewDefaultGetDefinition constructor for standard API access
-func NewDefaultGetDefinition(key string) *DefaultGetDefinition {
-	return &DefaultGetDefinition{key: key}
+// NewDefaultGetDefinition constructor for standard API access,
+// if client is nil, new one with DefaultTimeout is used
+func NewDefaultGetDefinition(key string, client *http.Client) *DefaultGetDefinition {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &DefaultGetDefinition{key: key, reference: Collegiate, client: client}
+}
+
+// NewLearnersGetDefinition constructor for Learner's Dictionary API access, key must be issued for it.
+// If client is nil, new one with DefaultTimeout is used
+func NewLearnersGetDefinition(key string, client *http.Client) *DefaultGetDefinition {
+	d := NewDefaultGetDefinition(key, client)
+	d.reference = Learners
+	return d
+}
+
+// Reference returns name of dictionary queried by d, either Collegiate or Learners
+func (d DefaultGetDefinition) Reference() string {
+	return d.reference
 }
 
 // query returns prepared URL for Get
 func (d DefaultGetDefinition) query(text string) string {
-	const GetUrl = "https://www.dictionaryapi.com/api/v3/references/collegiate/json/%s?key=%s"
+	const GetUrl = "https://www.dictionaryapi.com/api/v3/references/%s/json/%s?key=%s"
 
 	text = url.PathEscape(text)
-	return fmt.Sprintf(GetUrl, text, d.key)
+	return fmt.Sprintf(GetUrl, d.reference, text, d.key)
 }
 
 // Get fulfills Definitioner interface
-func (d DefaultGetDefinition) Get(text string) ([]byte, error) {
-	response, err := http.Get(d.query(text))
+func (d DefaultGetDefinition) Get(ctx context.Context, text string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.query(text), nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request failed %v", err)
+	}
+
+	response, err := d.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("get failed %v", err)
 	}
@@ -188,13 +311,14 @@ type Definition struct {
 		//Sort      string   `json:"sort"`
 		//Src       string   `json:"src"`
 		//Section   string   `json:"section"`
-		//Stems     []string `json:"stems"`
-		Offensive bool `json:"offensive"`
+		Stems     []string `json:"stems"`
+		Offensive bool     `json:"offensive"`
 	} `json:"meta"`
 	Hwi struct {
 		Hw  string `json:"hw"`
 		Prs []struct {
 			Mw    string `json:"mw"`
+			Ipa   string `json:"ipa"`
 			Sound struct {
 				Audio string `json:"audio"`
 				Ref   string `json:"ref"`
@@ -202,16 +326,24 @@ type Definition struct {
 			} `json:"sound"`
 		} `json:"prs"`
 	} `json:"hwi"`
-	Fl string `json:"fl"`
-	//Def []struct {
-	//	Sseq [][][]interface{} `json:"sseq"`
-	//} `json:"def"`
-	//Uros []struct {
-	//	Ure string `json:"ure"`
-	//	Fl  string `json:"fl"`
-	//} `json:"uros"`
-	//Et     [][]string `json:"et"`
-	//Date   string     `json:"date"`
+	Fl  string `json:"fl"`
+	Def []struct {
+		// Vd is verb divider, e.g. "transitive verb"
+		Vd   string     `json:"vd"`
+		Sseq sense.Sseq `json:"sseq"`
+	} `json:"def"`
+	Ins []struct {
+		If  string `json:"if"`
+		Ifc string `json:"ifc"`
+		Il  string `json:"il"`
+	} `json:"ins"`
+	Uros []struct {
+		Ure string `json:"ure"`
+		Fl  string `json:"fl"`
+	} `json:"uros"`
+	// Et is array of [type, data] pairs, data is either text or array of supplemental notes
+	Et   [][]json.RawMessage `json:"et"`
+	Date string              `json:"date"`
 	//LdLink struct {
 	//	LinkHw string `json:"link_hw"`
 	//	LinkFl string `json:"link_fl"`
@@ -5,17 +5,21 @@
 package dictionary_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/a-clap/dictionary/internal/merriamw/dictionary"
+	"io"
+	"net/http"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 )
 
 type errDefinitioner struct {
 }
 
-func (e errDefinitioner) Get(_ string) ([]byte, error) {
+func (e errDefinitioner) Get(_ context.Context, _ string) ([]byte, error) {
 	return nil, fmt.Errorf("handle me, please")
 }
 
@@ -62,7 +66,7 @@ func TestDictionary_Definition(t *testing.T) {
 		{
 			name: "test some obvious word \"world\"",
 			fields: fields{
-				Definitioner: dictionary.NewDefaultGetDefinition(dictKey),
+				Definitioner: dictionary.NewDefaultGetDefinition(dictKey, nil),
 			},
 			args: args{
 				text: "world",
@@ -77,7 +81,7 @@ func TestDictionary_Definition(t *testing.T) {
 		{
 			name: "test typo \"warld\"",
 			fields: fields{
-				Definitioner: dictionary.NewDefaultGetDefinition(dictKey),
+				Definitioner: dictionary.NewDefaultGetDefinition(dictKey, nil),
 			},
 			args: args{
 				text: "warld",
@@ -95,7 +99,7 @@ func TestDictionary_Definition(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			d := dictionary.NewDictionary(tt.fields.Definitioner)
-			gotData, suggestions, err := d.Definition(tt.args.text)
+			gotData, suggestions, err := d.Definition(context.Background(), tt.args.text)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("%s: Definition() error = %v, wantErr %v", t.Name(), err, tt.wantErr)
 			}
@@ -128,3 +132,119 @@ func TestDictionary_Definition(t *testing.T) {
 		})
 	}
 }
+
+// rawDefinitioner returns response as it is
+type rawDefinitioner string
+
+func (r rawDefinitioner) Get(_ context.Context, _ string) ([]byte, error) {
+	return []byte(r), nil
+}
+
+func TestDefinition_Senses(t *testing.T) {
+	const response = `[{
+		"meta": {"id": "brain:1"},
+		"fl": "noun",
+		"def": [
+			{"sseq": [[["sense", {"sn": "1", "dt": [["text", "{bc}the organ of thought"], ["vis", [{"t": "a {wi}brain{/wi} injury"}]]]}]]]},
+			{"vd": "transitive verb", "sseq": [[["sense", {"dt": [["text", "{bc}to hit on the head"]]}]]]}
+		],
+		"shortdef": ["the organ of thought"]
+	}]`
+
+	data, _, err := dictionary.NewDictionary(rawDefinitioner(response)).Definition(context.Background(), "brain")
+	if err != nil {
+		t.Fatalf("Definition() error = %v", err)
+	}
+	senses := data[0].Senses().Senses()
+	if len(senses) != 2 {
+		t.Fatalf("Senses() = %#v, want 2 senses", senses)
+	}
+	if senses[0].Number != "1" || senses[0].Text != "{bc}the organ of thought" || !reflect.DeepEqual(senses[0].Examples, []string{"a {wi}brain{/wi} injury"}) {
+		t.Fatalf("Senses()[0] = %#v", senses[0])
+	}
+	if senses[1].Text != "{bc}to hit on the head" || data[0].Def[1].Vd != "transitive verb" {
+		t.Fatalf("Senses()[1] = %#v", senses[1])
+	}
+}
+
+func TestDefinition_wordHistory(t *testing.T) {
+	const response = `[{
+		"meta": {"id": "brain:1", "stems": ["brain", "brains", "brained"]},
+		"fl": "verb",
+		"ins": [{"if": "brained"}, {"ifc": "-ing", "il": "or"}, {"if": "brain*ing"}],
+		"uros": [{"ure": "brain*less", "fl": "adjective"}, {"ure": "brain*less*ly", "fl": "adverb"}],
+		"et": [["text", "Middle English, from Old English {it}brægen{/it}"], ["et_snote", [["t", "Compare {it}bregen{/it}."]]]],
+		"date": "before 12th century{ds||1||}"
+	}]`
+
+	data, _, err := dictionary.NewDictionary(rawDefinitioner(response)).Definition(context.Background(), "brain")
+	if err != nil {
+		t.Fatalf("Definition() error = %v", err)
+	}
+	d := data[0]
+	if want := []string{"brained", "braining"}; !reflect.DeepEqual(d.Inflections(), want) {
+		t.Fatalf("Inflections() = %#v, want %#v", d.Inflections(), want)
+	}
+	if want := []dictionary.RunOn{{Word: "brainless", Function: "adjective"}, {Word: "brainlessly", Function: "adverb"}}; !reflect.DeepEqual(d.RunOns(), want) {
+		t.Fatalf("RunOns() = %#v, want %#v", d.RunOns(), want)
+	}
+	if want := []string{"Middle English, from Old English {it}brægen{/it}", "Compare {it}bregen{/it}."}; !reflect.DeepEqual(d.Etymology(), want) {
+		t.Fatalf("Etymology() = %#v, want %#v", d.Etymology(), want)
+	}
+	if want := "before 12th century{ds||1||}"; d.FirstUse() != want {
+		t.Fatalf("FirstUse() = %#v, want %#v", d.FirstUse(), want)
+	}
+	if want := []string{"brain", "brains", "brained"}; !reflect.DeepEqual(d.Stems(), want) {
+		t.Fatalf("Stems() = %#v, want %#v", d.Stems(), want)
+	}
+}
+
+// roundTripper records request and responds with body
+type roundTripper struct {
+	request *http.Request
+	body    string
+}
+
+func (r *roundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	r.request = request
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(r.body)),
+		Request:    request,
+	}, nil
+}
+
+func TestLearners(t *testing.T) {
+	rt := &roundTripper{body: `[{
+		"meta": {"id": "brain:1"},
+		"hwi": {"hw": "brain", "prs": [{"ipa": "ˈbreɪn", "sound": {"audio": "brain001"}}]},
+		"fl": "noun",
+		"shortdef": ["the organ of the body that contains nerve cells"]
+	}]`}
+	get := dictionary.NewLearnersGetDefinition("key", &http.Client{Transport: rt})
+	if get.Reference() != dictionary.Learners {
+		t.Fatalf("Reference() = %s, want %s", get.Reference(), dictionary.Learners)
+	}
+
+	data, suggestions, err := dictionary.NewDictionary(get).Definition(context.Background(), "brain")
+	if err != nil || suggestions != nil {
+		t.Fatalf("Definition() suggestions = %v, error = %v", suggestions, err)
+	}
+	if want := "https://www.dictionaryapi.com/api/v3/references/learners/json/brain?key=key"; rt.request.URL.String() != want {
+		t.Fatalf("URL = %s, want %s", rt.request.URL, want)
+	}
+	want := []dictionary.Pronunciation{{PhoneticNotation: "ˈbreɪn", Url: "https://media.merriam-webster.com/audio/prons/en/us/mp3/b/brain001.mp3", Filename: "brain001"}}
+	if !reflect.DeepEqual(data[0].Audio(), want) {
+		t.Fatalf("Audio() = %#v, want %#v", data[0].Audio(), want)
+	}
+
+	// Unknown word gets suggestions, the same as in collegiate dictionary
+	rt.body = `["brain", "brainy"]`
+	data, suggestions, err = dictionary.NewDictionary(get).Definition(context.Background(), "brian")
+	if err != nil || data != nil {
+		t.Fatalf("Definition() data = %v, error = %v", data, err)
+	}
+	if want := []string{"brain", "brainy"}; !reflect.DeepEqual(suggestions.Suggestions, want) {
+		t.Fatalf("Suggestions = %#v, want %#v", suggestions.Suggestions, want)
+	}
+}
@@ -0,0 +1,137 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package sense
+
+import (
+	"html"
+	"strings"
+)
+
+// format describes how single markup token is rendered, either as plain text or HTML
+type format struct {
+	plain, html string
+}
+
+// tokens without fields, see https://www.dictionaryapi.com/products/json#sec-2.tokens
+var tokens = map[string]format{
+	"bc":       {plain: ": ", html: "<b>:</b> "},
+	"ldquo":    {plain: "“", html: "&ldquo;"},
+	"rdquo":    {plain: "”", html: "&rdquo;"},
+	"p_br":     {plain: "\n", html: "<br>"},
+	"b":        {html: "<b>"},
+	"/b":       {html: "</b>"},
+	"it":       {html: "<i>"},
+	"/it":      {html: "</i>"},
+	"inf":      {html: "<sub>"},
+	"/inf":     {html: "</sub>"},
+	"sup":      {html: "<sup>"},
+	"/sup":     {html: "</sup>"},
+	"sc":       {html: `<span class="mw-sc">`},
+	"/sc":      {html: "</span>"},
+	"wi":       {html: "<i>"},
+	"/wi":      {html: "</i>"},
+	"phrase":   {html: "<b><i>"},
+	"/phrase":  {html: "</i></b>"},
+	"qword":    {html: "<i>"},
+	"/qword":   {html: "</i>"},
+	"parahw":   {html: `<span class="mw-sc">`},
+	"/parahw":  {html: "</span>"},
+	"gloss":    {plain: "[", html: "["},
+	"/gloss":   {plain: "]", html: "]"},
+	"dx":       {plain: "— ", html: "&mdash; "},
+	"/dx":      {},
+	"dx_def":   {plain: "(", html: "("},
+	"/dx_def":  {plain: ")", html: ")"},
+	"dx_ety":   {plain: "— ", html: "&mdash; "},
+	"/dx_ety":  {},
+	"ma":       {plain: "— more at ", html: "&mdash; more at "},
+	"/ma":      {},
+	"ds":       {},
+	"/ds":      {},
+	"hwi":      {},
+	"/hwi":     {},
+	"ital_bra": {},
+}
+
+// links are tokens with fields, the first field is displayed text.
+// Value tells, whether displayed text is in small capitals
+var links = map[string]bool{
+	"a_link":  false,
+	"d_link":  false,
+	"i_link":  false,
+	"et_link": true,
+	"mat":     true,
+	"sx":      true,
+	"dxt":     true,
+}
+
+// Plain strips MW markup from text, e.g. "{bc}a {it}small{/it} cat" -> "a small cat"
+func Plain(text string) string {
+	// Leading bold colon only introduces definition
+	return strings.TrimLeft(render(text, false), ": ")
+}
+
+// HTML converts MW markup in text to HTML, anything else is escaped
+func HTML(text string) string {
+	return render(text, true)
+}
+
+func render(text string, asHTML bool) string {
+	var b strings.Builder
+	for len(text) > 0 {
+		start := strings.IndexByte(text, '{')
+		end := -1
+		if start >= 0 {
+			end = strings.IndexByte(text[start:], '}')
+		}
+		if start < 0 || end < 0 {
+			b.WriteString(escape(text, asHTML))
+			break
+		}
+		end += start
+
+		b.WriteString(escape(text[:start], asHTML))
+		b.WriteString(token(text[start+1:end], asHTML))
+		text = text[end+1:]
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// token renders single token, without braces. Unknown tokens are dropped
+func token(t string, asHTML bool) string {
+	if f, ok := tokens[t]; ok {
+		if asHTML {
+			return f.html
+		}
+		return f.plain
+	}
+
+	fields := strings.Split(t, "|")
+	smallCaps, ok := links[fields[0]]
+	if !ok || len(fields) < 2 {
+		Logger.Debugf("skipping markup token %s", t)
+		return ""
+	}
+
+	shown := fields[1]
+	// Cross-references may point to specific sense, e.g. {sx|cat||2}
+	if len(fields) > 3 && len(fields[3]) > 0 {
+		shown += " " + fields[3]
+	}
+	if !asHTML {
+		return shown
+	}
+	if smallCaps {
+		return `<span class="mw-sc">` + html.EscapeString(shown) + "</span>"
+	}
+	return html.EscapeString(shown)
+}
+
+func escape(text string, asHTML bool) string {
+	if asHTML {
+		return html.EscapeString(text)
+	}
+	return text
+}
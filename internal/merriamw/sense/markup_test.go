@@ -0,0 +1,57 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package sense_test
+
+import (
+	"github.com/a-clap/dictionary/internal/merriamw/sense"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMarkup(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		plain string
+		html  string
+	}{
+		{
+			name:  "bold colon and italics",
+			text:  "{bc}a {it}small{/it} domesticated carnivore",
+			plain: "a small domesticated carnivore",
+			html:  "<b>:</b> a <i>small</i> domesticated carnivore",
+		},
+		{
+			name:  "synonymous cross-reference",
+			text:  "{bc}{sx|mind||} {bc}{sx|intellect|intellect:1|2}",
+			plain: "mind : intellect 2",
+			html:  `<b>:</b> <span class="mw-sc">mind</span> <b>:</b> <span class="mw-sc">intellect 2</span>`,
+		},
+		{
+			name:  "links and quotes",
+			text:  "{ldquo}the {wi}brain{/wi} of the {a_link|operation}{rdquo} {dx}compare {dxt|cerebrum||}{/dx}",
+			plain: "“the brain of the operation” — compare cerebrum",
+			html:  `&ldquo;the <i>brain</i> of the operation&rdquo; &mdash; compare <span class="mw-sc">cerebrum</span>`,
+		},
+		{
+			name:  "escaped html and unknown tokens",
+			text:  "x < y {unknown}{gloss}z{/gloss} {d_link|a & b|ab}",
+			plain: "x < y [z] a & b",
+			html:  "x &lt; y [z] a &amp; b",
+		},
+		{
+			name:  "unclosed brace",
+			text:  "{it}brain{/it} {sx",
+			plain: "brain {sx",
+			html:  "<i>brain</i> {sx",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.plain, sense.Plain(tt.text))
+			require.Equal(t, tt.html, sense.HTML(tt.text))
+		})
+	}
+}
@@ -0,0 +1,336 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+// Package sense parses sense sequences (sseq) of Merriam-Webster responses, see https://www.dictionaryapi.com/products/json#sec-2.sseq
+package sense
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/a-clap/logger"
+)
+
+var Logger logger.Logger = logger.NewNop()
+
+// Sense is single meaning of headword. Text, Examples and Notes keep MW markup, use Plain or HTML to display them
+type Sense struct {
+	// Number is sense number, e.g. "1 a", "b", "(2)"
+	Number string `json:"number,omitempty"`
+	// Labels are subject and status labels, e.g. "chiefly British"
+	Labels []string `json:"labels,omitempty"`
+	// Text is defining text
+	Text string `json:"text,omitempty"`
+	// Examples are verbal illustrations of Text
+	Examples []string `json:"examples,omitempty"`
+	// Notes are usage notes
+	Notes []string `json:"notes,omitempty"`
+	// Divider introduces divided sense, e.g. "also", "specifically", it is set only in Divided
+	Divider string `json:"divider,omitempty"`
+	// Divided is further meaning introduced by Divider
+	Divided *Sense `json:"divided,omitempty"`
+	// Subsenses are senses grouped under this one, e.g. (1) and (2) of "b"
+	Subsenses []Sense `json:"subsenses,omitempty"`
+	// Synonyms, Related, Phrases, NearAntonyms and Antonyms are word lists of thesaurus senses
+	Synonyms     []string `json:"synonyms,omitempty"`
+	Related      []string `json:"related,omitempty"`
+	Phrases      []string `json:"phrases,omitempty"`
+	NearAntonyms []string `json:"near_antonyms,omitempty"`
+	Antonyms     []string `json:"antonyms,omitempty"`
+}
+
+// Sequence is group of senses under the same top-level number, e.g. "1 a", "b" and "c"
+type Sequence []Sense
+
+// Sseq is parsed sense sequence. Unknown elements are skipped
+type Sseq []Sequence
+
+// UnmarshalJSON parses raw sseq: array of sequences, each of them is array of [type, data] elements
+func (s *Sseq) UnmarshalJSON(data []byte) error {
+	var raw [][]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("sseq: %w", err)
+	}
+
+	*s = make(Sseq, 0, len(raw))
+	for _, elems := range raw {
+		seq, err := parseElems(elems)
+		if err != nil {
+			return err
+		}
+		*s = append(*s, seq)
+	}
+	return nil
+}
+
+// Senses returns every sense, with subsenses following their parent
+func (s Sseq) Senses() []Sense {
+	var senses []Sense
+	var walk func([]Sense)
+	walk = func(list []Sense) {
+		for _, elem := range list {
+			sub := elem.Subsenses
+			elem.Subsenses = nil
+			senses = append(senses, elem)
+			walk(sub)
+		}
+	}
+	for _, seq := range s {
+		walk(seq)
+	}
+	return senses
+}
+
+// Format returns copy of s with f, e.g. Plain, applied on every text
+func (s Sseq) Format(f func(string) string) Sseq {
+	if s == nil {
+		return nil
+	}
+	formatted := make(Sseq, len(s))
+	for i, seq := range s {
+		formatted[i] = make(Sequence, len(seq))
+		for j, elem := range seq {
+			formatted[i][j] = elem.Format(f)
+		}
+	}
+	return formatted
+}
+
+// Format returns copy of s with f, e.g. Plain, applied on every text
+func (s Sense) Format(f func(string) string) Sense {
+	formatted := s
+	formatted.Text = f(s.Text)
+	formatted.Examples = formatAll(s.Examples, f)
+	formatted.Notes = formatAll(s.Notes, f)
+	if s.Divided != nil {
+		divided := s.Divided.Format(f)
+		formatted.Divided = &divided
+	}
+	if s.Subsenses != nil {
+		formatted.Subsenses = make([]Sense, len(s.Subsenses))
+		for i, elem := range s.Subsenses {
+			formatted.Subsenses[i] = elem.Format(f)
+		}
+	}
+	return formatted
+}
+
+func formatAll(texts []string, f func(string) string) []string {
+	if texts == nil {
+		return nil
+	}
+	formatted := make([]string, len(texts))
+	for i, elem := range texts {
+		formatted[i] = f(elem)
+	}
+	return formatted
+}
+
+// element is [type, data] pair, which is used by sseq and dt
+type element struct {
+	kind string
+	data json.RawMessage
+}
+
+func (e *element) UnmarshalJSON(data []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("expected [type, data], got %d elements", len(pair))
+	}
+	e.data = pair[1]
+	return json.Unmarshal(pair[0], &e.kind)
+}
+
+// rawSense is "sense" object, also the one embedded in "bs" and "sdsense"
+type rawSense struct {
+	Sn  string    `json:"sn"`
+	Sd  string    `json:"sd"`
+	Sls []string  `json:"sls"`
+	Lbs []string  `json:"lbs"`
+	Dt  []element `json:"dt"`
+	// Sdsense is divided sense
+	Sdsense *rawSense `json:"sdsense"`
+	// Word lists of thesaurus, see https://www.dictionaryapi.com/products/json#sec-3.lists
+	SynList    wordList `json:"syn_list"`
+	RelList    wordList `json:"rel_list"`
+	PhraseList wordList `json:"phrase_list"`
+	NearList   wordList `json:"near_list"`
+	AntList    wordList `json:"ant_list"`
+}
+
+// wordList is thesaurus list of word groups
+type wordList [][]struct {
+	Wd string `json:"wd"`
+}
+
+// words returns words of every group
+func (l wordList) words() []string {
+	var words []string
+	for _, group := range l {
+		for _, elem := range group {
+			words = append(words, elem.Wd)
+		}
+	}
+	return words
+}
+
+// parseElems parses elements of single sequence or "pseq".
+// Truncated sense "sen" and binding substitute "bs" become parents of the senses following them
+func parseElems(raw []json.RawMessage) ([]Sense, error) {
+	var (
+		senses []Sense
+		parent *Sense
+	)
+	add := func(s Sense) {
+		if parent != nil {
+			parent.Subsenses = append(parent.Subsenses, s)
+			return
+		}
+		senses = append(senses, s)
+	}
+
+	for _, r := range raw {
+		var e element
+		if err := json.Unmarshal(r, &e); err != nil {
+			return nil, fmt.Errorf("sseq element: %w", err)
+		}
+
+		switch e.kind {
+		case "sense":
+			s, err := parseSense(e.data)
+			if err != nil {
+				return nil, err
+			}
+			add(s)
+		case "sen", "bs":
+			data := e.data
+			if e.kind == "bs" {
+				var bs struct {
+					Sense json.RawMessage `json:"sense"`
+				}
+				if err := json.Unmarshal(data, &bs); err != nil {
+					return nil, fmt.Errorf("bs: %w", err)
+				}
+				data = bs.Sense
+			}
+			s, err := parseSense(data)
+			if err != nil {
+				return nil, err
+			}
+			if parent != nil {
+				senses = append(senses, *parent)
+			}
+			parent = &s
+		case "pseq":
+			var elems []json.RawMessage
+			if err := json.Unmarshal(e.data, &elems); err != nil {
+				return nil, fmt.Errorf("pseq: %w", err)
+			}
+			sub, err := parseElems(elems)
+			if err != nil {
+				return nil, err
+			}
+			// pseq starting with "bs" has its own parent, otherwise it is an anonymous group
+			var first element
+			if len(elems) > 0 && json.Unmarshal(elems[0], &first) == nil && first.kind == "bs" {
+				for _, elem := range sub {
+					add(elem)
+				}
+				continue
+			}
+			add(Sense{Subsenses: sub})
+		default:
+			Logger.Debugf("skipping sseq element %s", e.kind)
+		}
+	}
+	if parent != nil {
+		senses = append(senses, *parent)
+	}
+	return senses, nil
+}
+
+func parseSense(data json.RawMessage) (Sense, error) {
+	var r rawSense
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Sense{}, fmt.Errorf("sense: %w", err)
+	}
+	return r.sense()
+}
+
+func (r *rawSense) sense() (Sense, error) {
+	s := Sense{
+		Number:       r.Sn,
+		Labels:       append(r.Lbs, r.Sls...),
+		Divider:      r.Sd,
+		Synonyms:     r.SynList.words(),
+		Related:      r.RelList.words(),
+		Phrases:      r.PhraseList.words(),
+		NearAntonyms: r.NearList.words(),
+		Antonyms:     r.AntList.words(),
+	}
+	if err := parseDt(r.Dt, &s); err != nil {
+		return Sense{}, err
+	}
+	if r.Sdsense != nil {
+		divided, err := r.Sdsense.sense()
+		if err != nil {
+			return Sense{}, err
+		}
+		s.Divided = &divided
+	}
+	return s, nil
+}
+
+// parseDt fills Text, Examples and Notes of s from defining text
+func parseDt(dt []element, s *Sense) error {
+	for _, e := range dt {
+		switch e.kind {
+		case "text":
+			var text string
+			if err := json.Unmarshal(e.data, &text); err != nil {
+				return fmt.Errorf("dt text: %w", err)
+			}
+			s.Text += text
+		case "vis":
+			examples, err := parseVis(e.data)
+			if err != nil {
+				return err
+			}
+			s.Examples = append(s.Examples, examples...)
+		case "uns":
+			// Usage notes are array of dt-like arrays
+			var notes [][]element
+			if err := json.Unmarshal(e.data, &notes); err != nil {
+				return fmt.Errorf("dt uns: %w", err)
+			}
+			for _, note := range notes {
+				var n Sense
+				if err := parseDt(note, &n); err != nil {
+					return err
+				}
+				s.Notes = append(s.Notes, n.Text)
+				s.Examples = append(s.Examples, n.Examples...)
+			}
+		default:
+			Logger.Debugf("skipping dt element %s", e.kind)
+		}
+	}
+	return nil
+}
+
+func parseVis(data json.RawMessage) ([]string, error) {
+	var vis []struct {
+		T string `json:"t"`
+	}
+	if err := json.Unmarshal(data, &vis); err != nil {
+		return nil, fmt.Errorf("dt vis: %w", err)
+	}
+	examples := make([]string, len(vis))
+	for i, elem := range vis {
+		examples[i] = elem.T
+	}
+	return examples, nil
+}
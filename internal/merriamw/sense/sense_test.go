@@ -0,0 +1,145 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package sense_test
+
+import (
+	"encoding/json"
+	"github.com/a-clap/dictionary/internal/merriamw/sense"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// sseq is shortened sense sequence of "brain" from collegiate dictionary, with pseq, bs and sen added
+const sseq = `[
+	[
+		["sense", {"sn": "1 a", "dt": [["text", "{bc}the portion of the vertebrate central nervous system"], ["vis", [{"t": "a {wi}brain{/wi} injury"}]]]}],
+		["sense", {"sn": "b", "dt": [["text", "{bc}a centralized mass of nerve tissue"]], "sdsense": {"sd": "also", "dt": [["text", "{bc}ganglion"]]}}]
+	],
+	[
+		["sense", {"sn": "2 a", "dt": [["text", "{bc}{sx|intellect||} {bc}{sx|mind||}"]]}],
+		["sense", {"sn": "b", "sls": ["informal"], "dt": [["text", "{bc}intellectual endowment"], ["uns", [[["text", "often used in plural"], ["vis", [{"t": "has {it}brains{/it}"}]]]]]]}],
+		["pseq", [
+			["bs", {"sense": {"sn": "c", "dt": [["text", "{bc}a very intelligent person"]]}}],
+			["sense", {"sn": "(1)", "dt": [["text", "{bc}one who plans"]]}],
+			["sense", {"sn": "(2)", "dt": [["text", "{bc}one who directs"]]}]
+		]]
+	],
+	[
+		["sen", {"sn": "3", "sls": ["chiefly British"]}],
+		["sense", {"sn": "a", "dt": [["text", "{bc}a computer"]]}],
+		["pseq", [
+			["sense", {"sn": "b (1)", "dt": [["text", "{bc}a device"]]}],
+			["sense", {"sn": "(2)", "dt": [["text", "{bc}a machine"]]}]
+		]],
+		["unknown", {}]
+	]
+]`
+
+func TestSseq_UnmarshalJSON(t *testing.T) {
+	var s sense.Sseq
+	require.Nil(t, json.Unmarshal([]byte(sseq), &s))
+
+	expected := sense.Sseq{
+		{
+			{
+				Number:   "1 a",
+				Text:     "{bc}the portion of the vertebrate central nervous system",
+				Examples: []string{"a {wi}brain{/wi} injury"},
+			},
+			{
+				Number:  "b",
+				Text:    "{bc}a centralized mass of nerve tissue",
+				Divided: &sense.Sense{Divider: "also", Text: "{bc}ganglion"},
+			},
+		},
+		{
+			{Number: "2 a", Text: "{bc}{sx|intellect||} {bc}{sx|mind||}"},
+			{
+				Number:   "b",
+				Labels:   []string{"informal"},
+				Text:     "{bc}intellectual endowment",
+				Notes:    []string{"often used in plural"},
+				Examples: []string{"has {it}brains{/it}"},
+			},
+			{
+				Number: "c",
+				Text:   "{bc}a very intelligent person",
+				Subsenses: []sense.Sense{
+					{Number: "(1)", Text: "{bc}one who plans"},
+					{Number: "(2)", Text: "{bc}one who directs"},
+				},
+			},
+		},
+		{
+			{
+				Number: "3",
+				Labels: []string{"chiefly British"},
+				Subsenses: []sense.Sense{
+					{Number: "a", Text: "{bc}a computer"},
+					{Subsenses: []sense.Sense{
+						{Number: "b (1)", Text: "{bc}a device"},
+						{Number: "(2)", Text: "{bc}a machine"},
+					}},
+				},
+			},
+		},
+	}
+	require.Equal(t, expected, s)
+
+	senses := s.Senses()
+	require.Len(t, senses, 12)
+	require.Equal(t, "1 a", senses[0].Number)
+	require.Equal(t, "c", senses[4].Number)
+	require.Nil(t, senses[4].Subsenses)
+	require.Equal(t, "(1)", senses[5].Number)
+	require.Equal(t, "(2)", senses[11].Number)
+
+	plain := s.Format(sense.Plain)
+	require.Equal(t, "intellect : mind", plain[1][0].Text)
+	require.Equal(t, []string{"has brains"}, plain[1][1].Examples)
+	require.Equal(t, "ganglion", plain[0][1].Divided.Text)
+	require.Equal(t, "one who directs", plain[1][2].Subsenses[1].Text)
+	// Original is left untouched
+	require.Equal(t, "{bc}ganglion", s[0][1].Divided.Text)
+}
+
+func TestSseq_UnmarshalJSON_invalid(t *testing.T) {
+	for _, data := range []string{
+		`{}`,
+		`[[["sense"]]]`,
+		`[[["sense", {"dt": [["text", 5]]}]]]`,
+		`[[["sense", {"dt": [["vis", "brain"]]}]]]`,
+		`[[["pseq", {}]]]`,
+		`[[["bs", []]]]`,
+	} {
+		var s sense.Sseq
+		require.NotNil(t, json.Unmarshal([]byte(data), &s), data)
+	}
+}
+
+func TestSseq_UnmarshalJSON_thesaurus(t *testing.T) {
+	const data = `[[["sense", {
+		"sn": "1",
+		"dt": [["text", "a very smart person"], ["vis", [{"t": "she's the {it}brain{/it} of the class"}]]],
+		"syn_list": [[{"wd": "genius"}, {"wd": "intellect"}]],
+		"rel_list": [[{"wd": "prodigy"}], [{"wd": "expert"}, {"wd": "master"}]],
+		"phrase_list": [[{"wd": "mental giant"}]],
+		"near_list": [[{"wd": "ignoramus"}]],
+		"ant_list": [[{"wd": "dunce"}, {"wd": "idiot"}]]
+	}]]]`
+
+	var s sense.Sseq
+	require.Nil(t, json.Unmarshal([]byte(data), &s))
+	require.Equal(t, sense.Sseq{{{
+		Number:       "1",
+		Text:         "a very smart person",
+		Examples:     []string{"she's the {it}brain{/it} of the class"},
+		Synonyms:     []string{"genius", "intellect"},
+		Related:      []string{"prodigy", "expert", "master"},
+		Phrases:      []string{"mental giant"},
+		NearAntonyms: []string{"ignoramus"},
+		Antonyms:     []string{"dunce", "idiot"},
+	}}}, s)
+}
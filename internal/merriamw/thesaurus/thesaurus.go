@@ -6,26 +6,33 @@ package thesaurus
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/a-clap/dictionary/internal/merriamw/sense"
 	"github.com/a-clap/logger"
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 var Logger logger.Logger = logger.NewNop()
 
+// DefaultTimeout is used by http.Client of DefaultThesauruser, when none is provided
+const DefaultTimeout = 10 * time.Second
+
 type Thesaurus struct {
 	Thesauruser
 }
 
 type Thesauruser interface {
-	Get(text string) ([]byte, error)
+	Get(ctx context.Context, text string) ([]byte, error)
 }
 
 type DefaultThesauruser struct {
-	key string
+	key    string
+	client *http.Client
 }
 
 func NewThesaurus(getWord Thesauruser) *Thesaurus {
@@ -33,11 +40,11 @@ func NewThesaurus(getWord Thesauruser) *Thesaurus {
 }
 
 func NewThesaurusDefault(key string) *Thesaurus {
-	return NewThesaurus(NewDefaultThesauruser(key))
+	return NewThesaurus(NewDefaultThesauruser(key, nil))
 }
 
-func (t *Thesaurus) Translate(text string) (words []*Word, err error) {
-	resp, err := t.Get(text)
+func (t *Thesaurus) Translate(ctx context.Context, text string) (words []*Word, err error) {
+	resp, err := t.Get(ctx, text)
 	if err != nil {
 		return nil, fmt.Errorf("error on get %v", err)
 	}
@@ -77,6 +84,44 @@ func (w *Word) Antonyms() [][]string {
 	return w.Meta.Ants
 }
 
+// Sense is single meaning of Word, together with its short definition
+type Sense struct {
+	sense.Sense
+	Shortdef string
+}
+
+// Senses returns every sense of Word, in order of short definitions.
+// Synonyms and antonyms missing in sense lists are taken from meta, response without "def" has senses built only from meta
+func (w *Word) Senses() []Sense {
+	var all []sense.Sense
+	for _, elem := range w.Def {
+		all = append(all, elem.Sseq.Senses()...)
+	}
+	n := len(all)
+	for _, l := range []int{len(w.Shortdef), len(w.Meta.Syns), len(w.Meta.Ants)} {
+		if l > n {
+			n = l
+		}
+	}
+
+	senses := make([]Sense, n)
+	for i := range senses {
+		if i < len(all) {
+			senses[i].Sense = all[i]
+		}
+		if i < len(w.Shortdef) {
+			senses[i].Shortdef = w.Shortdef[i]
+		}
+		if len(senses[i].Synonyms) == 0 && i < len(w.Meta.Syns) {
+			senses[i].Synonyms = w.Meta.Syns[i]
+		}
+		if len(senses[i].Antonyms) == 0 && i < len(w.Meta.Ants) {
+			senses[i].Antonyms = w.Meta.Ants[i]
+		}
+	}
+	return senses
+}
+
 // IsOffensive returns true, whether word is considered as offensive
 func (w *Word) IsOffensive() bool {
 	return w.Meta.Offensive
@@ -87,9 +132,13 @@ func (w *Word) Function() string {
 	return w.Fl
 }
 
-// NewDefaultThesauruser constructor for default API access
-func NewDefaultThesauruser(key string) *DefaultThesauruser {
-	return &DefaultThesauruser{key: key}
+// NewDefaultThesauruser constructor for default API access,
+// if client is nil, new one with DefaultTimeout is used
+func NewDefaultThesauruser(key string, client *http.Client) *DefaultThesauruser {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &DefaultThesauruser{key: key, client: client}
 }
 
 // query returns prepared URL for Get
@@ -102,8 +151,13 @@ func (d DefaultThesauruser) query(text string) string {
 }
 
 // Get fulfills Thesauruser interface
-func (d DefaultThesauruser) Get(text string) ([]byte, error) {
-	response, err := http.Get(d.query(text))
+func (d DefaultThesauruser) Get(ctx context.Context, text string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.query(text), nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request failed %v", err)
+	}
+
+	response, err := d.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("get failed %v", err)
 	}
@@ -146,9 +200,9 @@ type Word struct {
 	//Hwi struct {
 	//	Hw string `json:"hw"`
 	//} `json:"hwi"`
-	Fl string `json:"fl"`
-	//Def []struct {
-	//	Sseq [][][]interface{} `json:"sseq"`
-	//} `json:"def"`
+	Fl  string `json:"fl"`
+	Def []struct {
+		Sseq sense.Sseq `json:"sseq"`
+	} `json:"def"`
 	Shortdef []string `json:"shortdef"`
 }
@@ -5,6 +5,7 @@
 package thesaurus_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/a-clap/dictionary/internal/merriamw/thesaurus"
 	"github.com/google/go-cmp/cmp"
@@ -18,7 +19,7 @@ func init() {
 type errThesauruser struct {
 }
 
-func (e errThesauruser) Get(_ string) ([]byte, error) {
+func (e errThesauruser) Get(_ context.Context, _ string) ([]byte, error) {
 	return nil, fmt.Errorf("handle me, please")
 }
 
@@ -56,7 +57,7 @@ func TestThesaurus_Translate(t1 *testing.T) {
 		{
 			name: "test some obvious word \"world\"",
 			fields: fields{
-				Thesauruser: thesaurus.NewDefaultThesauruser(thKey),
+				Thesauruser: thesaurus.NewDefaultThesauruser(thKey, nil),
 			},
 			args: args{
 				text: "world",
@@ -85,7 +86,7 @@ func TestThesaurus_Translate(t1 *testing.T) {
 	for _, tt := range tests {
 		t1.Run(tt.name, func(tester *testing.T) {
 			t := thesaurus.NewThesaurus(tt.fields.Thesauruser)
-			gotWords, err := t.Translate(tt.args.text)
+			gotWords, err := t.Translate(context.Background(), tt.args.text)
 			if (err != nil) != tt.wantErr {
 				tester.Fatalf("%s: Translate() error = %v, wantErr %v", tester.Name(), err, tt.wantErr)
 			}
@@ -107,3 +108,59 @@ func TestThesaurus_Translate(t1 *testing.T) {
 		})
 	}
 }
+
+// rawThesauruser returns response as it is
+type rawThesauruser string
+
+func (r rawThesauruser) Get(_ context.Context, _ string) ([]byte, error) {
+	return []byte(r), nil
+}
+
+func TestWord_Senses(t *testing.T) {
+	const response = `[{
+		"meta": {"id": "brain", "syns": [["genius", "intellect"], ["mind", "reason"]], "ants": [["dunce"]]},
+		"fl": "noun",
+		"def": [{"sseq": [
+			[["sense", {"sn": "1", "dt": [["text", "a very smart person"]],
+				"syn_list": [[{"wd": "genius"}, {"wd": "intellect"}]], "near_list": [[{"wd": "ignoramus"}]], "ant_list": [[{"wd": "dunce"}]]}]],
+			[["sense", {"sn": "2", "dt": [["text", "the part of a person that thinks"]], "rel_list": [[{"wd": "psyche"}]]}]]
+		]}],
+		"shortdef": ["a very smart person", "the part of a person that thinks"]
+	}, {
+		"meta": {"id": "brain", "syns": [["bean"]]},
+		"fl": "verb",
+		"shortdef": ["to hit on the head"]
+	}]`
+
+	words, err := thesaurus.NewThesaurus(rawThesauruser(response)).Translate(context.Background(), "brain")
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+
+	senses := words[0].Senses()
+	if len(senses) != 2 {
+		t.Fatalf("Senses() = %#v, want 2 senses", senses)
+	}
+	if diff := cmp.Diff([]string{"ignoramus"}, senses[0].NearAntonyms); diff != "" {
+		t.Fatalf("NearAntonyms mismatch (-want +got):\n%s", diff)
+	}
+	if senses[0].Shortdef != "a very smart person" || senses[0].Number != "1" {
+		t.Fatalf("Senses()[0] = %#v", senses[0])
+	}
+	// Synonyms missing in sense are taken from meta
+	if diff := cmp.Diff([]string{"mind", "reason"}, senses[1].Synonyms); diff != "" {
+		t.Fatalf("Synonyms mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"psyche"}, senses[1].Related); diff != "" {
+		t.Fatalf("Related mismatch (-want +got):\n%s", diff)
+	}
+
+	// Without def, senses come from meta
+	senses = words[1].Senses()
+	if len(senses) != 1 || senses[0].Shortdef != "to hit on the head" {
+		t.Fatalf("Senses() = %#v", senses)
+	}
+	if diff := cmp.Diff([]string{"bean"}, senses[0].Synonyms); diff != "" {
+		t.Fatalf("Synonyms mismatch (-want +got):\n%s", diff)
+	}
+}
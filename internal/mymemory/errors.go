@@ -0,0 +1,51 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package mymemory
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrRateLimited means too many requests were sent or daily quota is used up
+	ErrRateLimited = errors.New("mymemory rate limit reached")
+	// ErrUpstream is any other failure reported by MyMemory, e.g. invalid language pair
+	ErrUpstream = errors.New("mymemory upstream error")
+)
+
+// StatusError is returned, when MyMemory responds with responseStatus other than 200.
+// MyMemory does it with HTTP status 200, putting error message in place of translation.
+// It wraps ErrRateLimited or ErrUpstream, depending on Status
+type StatusError struct {
+	Status  int
+	Details string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%v: status code %d: %s", e.Unwrap(), e.Status, e.Details)
+}
+
+func (e *StatusError) Unwrap() error {
+	if e.Status == http.StatusTooManyRequests {
+		return ErrRateLimited
+	}
+	return ErrUpstream
+}
+
+// status is part of every MyMemory response
+type status struct {
+	ResponseStatus  number `json:"responseStatus"`
+	ResponseDetails string `json:"responseDetails"`
+}
+
+// err returns *StatusError, if status isn't 200
+func (s status) err() error {
+	if s.ResponseStatus == http.StatusOK {
+		return nil
+	}
+	return &StatusError{Status: int(s.ResponseStatus), Details: s.ResponseDetails}
+}
@@ -6,44 +6,56 @@ package mymemory
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 )
 
-type Language int64
-
-const (
-	Polish Language = iota
-	English
-)
+// DefaultTimeout is used by http.Client of Default, when none is provided
+const DefaultTimeout = 10 * time.Second
 
 type GetWord interface {
-	Get(text string, lang Language) ([]byte, error)
+	Get(ctx context.Context, text string, from, to Language) ([]byte, error)
 }
 
 type Default struct {
+	client *http.Client
+	email  string
 }
 
-func NewDefault() *Default {
-	return &Default{}
+// NewDefault creates GetWord accessing MyMemory API with client,
+// if client is nil, new one with DefaultTimeout is used.
+// Non-empty email is sent with each request, MyMemory gives higher quota in that case
+func NewDefault(client *http.Client, email string) *Default {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Default{client: client, email: email}
 }
 
-func query(text string, lang Language) string {
-	const GetUrl = "https://api.mymemory.translated.net/get?q=%s&langpair=%s"
-	text = url.PathEscape(text)
+func (d *Default) query(text string, from, to Language) string {
+	const GetUrl = "https://api.mymemory.translated.net/get"
 
-	langPair := ""
-	if lang == English {
-		langPair = "en|pl"
-	} else {
-		langPair = "pl|en"
+	values := url.Values{
+		"q":        {text},
+		"langpair": {string(from) + "|" + string(to)},
 	}
-	return fmt.Sprintf(GetUrl, text, langPair)
+	if len(d.email) > 0 {
+		values.Set("de", d.email)
+	}
+	return GetUrl + "?" + values.Encode()
 }
 
-func (d *Default) Get(text string, lang Language) ([]byte, error) {
-	response, err := http.Get(query(text, lang))
+func (d *Default) Get(ctx context.Context, text string, from, to Language) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.query(text, from, to), nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request failed %v", err)
+	}
+
+	response, err := d.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("get failed %v", err)
 	}
@@ -58,5 +70,14 @@ func (d *Default) Get(text string, lang Language) ([]byte, error) {
 		return nil, fmt.Errorf("read response body: %v", err)
 	}
 
+	// Error is reported in body, it must not be returned (and cached) as translation
+	var s status
+	if err := json.Unmarshal(buf.Bytes(), &s); err != nil {
+		return nil, fmt.Errorf("decode response body: %v", err)
+	}
+	if err := s.err(); err != nil {
+		return nil, err
+	}
+
 	return buf.Bytes(), nil
 }
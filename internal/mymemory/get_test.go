@@ -0,0 +1,138 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package mymemory_test
+
+import (
+	"context"
+	"github.com/a-clap/dictionary/internal/mymemory"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// roundTripper records request and responds with body
+type roundTripper struct {
+	request *http.Request
+	body    string
+}
+
+func (r *roundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	r.request = request
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(r.body)),
+		Request:    request,
+	}, nil
+}
+
+func TestParseLanguage(t *testing.T) {
+	tests := []struct {
+		lang string
+		want mymemory.Language
+		err  bool
+	}{
+		{lang: "PL", want: mymemory.Polish},
+		{lang: "en-gb", want: mymemory.EnglishBritish},
+		{lang: "PT-br", want: mymemory.PortugueseBrazil},
+		{lang: "ZH", want: mymemory.Chinese},
+		{lang: "xx", err: true},
+		{lang: "", err: true},
+		{lang: "autodetect", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			got, err := mymemory.ParseLanguage(tt.lang)
+			if tt.err {
+				require.ErrorIs(t, err, mymemory.ErrInvalidLang)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMyMemory_Translate(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		from, to mymemory.Language
+		query    map[string]string
+		err      bool
+	}{
+		{
+			name:  "pair",
+			from:  mymemory.German,
+			to:    mymemory.French,
+			query: map[string]string{"q": "Gehirn", "langpair": "de|fr", "de": ""},
+		},
+		{
+			name:  "autodetect with email",
+			email: "adam@example.com",
+			from:  mymemory.Autodetect,
+			to:    mymemory.Polish,
+			query: map[string]string{"q": "Gehirn", "langpair": "autodetect|pl", "de": "adam@example.com"},
+		},
+		{
+			name: "autodetect is not a target",
+			from: mymemory.Polish,
+			to:   mymemory.Autodetect,
+			err:  true,
+		},
+		{
+			name: "unknown language",
+			from: mymemory.Language("xx"),
+			to:   mymemory.Polish,
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &roundTripper{body: `{"responseData":{"translatedText":"cerveau"},"responseStatus":200}`}
+			m := mymemory.NewMyMemory(mymemory.NewDefault(&http.Client{Transport: rt}, tt.email))
+
+			w, err := m.Translate(context.Background(), "Gehirn", tt.from, tt.to)
+			if tt.err {
+				require.ErrorIs(t, err, mymemory.ErrInvalidLang)
+				require.Nil(t, rt.request)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, "cerveau", w.Translated())
+			for k, v := range tt.query {
+				require.Equal(t, v, rt.request.URL.Query().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestDefault_Get_status(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		err  error
+	}{
+		{name: "ok", body: `{"responseData":{"translatedText":"mózg"},"responseStatus":200}`},
+		{name: "ok as string", body: `{"responseData":{"translatedText":"mózg"},"responseStatus":"200"}`},
+		{name: "rate limited", body: `{"responseData":{"translatedText":"MYMEMORY WARNING"},"responseStatus":429}`, err: mymemory.ErrRateLimited},
+		{name: "invalid pair", body: `{"responseData":{"translatedText":"INVALID LANGUAGE PAIR"},"responseStatus":"403","responseDetails":"INVALID LANGUAGE PAIR"}`, err: mymemory.ErrUpstream},
+		{name: "missing status", body: `{"responseData":{"translatedText":"mózg"}}`, err: mymemory.ErrUpstream},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mymemory.NewDefault(&http.Client{Transport: &roundTripper{body: tt.body}}, "")
+			data, err := g.Get(context.Background(), "brain", mymemory.English, mymemory.Polish)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				require.Nil(t, data)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.body, string(data))
+		})
+	}
+}
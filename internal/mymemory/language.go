@@ -0,0 +1,95 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package mymemory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Language is ISO 639-1 code, optionally followed by region, e.g. "en", "en-GB"
+type Language string
+
+const (
+	// Autodetect as source language makes MyMemory detect it on its own
+	Autodetect Language = "autodetect"
+
+	Bulgarian          Language = "bg"
+	Czech              Language = "cs"
+	Danish             Language = "da"
+	German             Language = "de"
+	Greek              Language = "el"
+	English            Language = "en"
+	EnglishBritish     Language = "en-GB"
+	EnglishAmerican    Language = "en-US"
+	Spanish            Language = "es"
+	Estonian           Language = "et"
+	Finnish            Language = "fi"
+	French             Language = "fr"
+	Hungarian          Language = "hu"
+	Indonesian         Language = "id"
+	Italian            Language = "it"
+	Japanese           Language = "ja"
+	Lithuanian         Language = "lt"
+	Latvian            Language = "lv"
+	Dutch              Language = "nl"
+	Polish             Language = "pl"
+	Portuguese         Language = "pt"
+	PortugueseEuropean Language = "pt-PT"
+	PortugueseBrazil   Language = "pt-BR"
+	Romanian           Language = "ro"
+	Russian            Language = "ru"
+	Slovak             Language = "sk"
+	Slovenian          Language = "sl"
+	Swedish            Language = "sv"
+	Turkish            Language = "tr"
+	Chinese            Language = "zh-CN"
+)
+
+var ErrInvalidLang = errors.New("invalid language")
+
+// languages is table of supported languages, same set as DeepL supports
+var languages = []Language{
+	Bulgarian, Czech, Danish, German, Greek, English, EnglishBritish, EnglishAmerican, Spanish, Estonian, Finnish,
+	French, Hungarian, Indonesian, Italian, Japanese, Lithuanian, Latvian, Dutch, Polish, Portuguese,
+	PortugueseEuropean, PortugueseBrazil, Romanian, Russian, Slovak, Slovenian, Swedish, Turkish, Chinese,
+}
+
+// aliases maps codes without region to language, which MyMemory expects
+var aliases = map[string]Language{
+	"zh": Chinese,
+}
+
+// ParseLanguage returns Language for case-insensitive lang, e.g. "EN-gb" -> EnglishBritish
+func ParseLanguage(lang string) (Language, error) {
+	base, region, hasRegion := strings.Cut(lang, "-")
+	code := strings.ToLower(base)
+	if hasRegion {
+		code += "-" + strings.ToUpper(region)
+	}
+	if l, ok := aliases[code]; ok {
+		return l, nil
+	}
+	for _, elem := range languages {
+		if string(elem) == code {
+			return elem, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrInvalidLang, lang)
+}
+
+// validate checks, whether from-to is supported pair
+func validate(from, to Language) error {
+	if from != Autodetect {
+		if _, err := ParseLanguage(string(from)); err != nil {
+			return fmt.Errorf("source %w", err)
+		}
+	}
+	if _, err := ParseLanguage(string(to)); err != nil {
+		return fmt.Errorf("target %w", err)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@
 package mymemory
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/a-clap/logger"
@@ -20,14 +21,20 @@ func NewMyMemory(word GetWord) *MyMemory {
 	return &MyMemory{GetWord: word}
 }
 
-func NewMyMemoryDefault() *MyMemory {
-	return NewMyMemory(NewDefault())
+// NewMyMemoryDefault creates MyMemory accessing API, see NewDefault for email
+func NewMyMemoryDefault(email string) *MyMemory {
+	return NewMyMemory(NewDefault(nil, email))
 }
 
-func (d *MyMemory) Translate(word string, lang Language) (words *Word, err error) {
-	data, err := d.Get(word, lang)
+// Translate translates word from one language into another, from may be Autodetect
+func (d *MyMemory) Translate(ctx context.Context, word string, from, to Language) (words *Word, err error) {
+	if err := validate(from, to); err != nil {
+		return nil, err
+	}
+
+	data, err := d.Get(ctx, word, from, to)
 	if err != nil {
-		return nil, fmt.Errorf("error on get: %v", err)
+		return nil, fmt.Errorf("error on get: %w", err)
 	}
 
 	err = json.Unmarshal(data, &words)
@@ -35,5 +42,9 @@ func (d *MyMemory) Translate(word string, lang Language) (words *Word, err error
 		Logger.Errorf("error decoding json %v", err)
 		return
 	}
+	// Other GetWord implementations may pass error response on
+	if err = words.Err(); err != nil {
+		return nil, err
+	}
 	return
 }
@@ -4,34 +4,102 @@
 
 package mymemory
 
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+)
+
+// Err returns *StatusError, if MyMemory responded with error instead of translation
+func (w *Word) Err() error {
+	return w.status.err()
+}
+
 func (w *Word) Translated() string {
 	return w.ResponseData.TranslatedText
 }
 
+// Match returns how well Translated matches requested text, from 0 to 1
+func (w *Word) Match() float64 {
+	return w.ResponseData.Match
+}
+
+// Alternative is single translation memory match
 type Alternative struct {
-	text, translation string
+	// Text is segment from translation memory, which matched requested text
+	Text string `json:"text"`
+	// Translation of Text
+	Translation string `json:"translation"`
+	// Match is how well Text matches requested text, from 0 to 1
+	Match float64 `json:"match"`
+	// Quality of Translation, from 0 to 100
+	Quality int `json:"quality"`
+	// UsageCount is how many times Translation was used
+	UsageCount int `json:"usage_count"`
+	// Source tells, who created Translation, e.g. "MT!" for machine translation
+	Source string `json:"source"`
 }
 
+// Alternatives returns matches ranked by match score, then by quality and usage count
 func (w *Word) Alternatives() []Alternative {
 	alt := make([]Alternative, 0, len(w.Matches))
 	for _, elem := range w.Matches {
 		alt = append(alt, Alternative{
-			text:        elem.Segment,
-			translation: elem.Translation,
+			Text:        elem.Segment,
+			Translation: elem.Translation,
+			Match:       elem.Match,
+			Quality:     int(elem.Quality),
+			UsageCount:  elem.UsageCount,
+			Source:      elem.CreatedBy,
 		})
 	}
+
+	sort.SliceStable(alt, func(i, j int) bool {
+		if alt[i].Match != alt[j].Match {
+			return alt[i].Match > alt[j].Match
+		}
+		if alt[i].Quality != alt[j].Quality {
+			return alt[i].Quality > alt[j].Quality
+		}
+		return alt[i].UsageCount > alt[j].UsageCount
+	})
 	return alt
 }
 
+// number is sent by MyMemory either as JSON number or as string, e.g. "quality": "74"
+type number int
+
+func (n *number) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var f float64
+		if err := json.Unmarshal(data, &f); err != nil {
+			return err
+		}
+		*n = number(f)
+		return nil
+	}
+	if s == "" {
+		*n = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*n = number(f)
+	return nil
+}
+
 type Word struct {
 	ResponseData struct {
-		TranslatedText string `json:"translatedText"`
-		//Match          float64 `json:"match"`
+		TranslatedText string  `json:"translatedText"`
+		Match          float64 `json:"match"`
 	} `json:"responseData"`
-	//QuotaFinished   bool        `json:"quotaFinished"`
+	QuotaFinished bool `json:"quotaFinished"`
 	//MtLangSupported interface{} `json:"mtLangSupported"`
-	//ResponseDetails string      `json:"responseDetails"`
-	//ResponseStatus  int         `json:"responseStatus"`
+	// ResponseStatus and ResponseDetails, see Err
+	status
 	//ResponderId     string      `json:"responderId"`
 	//ExceptionCode   interface{} `json:"exception_code"`
 	Matches []struct {
@@ -40,14 +108,14 @@ type Word struct {
 		Translation string `json:"translation"`
 		//Source         string      `json:"source"`
 		//Target         string      `json:"target"`
-		//Quality        string      `json:"quality"`
+		Quality number `json:"quality"`
 		//Reference      interface{} `json:"reference"`
-		//UsageCount     int         `json:"usage-count"`
+		UsageCount int `json:"usage-count"`
 		//Subject        string      `json:"subject"`
-		//CreatedBy      string      `json:"created-by"`
+		CreatedBy string `json:"created-by"`
 		//LastUpdatedBy  string      `json:"last-updated-by"`
 		//CreateDate     string      `json:"create-date"`
 		//LastUpdateDate string      `json:"last-update-date"`
-		//Match          float64     `json:"match"`
+		Match float64 `json:"match"`
 	} `json:"matches"`
 }
@@ -0,0 +1,47 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package mymemory_test
+
+import (
+	"encoding/json"
+	"github.com/a-clap/dictionary/internal/mymemory"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWord_Alternatives(t *testing.T) {
+	data := `{
+		"responseData": {"translatedText": "mózg", "match": 0.99},
+		"matches": [
+			{"segment": "brain", "translation": "umysł", "quality": "74", "usage-count": 2, "created-by": "MateCat", "match": 0.98},
+			{"segment": "brain", "translation": "mózg", "quality": 0, "usage-count": 1, "created-by": "MT!", "match": 0.99},
+			{"segment": "the brain", "translation": "mózgownica", "quality": "80", "usage-count": 5, "created-by": "anonymous", "match": 0.98},
+			{"segment": "brains", "translation": "mózgi", "quality": "", "usage-count": 0, "match": 0.5}
+		]
+	}`
+	var w mymemory.Word
+	require.Nil(t, json.Unmarshal([]byte(data), &w))
+	require.Equal(t, "mózg", w.Translated())
+	require.Equal(t, 0.99, w.Match())
+
+	require.Equal(t, []mymemory.Alternative{
+		{Text: "brain", Translation: "mózg", Match: 0.99, Quality: 0, UsageCount: 1, Source: "MT!"},
+		{Text: "the brain", Translation: "mózgownica", Match: 0.98, Quality: 80, UsageCount: 5, Source: "anonymous"},
+		{Text: "brain", Translation: "umysł", Match: 0.98, Quality: 74, UsageCount: 2, Source: "MateCat"},
+		{Text: "brains", Translation: "mózgi", Match: 0.5},
+	}, w.Alternatives())
+}
+
+func TestWord_Err(t *testing.T) {
+	var w mymemory.Word
+	require.Nil(t, json.Unmarshal([]byte(`{"responseStatus":200}`), &w))
+	require.Nil(t, w.Err())
+
+	require.Nil(t, json.Unmarshal([]byte(`{"responseStatus":"429","responseDetails":"MYMEMORY WARNING"}`), &w))
+	var statusErr *mymemory.StatusError
+	require.ErrorAs(t, w.Err(), &statusErr)
+	require.Equal(t, mymemory.StatusError{Status: 429, Details: "MYMEMORY WARNING"}, *statusErr)
+	require.ErrorIs(t, w.Err(), mymemory.ErrRateLimited)
+}
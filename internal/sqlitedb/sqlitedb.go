@@ -0,0 +1,60 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+// Package sqlitedb opens sqlite databases of stores and keeps their schema up to date
+package sqlitedb
+
+import (
+	"database/sql"
+	"fmt"
+
+	// pure-Go sqlite driver, doesn't require cgo
+	_ "modernc.org/sqlite"
+)
+
+// Open opens (or creates) database at path and applies migrations, which weren't applied yet.
+// Migrations are applied in order, PRAGMA user_version holds number of already applied ones,
+// so existing migration must never be modified, new one is always appended
+func Open(path string, migrations []string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open: %w", err)
+	}
+	// sqlite doesn't like concurrent writers, also in-memory database lives only within single connection
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db, migrations); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// migrate applies every migration in its own transaction, together with update of user_version
+func migrate(db *sql.DB, migrations []string) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for ; version < len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		if _, err := tx.Exec(migrations[version]); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		// PRAGMA doesn't accept bound parameters
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
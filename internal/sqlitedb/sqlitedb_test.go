@@ -0,0 +1,45 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package sqlitedb_test
+
+import (
+	"github.com/a-clap/dictionary/internal/sqlitedb"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	migrations := []string{
+		`CREATE TABLE words (text TEXT NOT NULL);`,
+	}
+
+	db, err := sqlitedb.Open(path, migrations)
+	require.Nil(t, err)
+	_, err = db.Exec(`INSERT INTO words (text) VALUES ('brain')`)
+	require.Nil(t, err)
+	require.Nil(t, db.Close())
+
+	// Only new migration is applied on reopening, data is kept
+	migrations = append(migrations, `ALTER TABLE words ADD COLUMN lang TEXT;`)
+	db, err = sqlitedb.Open(path, migrations)
+	require.Nil(t, err)
+	var text string
+	require.Nil(t, db.QueryRow(`SELECT text FROM words WHERE lang IS NULL`).Scan(&text))
+	require.Equal(t, "brain", text)
+	var version int
+	require.Nil(t, db.QueryRow(`PRAGMA user_version`).Scan(&version))
+	require.Equal(t, 2, version)
+	require.Nil(t, db.Close())
+
+	// Failed migration is rolled back
+	db, err = sqlitedb.Open(path, append(migrations, `CREATE TABLE words (text TEXT);`))
+	require.ErrorContains(t, err, "migration 3")
+	require.Nil(t, db)
+
+	_, err = sqlitedb.Open(filepath.Join(t.TempDir(), "not", "existing", "dir.db"), migrations)
+	require.NotNil(t, err)
+}
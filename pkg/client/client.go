@@ -0,0 +1,274 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+// Package client talks to running server.Server on behalf of logged user
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/a-clap/dictionary/pkg/review"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/a-clap/dictionary/pkg/wordlist"
+	"github.com/a-clap/logger"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var Logger logger.Logger = logger.NewNop()
+
+// DefaultTimeout is used by http.Client of Client, when none is provided
+const DefaultTimeout = 30 * time.Second
+
+var (
+	// ErrUnauthorized means missing, expired or invalid token, also invalid credentials
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+	ErrInvalid      = errors.New("invalid request")
+	// ErrServer is every other error response of server
+	ErrServer = errors.New("server error")
+)
+
+// StatusError is error response of server
+type StatusError struct {
+	Status  int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%v: status code %d: %s", e.Unwrap(), e.Status, e.Message)
+}
+
+func (e *StatusError) Unwrap() error {
+	switch e.Status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusBadRequest:
+		return ErrInvalid
+	default:
+		return ErrServer
+	}
+}
+
+// Tokens are issued by server on login and refresh
+type Tokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Client sends requests to server at base address, e.g. "http://localhost:8080".
+// Expired token is refreshed once per request, if Client has refresh token
+type Client struct {
+	base   string
+	client *http.Client
+
+	mtx       sync.Mutex
+	tokens    Tokens
+	onRefresh func(Tokens)
+}
+
+// New is default constructor for Client, if client is nil, new one with DefaultTimeout is used
+func New(base string, client *http.Client) *Client {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{base: strings.TrimSuffix(base, "/"), client: client}
+}
+
+// SetTokens sets tokens used by requests, e.g. stored after previous Login
+func (c *Client) SetTokens(t Tokens) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.tokens = t
+}
+
+// Tokens returns current tokens, they change on Login and refresh
+func (c *Client) Tokens() Tokens {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.tokens
+}
+
+// OnRefresh sets callback called with new tokens, whenever Client refreshes them, so they can be stored
+func (c *Client) OnRefresh(f func(Tokens)) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.onRefresh = f
+}
+
+// Login authenticates user and keeps issued tokens
+func (c *Client) Login(ctx context.Context, name, password string) (Tokens, error) {
+	var t Tokens
+	body := map[string]string{"name": name, "password": password}
+	if err := c.send(ctx, http.MethodPost, "/api/user/login", body, &t); err != nil {
+		return Tokens{}, err
+	}
+	c.SetTokens(t)
+	return t, nil
+}
+
+// Logout invalidates current token
+func (c *Client) Logout(ctx context.Context) error {
+	if err := c.do(ctx, http.MethodPost, "/api/user/logout", nil, nil); err != nil {
+		return err
+	}
+	c.SetTokens(Tokens{})
+	return nil
+}
+
+// Translate translates text from language (empty means detection) to language
+func (c *Client) Translate(ctx context.Context, text, from, to string, autocorrect bool) (*translator.Translation, error) {
+	query := url.Values{"text": {text}, "from": {from}, "to": {to}}
+	if autocorrect {
+		query.Set("autocorrect", "true")
+	}
+	var t translator.Translation
+	if err := c.do(ctx, http.MethodGet, "/api/translate?"+query.Encode(), nil, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// AddWord translates text and saves it in user's word list
+func (c *Client) AddWord(ctx context.Context, text, from, to string) (*wordlist.Word, error) {
+	var w wordlist.Word
+	body := map[string]string{"text": text, "from": from, "to": to}
+	if err := c.do(ctx, http.MethodPost, "/api/words", body, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Words returns every word of user, from the oldest one
+func (c *Client) Words(ctx context.Context) ([]wordlist.Word, error) {
+	var w []wordlist.Word
+	if err := c.do(ctx, http.MethodGet, "/api/words", nil, &w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// RemoveWord removes word with id from user's word list
+func (c *Client) RemoveWord(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/words/"+url.PathEscape(id), nil, nil)
+}
+
+// AddCard creates review card of word with id
+func (c *Client) AddCard(ctx context.Context, wordID string) (*review.Card, error) {
+	var card review.Card
+	if err := c.do(ctx, http.MethodPost, "/api/review", map[string]string{"word_id": wordID}, &card); err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// Due returns at most limit cards due for review
+func (c *Client) Due(ctx context.Context, limit int) ([]review.Card, error) {
+	var cards []review.Card
+	if err := c.do(ctx, http.MethodGet, "/api/review/due?limit="+strconv.Itoa(limit), nil, &cards); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// Grade submits review grade of card with id, see review.MinGrade and review.MaxGrade
+func (c *Client) Grade(ctx context.Context, id string, grade int) (*review.Card, error) {
+	var card review.Card
+	if err := c.do(ctx, http.MethodPost, "/api/review/"+url.PathEscape(id), map[string]int{"grade": grade}, &card); err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// do sends authorized request, refreshing expired token once
+func (c *Client) do(ctx context.Context, method, path string, body, v interface{}) error {
+	err := c.send(ctx, method, path, body, v)
+	if !errors.Is(err, ErrUnauthorized) || len(c.Tokens().RefreshToken) == 0 {
+		return err
+	}
+
+	Logger.Debugf("%s %s unauthorized, refreshing token", method, path)
+	if refreshErr := c.refresh(ctx); refreshErr != nil {
+		Logger.Debugf("refresh failed: %v", refreshErr)
+		return err
+	}
+	return c.send(ctx, method, path, body, v)
+}
+
+func (c *Client) refresh(ctx context.Context) error {
+	var t Tokens
+	body := map[string]string{"refresh_token": c.Tokens().RefreshToken}
+	if err := c.send(ctx, http.MethodPost, "/api/user/refresh", body, &t); err != nil {
+		return err
+	}
+	c.SetTokens(t)
+
+	c.mtx.Lock()
+	onRefresh := c.onRefresh
+	c.mtx.Unlock()
+	if onRefresh != nil {
+		onRefresh(t)
+	}
+	return nil
+}
+
+// send sends single request with current token, body is encoded and response decoded into v as JSON, if they aren't nil
+func (c *Client) send(ctx context.Context, method, path string, body, v interface{}) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		r = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, r)
+	if err != nil {
+		return fmt.Errorf("new request failed %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.Tokens().Token; len(token) > 0 {
+		req.Header.Set("Authorization", token)
+	}
+
+	response, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode >= http.StatusMultipleChoices {
+		var e struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(response.Body)
+		if json.Unmarshal(data, &e) != nil || len(e.Error) == 0 {
+			e.Error = strings.TrimSpace(string(data))
+		}
+		return &StatusError{Status: response.StatusCode, Message: e.Error}
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/a-clap/dictionary/pkg/client"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeServer accepts only token, refresh exchanges refreshToken for token
+type fakeServer struct {
+	token        string
+	refreshToken string
+	refreshed    int
+	requests     []string
+	bodies       []map[string]interface{}
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r.Method+" "+r.URL.String())
+	body := make(map[string]interface{})
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	f.bodies = append(f.bodies, body)
+
+	respond := func(code int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+
+	switch r.URL.Path {
+	case "/api/user/login":
+		if body["password"] != "pwd" {
+			respond(http.StatusUnauthorized, map[string]string{"error": "invalid credentials"})
+			return
+		}
+		respond(http.StatusOK, client.Tokens{Token: f.token, RefreshToken: f.refreshToken})
+		return
+	case "/api/user/refresh":
+		if body["refresh_token"] != f.refreshToken {
+			respond(http.StatusUnauthorized, map[string]string{"error": "invalid refresh token"})
+			return
+		}
+		f.refreshed++
+		f.token, f.refreshToken = f.token+"+", f.refreshToken+"+"
+		respond(http.StatusOK, client.Tokens{Token: f.token, RefreshToken: f.refreshToken})
+		return
+	}
+
+	if r.Header.Get("Authorization") != f.token {
+		respond(http.StatusUnauthorized, map[string]string{"error": "token expired"})
+		return
+	}
+	switch r.Method + " " + r.URL.Path {
+	case "GET /api/translate":
+		respond(http.StatusOK, translator.Translation{Provider: translator.SourceDeepL, Deepl: []translator.DeeplTranslate{{Text: "mózg"}}})
+	case "POST /api/words":
+		respond(http.StatusCreated, map[string]interface{}{"id": "1", "text": body["text"]})
+	case "GET /api/words":
+		respond(http.StatusOK, []map[string]string{{"id": "1", "text": "brain"}})
+	case "DELETE /api/words/1":
+		respond(http.StatusOK, map[string]string{"id": "1"})
+	case "GET /api/review/due":
+		respond(http.StatusOK, []map[string]interface{}{{"id": "c1", "word_id": "1", "text": "brain"}})
+	case "POST /api/review/c1":
+		respond(http.StatusOK, map[string]interface{}{"id": "c1", "interval": 1})
+	case "POST /api/user/logout":
+		respond(http.StatusOK, map[string]string{"name": "adam"})
+	default:
+		respond(http.StatusNotFound, map[string]string{"error": "word doesn't exist"})
+	}
+}
+
+func TestClient(t *testing.T) {
+	f := &fakeServer{token: "token", refreshToken: "refresh"}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	c := client.New(srv.URL+"/", nil)
+	ctx := context.Background()
+
+	_, err := c.Login(ctx, "adam", "wrong")
+	require.True(t, errors.Is(err, client.ErrUnauthorized), err)
+	require.Contains(t, err.Error(), "invalid credentials")
+
+	tokens, err := c.Login(ctx, "adam", "pwd")
+	require.Nil(t, err)
+	require.Equal(t, client.Tokens{Token: "token", RefreshToken: "refresh"}, tokens)
+	require.Equal(t, tokens, c.Tokens())
+
+	translation, err := c.Translate(ctx, "brain", "EN", "PL", true)
+	require.Nil(t, err)
+	require.Equal(t, "mózg", translation.Deepl[0].Text)
+	require.Equal(t, "GET /api/translate?autocorrect=true&from=EN&text=brain&to=PL", f.requests[len(f.requests)-1])
+
+	word, err := c.AddWord(ctx, "brain", "EN", "PL")
+	require.Nil(t, err)
+	require.Equal(t, "1", word.ID)
+	require.Equal(t, map[string]interface{}{"text": "brain", "from": "EN", "to": "PL"}, f.bodies[len(f.bodies)-1])
+
+	words, err := c.Words(ctx)
+	require.Nil(t, err)
+	require.Len(t, words, 1)
+	require.Nil(t, c.RemoveWord(ctx, "1"))
+	err = c.RemoveWord(ctx, "2")
+	require.True(t, errors.Is(err, client.ErrNotFound), err)
+
+	cards, err := c.Due(ctx, 5)
+	require.Nil(t, err)
+	require.Len(t, cards, 1)
+	require.Equal(t, "GET /api/review/due?limit=5", f.requests[len(f.requests)-1])
+	card, err := c.Grade(ctx, "c1", 4)
+	require.Nil(t, err)
+	require.Equal(t, 1, card.Interval)
+	require.Equal(t, map[string]interface{}{"grade": 4.0}, f.bodies[len(f.bodies)-1])
+
+	require.Nil(t, c.Logout(ctx))
+	require.Equal(t, client.Tokens{}, c.Tokens())
+	_, err = c.Words(ctx)
+	require.True(t, errors.Is(err, client.ErrUnauthorized), err)
+}
+
+func TestClient_refresh(t *testing.T) {
+	f := &fakeServer{token: "new", refreshToken: "refresh"}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	c := client.New(srv.URL, nil)
+	c.SetTokens(client.Tokens{Token: "expired", RefreshToken: "refresh"})
+	var stored client.Tokens
+	c.OnRefresh(func(t client.Tokens) {
+		stored = t
+	})
+
+	words, err := c.Words(context.Background())
+	require.Nil(t, err)
+	require.Len(t, words, 1)
+	require.Equal(t, 1, f.refreshed)
+	require.Equal(t, client.Tokens{Token: "new+", RefreshToken: "refresh+"}, stored)
+	require.Equal(t, stored, c.Tokens())
+
+	// Invalid refresh token returns the original error
+	c.SetTokens(client.Tokens{Token: "expired", RefreshToken: "invalid"})
+	_, err = c.Words(context.Background())
+	require.True(t, errors.Is(err, client.ErrUnauthorized), err)
+	require.Contains(t, err.Error(), "token expired")
+	require.Equal(t, 1, f.refreshed)
+}
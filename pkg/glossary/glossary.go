@@ -0,0 +1,200 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package glossary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/logger"
+	"sort"
+	"strings"
+	"time"
+)
+
+var Logger logger.Logger = logger.NewNop()
+
+var (
+	ErrNotExist = errors.New("glossary doesn't exist")
+	ErrInvalid  = errors.New("invalid argument")
+	ErrUpstream = errors.New("upstream error")
+	ErrIO       = errors.New("io error")
+)
+
+// Glossary is user's set of terms for one language pair, compiled into DeepL glossary.
+// DeepL glossaries don't distinguish regions, so To is language without region, e.g. "EN"
+type Glossary struct {
+	ID      string                `json:"id"`
+	From    deepl.SourceLang      `json:"from"`
+	To      deepl.SourceLang      `json:"to"`
+	Entries []deepl.GlossaryEntry `json:"entries"`
+	Created time.Time             `json:"created"`
+}
+
+// GlossaryStore realizes access to users glossaries, only one glossary per language pair is allowed.
+// Errors returned by interface should be ONLY related to internal IO errors
+type GlossaryStore interface {
+	// SaveGlossary saves glossary for user. Overwrites, if glossary for the same pair already exists
+	SaveGlossary(user string, g Glossary) error
+	// LoadGlossary returns glossary for from-to pair, nil if it doesn't exist
+	LoadGlossary(user string, from, to deepl.SourceLang) (*Glossary, error)
+	// LoadGlossaries returns every glossary of user, in any order
+	LoadGlossaries(user string) ([]Glossary, error)
+	// RemoveGlossary removes glossary for from-to pair, if it doesn't exist, don't do anything
+	RemoveGlossary(user string, from, to deepl.SourceLang) error
+}
+
+// Client manages glossaries on DeepL side, satisfied by deepl.DeeplerDefault
+type Client interface {
+	CreateGlossary(ctx context.Context, name string, source, target deepl.SourceLang, entries string, format deepl.EntriesFormat) (*deepl.Glossary, error)
+	DeleteGlossary(ctx context.Context, id string) error
+}
+
+// Glossaries keeps users glossaries in sync with DeepL
+type Glossaries struct {
+	s   GlossaryStore
+	c   Client
+	now func() time.Time
+}
+
+// New is default constructor for Glossaries
+func New(store GlossaryStore, client Client) *Glossaries {
+	return &Glossaries{
+		s:   store,
+		c:   client,
+		now: time.Now,
+	}
+}
+
+// SetClock replaces source of current time, useful for testing
+func (g *Glossaries) SetClock(now func() time.Time) {
+	g.now = now
+}
+
+// Compile creates DeepL glossary from entries, replacing user's previous glossary for the same pair
+func (g *Glossaries) Compile(ctx context.Context, user string, from deepl.SourceLang, to deepl.TargetLang, entries []deepl.GlossaryEntry) (*Glossary, error) {
+	if len(user) == 0 || len(from) == 0 || len(to) == 0 {
+		return nil, fmt.Errorf("%w: user and both languages must be provided", ErrInvalid)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: glossary requires at least one entry", ErrInvalid)
+	}
+
+	seen := make(map[string]struct{}, len(entries))
+	clean := make([]deepl.GlossaryEntry, len(entries))
+	for i, e := range entries {
+		e.Source, e.Target = strings.TrimSpace(e.Source), strings.TrimSpace(e.Target)
+		if _, ok := seen[e.Source]; ok {
+			return nil, fmt.Errorf("%w: duplicated entry %s", ErrInvalid, e.Source)
+		}
+		seen[e.Source] = struct{}{}
+		clean[i] = e
+	}
+
+	tsv, err := deepl.EncodeEntries(clean, deepl.FormatTSV)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	old, err := g.load(user, from, to.Base())
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s %s-%s", user, from, to.Base())
+	created, err := g.c.CreateGlossary(ctx, name, from, to.Base(), tsv, deepl.FormatTSV)
+	if err != nil {
+		return nil, fmt.Errorf("%w: CreateGlossary: %v", ErrUpstream, err)
+	}
+
+	glossary := Glossary{
+		ID:      created.ID,
+		From:    from,
+		To:      to.Base(),
+		Entries: clean,
+		Created: g.now(),
+	}
+	if err := g.s.SaveGlossary(user, glossary); err != nil {
+		if err := g.c.DeleteGlossary(ctx, created.ID); err != nil {
+			Logger.Errorf("failed to delete unsaved glossary %s: %v", created.ID, err)
+		}
+		return nil, fmt.Errorf("%w: SaveGlossary: user %s, error: %v", ErrIO, user, err)
+	}
+
+	if old != nil {
+		// Replaced glossary is useless, failing to remove it just wastes space on DeepL side
+		if err := g.c.DeleteGlossary(ctx, old.ID); err != nil {
+			Logger.Errorf("failed to delete replaced glossary %s: %v", old.ID, err)
+		}
+	}
+	return &glossary, nil
+}
+
+// Glossary returns user's glossary for from-to pair
+func (g *Glossaries) Glossary(user string, from deepl.SourceLang, to deepl.TargetLang) (*Glossary, error) {
+	glossary, err := g.load(user, from, to.Base())
+	if err != nil {
+		return nil, err
+	}
+	if glossary == nil {
+		return nil, fmt.Errorf("%w: %s-%s", ErrNotExist, from, to.Base())
+	}
+	return glossary, nil
+}
+
+// List returns every glossary of user, sorted by language pair
+func (g *Glossaries) List(user string) ([]Glossary, error) {
+	glossaries, err := g.s.LoadGlossaries(user)
+	if err != nil {
+		return nil, fmt.Errorf("%w: LoadGlossaries: user %s, error: %v", ErrIO, user, err)
+	}
+	if glossaries == nil {
+		glossaries = []Glossary{}
+	}
+
+	sort.Slice(glossaries, func(i, j int) bool {
+		if glossaries[i].From == glossaries[j].From {
+			return glossaries[i].To < glossaries[j].To
+		}
+		return glossaries[i].From < glossaries[j].From
+	})
+	return glossaries, nil
+}
+
+// ID returns id of DeepL glossary, which should be used to translate from-to pair, empty if user doesn't have one
+func (g *Glossaries) ID(user string, from deepl.SourceLang, to deepl.TargetLang) (string, error) {
+	glossary, err := g.load(user, from, to.Base())
+	if err != nil || glossary == nil {
+		return "", err
+	}
+	return glossary.ID, nil
+}
+
+// Remove removes user's glossary for from-to pair, also from DeepL
+func (g *Glossaries) Remove(ctx context.Context, user string, from deepl.SourceLang, to deepl.TargetLang) error {
+	glossary, err := g.Glossary(user, from, to)
+	if err != nil {
+		return err
+	}
+
+	if err := g.s.RemoveGlossary(user, from, to.Base()); err != nil {
+		return fmt.Errorf("%w: RemoveGlossary: user %s, error: %v", ErrIO, user, err)
+	}
+	// Glossary might be already removed on DeepL side, user shouldn't be stuck with it anyway
+	if err := g.c.DeleteGlossary(ctx, glossary.ID); err != nil {
+		Logger.Errorf("failed to delete glossary %s: %v", glossary.ID, err)
+	}
+	return nil
+}
+
+// load is wrapper for interface call LoadGlossary, returns appropriate wrapped error
+func (g *Glossaries) load(user string, from, to deepl.SourceLang) (*Glossary, error) {
+	glossary, err := g.s.LoadGlossary(user, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: LoadGlossary: user %s, pair %s-%s, error: %v", ErrIO, user, from, to, err)
+	}
+	return glossary, nil
+}
@@ -0,0 +1,155 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package glossary_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/pkg/glossary"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var _ glossary.GlossaryStore = errStore{}
+
+type errStore struct {
+}
+
+func (e errStore) SaveGlossary(_ string, _ glossary.Glossary) error {
+	return fmt.Errorf("io err")
+}
+
+func (e errStore) LoadGlossary(_ string, _, _ deepl.SourceLang) (*glossary.Glossary, error) {
+	return nil, fmt.Errorf("io err")
+}
+
+func (e errStore) LoadGlossaries(_ string) ([]glossary.Glossary, error) {
+	return nil, fmt.Errorf("io err")
+}
+
+func (e errStore) RemoveGlossary(_ string, _, _ deepl.SourceLang) error {
+	return fmt.Errorf("io err")
+}
+
+// fakeClient keeps glossaries in map, ids are consecutive numbers
+type fakeClient struct {
+	glossaries map[string]string
+	next       int
+	err        error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{glossaries: map[string]string{}}
+}
+
+func (f *fakeClient) CreateGlossary(_ context.Context, _ string, source, target deepl.SourceLang, entries string, _ deepl.EntriesFormat) (*deepl.Glossary, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.next++
+	id := fmt.Sprint(f.next)
+	f.glossaries[id] = fmt.Sprintf("%s-%s:%s", source, target, entries)
+	return &deepl.Glossary{ID: id}, nil
+}
+
+func (f *fakeClient) DeleteGlossary(_ context.Context, id string) error {
+	if _, ok := f.glossaries[id]; !ok {
+		return fmt.Errorf("not found")
+	}
+	delete(f.glossaries, id)
+	return nil
+}
+
+func TestGlossaries_Compile(t *testing.T) {
+	ctx := context.Background()
+	c := newFakeClient()
+	g := glossary.New(glossary.NewMemoryStore(), c)
+
+	first, err := g.Compile(ctx, "adam", deepl.SrcEnglish, deepl.TarPolish, []deepl.GlossaryEntry{{Source: " brain ", Target: "mózg"}})
+	require.Nil(t, err)
+	require.Equal(t, "1", first.ID)
+	require.Equal(t, []deepl.GlossaryEntry{{Source: "brain", Target: "mózg"}}, first.Entries)
+	require.Equal(t, "EN-PL:brain\tmózg\n", c.glossaries["1"])
+
+	// Regions are ignored, new glossary replaces old one
+	second, err := g.Compile(ctx, "adam", deepl.SrcPolish, deepl.TarEnglishBritish, []deepl.GlossaryEntry{{Source: "mózg", Target: "brain"}})
+	require.Nil(t, err)
+	require.Equal(t, deepl.SrcEnglish, second.To)
+	third, err := g.Compile(ctx, "adam", deepl.SrcPolish, deepl.TarEnglishAmerican, []deepl.GlossaryEntry{{Source: "mózg", Target: "mind"}})
+	require.Nil(t, err)
+	require.Len(t, c.glossaries, 2)
+	require.NotContains(t, c.glossaries, second.ID)
+
+	id, err := g.ID("adam", deepl.SrcPolish, deepl.TarEnglishBritish)
+	require.Nil(t, err)
+	require.Equal(t, third.ID, id)
+	id, err = g.ID("eve", deepl.SrcPolish, deepl.TarEnglishBritish)
+	require.Nil(t, err)
+	require.Empty(t, id)
+
+	list, err := g.List("adam")
+	require.Nil(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, deepl.SrcEnglish, list[0].From)
+	require.Equal(t, deepl.SrcPolish, list[1].From)
+
+	invalid := [][]deepl.GlossaryEntry{
+		nil,
+		{{Source: "brain", Target: ""}},
+		{{Source: "brain", Target: "mózg"}, {Source: "brain ", Target: "umysł"}},
+		{{Source: "a\tb", Target: "c"}},
+	}
+	for _, entries := range invalid {
+		_, err := g.Compile(ctx, "adam", deepl.SrcEnglish, deepl.TarPolish, entries)
+		require.ErrorIs(t, err, glossary.ErrInvalid, entries)
+	}
+	_, err = g.Compile(ctx, "adam", "", deepl.TarPolish, []deepl.GlossaryEntry{{Source: "brain", Target: "mózg"}})
+	require.ErrorIs(t, err, glossary.ErrInvalid)
+
+	c.err = fmt.Errorf("unsupported pair")
+	_, err = g.Compile(ctx, "adam", deepl.SrcEnglish, deepl.TarPolish, []deepl.GlossaryEntry{{Source: "brain", Target: "mózg"}})
+	require.ErrorIs(t, err, glossary.ErrUpstream)
+	// Previous glossary is still there
+	id, err = g.ID("adam", deepl.SrcEnglish, deepl.TarPolish)
+	require.Nil(t, err)
+	require.Equal(t, first.ID, id)
+}
+
+func TestGlossaries_Remove(t *testing.T) {
+	ctx := context.Background()
+	c := newFakeClient()
+	g := glossary.New(glossary.NewMemoryStore(), c)
+
+	_, err := g.Compile(ctx, "adam", deepl.SrcEnglish, deepl.TarPolish, []deepl.GlossaryEntry{{Source: "brain", Target: "mózg"}})
+	require.Nil(t, err)
+
+	require.ErrorIs(t, g.Remove(ctx, "adam", deepl.SrcPolish, deepl.TarEnglishBritish), glossary.ErrNotExist)
+	require.Nil(t, g.Remove(ctx, "adam", deepl.SrcEnglish, deepl.TarPolish))
+	require.Empty(t, c.glossaries)
+	_, err = g.Glossary("adam", deepl.SrcEnglish, deepl.TarPolish)
+	require.ErrorIs(t, err, glossary.ErrNotExist)
+
+	// Glossary removed on DeepL side can still be removed by user
+	_, err = g.Compile(ctx, "adam", deepl.SrcEnglish, deepl.TarPolish, []deepl.GlossaryEntry{{Source: "brain", Target: "mózg"}})
+	require.Nil(t, err)
+	c.glossaries = map[string]string{}
+	require.Nil(t, g.Remove(ctx, "adam", deepl.SrcEnglish, deepl.TarPolish))
+}
+
+func TestGlossaries_errStore(t *testing.T) {
+	ctx := context.Background()
+	g := glossary.New(errStore{}, newFakeClient())
+
+	_, err := g.Compile(ctx, "adam", deepl.SrcEnglish, deepl.TarPolish, []deepl.GlossaryEntry{{Source: "brain", Target: "mózg"}})
+	require.ErrorIs(t, err, glossary.ErrIO)
+	_, err = g.Glossary("adam", deepl.SrcEnglish, deepl.TarPolish)
+	require.ErrorIs(t, err, glossary.ErrIO)
+	_, err = g.List("adam")
+	require.ErrorIs(t, err, glossary.ErrIO)
+	_, err = g.ID("adam", deepl.SrcEnglish, deepl.TarPolish)
+	require.ErrorIs(t, err, glossary.ErrIO)
+	require.ErrorIs(t, g.Remove(ctx, "adam", deepl.SrcEnglish, deepl.TarPolish), glossary.ErrIO)
+}
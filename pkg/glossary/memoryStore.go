@@ -0,0 +1,75 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package glossary
+
+import (
+	"github.com/a-clap/dictionary/internal/deepl"
+	"sync"
+)
+
+var _ GlossaryStore = &MemoryStore{}
+
+// pair is key of glossary
+type pair struct {
+	from, to deepl.SourceLang
+}
+
+// MemoryStore satisfies GlossaryStore interface
+type MemoryStore struct {
+	mtx        sync.Mutex
+	glossaries map[string]map[pair]Glossary
+}
+
+// NewMemoryStore is default constructor for MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		glossaries: map[string]map[pair]Glossary{},
+	}
+}
+
+// SaveGlossary saves glossary for user
+func (m *MemoryStore) SaveGlossary(user string, g Glossary) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.glossaries[user]; !ok {
+		m.glossaries[user] = map[pair]Glossary{}
+	}
+	m.glossaries[user][pair{from: g.From, to: g.To}] = g
+	return nil
+}
+
+// LoadGlossary returns glossary for from-to pair, nil if it doesn't exist
+func (m *MemoryStore) LoadGlossary(user string, from, to deepl.SourceLang) (*Glossary, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	g, ok := m.glossaries[user][pair{from: from, to: to}]
+	if !ok {
+		return nil, nil
+	}
+	return &g, nil
+}
+
+// LoadGlossaries returns every glossary of user
+func (m *MemoryStore) LoadGlossaries(user string) ([]Glossary, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	glossaries := make([]Glossary, 0, len(m.glossaries[user]))
+	for _, elem := range m.glossaries[user] {
+		glossaries = append(glossaries, elem)
+	}
+	return glossaries, nil
+}
+
+// RemoveGlossary removes glossary for from-to pair
+func (m *MemoryStore) RemoveGlossary(user string, from, to deepl.SourceLang) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	delete(m.glossaries[user], pair{from: from, to: to})
+	return nil
+}
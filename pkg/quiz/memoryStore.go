@@ -0,0 +1,119 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package quiz
+
+import (
+	"sync"
+	"time"
+)
+
+var _ Store = &MemoryStore{}
+
+// MemoryStore satisfies Store interface
+type MemoryStore struct {
+	mtx      sync.Mutex
+	entries  map[string]map[string]Entry
+	sessions map[string]map[string]Session
+}
+
+// NewMemoryStore is default constructor for MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries:  map[string]map[string]Entry{},
+		sessions: map[string]map[string]Session{},
+	}
+}
+
+// SaveEntry saves entry for user
+func (m *MemoryStore) SaveEntry(user string, entry Entry) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.entries[user]; !ok {
+		m.entries[user] = map[string]Entry{}
+	}
+	m.entries[user][entry.Text] = entry
+	return nil
+}
+
+// LoadEntry returns entry with provided text, nil if it doesn't exist
+func (m *MemoryStore) LoadEntry(user string, text string) (*Entry, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	entry, ok := m.entries[user][text]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// LoadEntries returns every entry of user
+func (m *MemoryStore) LoadEntries(user string) ([]Entry, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	entries := make([]Entry, 0, len(m.entries[user]))
+	for _, elem := range m.entries[user] {
+		entries = append(entries, elem)
+	}
+	return entries, nil
+}
+
+// SaveSession saves session for user
+func (m *MemoryStore) SaveSession(user string, session Session) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.sessions[user]; !ok {
+		m.sessions[user] = map[string]Session{}
+	}
+	// Questions are modified in place by Quiz, don't share them
+	session.Questions = append([]Question{}, session.Questions...)
+	m.sessions[user][session.ID] = session
+	return nil
+}
+
+// LoadSession returns session with provided id, nil if it doesn't exist
+func (m *MemoryStore) LoadSession(user string, id string) (*Session, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	session, ok := m.sessions[user][id]
+	if !ok {
+		return nil, nil
+	}
+	session.Questions = append([]Question{}, session.Questions...)
+	return &session, nil
+}
+
+// SaveAnswer marks question as answered and updates statistics of asked word, under single lock
+func (m *MemoryStore) SaveAnswer(user string, id string, question int, correct bool, asked time.Time) (bool, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	session, ok := m.sessions[user][id]
+	if !ok || question < 0 || question >= len(session.Questions) || session.Questions[question].Answered {
+		return false, nil
+	}
+	session.Questions = append([]Question{}, session.Questions...)
+	session.Questions[question].Answered = true
+	session.Questions[question].Correct = correct
+	m.sessions[user][id] = session
+
+	// Word may be gone in the meantime, that's not a reason to fail
+	entry, ok := m.entries[user][session.Questions[question].Word]
+	if !ok {
+		return true, nil
+	}
+	if correct {
+		entry.Stats.Correct++
+	} else {
+		entry.Stats.Wrong++
+	}
+	entry.Stats.Asked = asked
+	m.entries[user][entry.Text] = entry
+	return true, nil
+}
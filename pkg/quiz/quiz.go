@@ -0,0 +1,545 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package quiz
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/a-clap/dictionary/pkg/translator"
+	mrand "math/rand"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotExist  = errors.New("doesn't exist")
+	ErrInvalid   = errors.New("invalid argument")
+	ErrNotEnough = errors.New("not enough words to build quiz")
+	ErrAnswered  = errors.New("question already answered")
+	ErrIO        = errors.New("io error")
+)
+
+// Mode is kind of question
+type Mode string
+
+const (
+	// ModeTranslation - pick the right translation, distractors are translations of other user's words
+	ModeTranslation Mode = "translation"
+	// ModeSynonym - pick the synonym of word
+	ModeSynonym Mode = "synonym"
+	// ModeAntonym - pick the antonym of word
+	ModeAntonym Mode = "antonym"
+	// ModeFillBlank - type word masked in usage example
+	ModeFillBlank Mode = "fill"
+	// ModeMixed - every mode, which can be built from user's words
+	ModeMixed Mode = "mixed"
+)
+
+const (
+	// blank replaces word in ModeFillBlank questions
+	blank = "_____"
+	// maxOptions is number of options in multiple-choice questions, including the right one
+	maxOptions = 4
+)
+
+// Stats holds user's results for single word
+type Stats struct {
+	Correct int       `json:"correct"`
+	Wrong   int       `json:"wrong"`
+	Asked   time.Time `json:"asked"`
+}
+
+// Entry is word looked up by user, recorded to build quizzes from it
+type Entry struct {
+	Text        string                  `json:"text"`
+	Translation *translator.Translation `json:"translation"`
+	Stats       Stats                   `json:"stats"`
+}
+
+// Question is single question in Session. Answer is never sent to user
+type Question struct {
+	Mode     Mode     `json:"mode"`
+	Word     string   `json:"word"`
+	Prompt   string   `json:"prompt"`
+	Options  []string `json:"options,omitempty"`
+	Answer   string   `json:"-"`
+	Answered bool     `json:"answered"`
+	Correct  bool     `json:"correct"`
+}
+
+// Session is single quiz
+type Session struct {
+	ID        string     `json:"id"`
+	Mode      Mode       `json:"mode"`
+	Questions []Question `json:"questions"`
+	Created   time.Time  `json:"created"`
+}
+
+// Result is returned after answering question
+type Result struct {
+	Correct bool   `json:"correct"`
+	Answer  string `json:"answer"`
+}
+
+// Score summarizes Session
+type Score struct {
+	Total    int `json:"total"`
+	Answered int `json:"answered"`
+	Correct  int `json:"correct"`
+}
+
+// Store realizes access to users entries and quiz sessions.
+// Errors returned by interface should be ONLY related to internal IO errors
+type Store interface {
+	// SaveEntry saves entry for user. Overwrites, if entry with the same Text already exists
+	SaveEntry(user string, entry Entry) error
+	// LoadEntry returns entry with provided text, nil if entry doesn't exist
+	LoadEntry(user string, text string) (*Entry, error)
+	// LoadEntries returns every entry of user, in any order
+	LoadEntries(user string) ([]Entry, error)
+	// SaveSession saves session for user. Overwrites, if session with the same ID already exists
+	SaveSession(user string, session Session) error
+	// LoadSession returns session with provided id, nil if session doesn't exist
+	LoadSession(user string, id string) (*Session, error)
+	// SaveAnswer marks question (index in Session.Questions) as answered and counts it in statistics of asked word,
+	// if word still exists. Must be atomic, returns false if question doesn't exist or was already answered
+	SaveAnswer(user string, id string, question int, correct bool, asked time.Time) (bool, error)
+}
+
+// Quiz builds quizzes from words looked up by user
+type Quiz struct {
+	s   Store
+	now func() time.Time
+	// mtx guards rand, *mrand.Rand isn't safe for concurrent use
+	mtx  sync.Mutex
+	rand *mrand.Rand
+}
+
+// New is default constructor for Quiz
+func New(store Store) *Quiz {
+	return &Quiz{
+		s:    store,
+		rand: mrand.New(mrand.NewSource(time.Now().UnixNano())),
+		now:  time.Now,
+	}
+}
+
+// SetRand replaces source of randomness, useful for testing
+func (q *Quiz) SetRand(r *mrand.Rand) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.rand = r
+}
+
+// SetClock replaces source of current time, useful for testing
+func (q *Quiz) SetClock(now func() time.Time) {
+	q.now = now
+}
+
+// Record saves word looked up by user. Statistics of already recorded word are kept
+func (q *Quiz) Record(user string, text string, translation *translator.Translation) error {
+	if len(user) == 0 || len(text) == 0 || translation == nil {
+		return fmt.Errorf("%w: user, text and translation must be provided", ErrInvalid)
+	}
+
+	entry, err := q.entry(user, text)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		entry = &Entry{Text: text}
+	}
+	entry.Translation = translation
+
+	return q.saveEntry(user, *entry)
+}
+
+// Entries returns every word recorded for user, sorted by text
+func (q *Quiz) Entries(user string) ([]Entry, error) {
+	entries, err := q.s.LoadEntries(user)
+	if err != nil {
+		return nil, fmt.Errorf("%w: LoadEntries: user %s, error: %v", ErrIO, user, err)
+	}
+	if entries == nil {
+		entries = []Entry{}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Text < entries[j].Text
+	})
+	return entries, nil
+}
+
+// Start builds new Session with at most count questions. The least known words are asked first
+func (q *Quiz) Start(user string, mode Mode, count int) (*Session, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("%w: count must be positive", ErrInvalid)
+	}
+
+	var modes []Mode
+	switch mode {
+	case ModeTranslation, ModeSynonym, ModeAntonym, ModeFillBlank:
+		modes = []Mode{mode}
+	case ModeMixed, "":
+		mode = ModeMixed
+		modes = []Mode{ModeTranslation, ModeSynonym, ModeAntonym, ModeFillBlank}
+	default:
+		return nil, fmt.Errorf("%w: mode %s", ErrInvalid, mode)
+	}
+
+	entries, err := q.Entries(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shuffle first, so words with equal stats are asked in random order
+	q.shuffle(len(entries), func(i, j int) {
+		entries[i], entries[j] = entries[j], entries[i]
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		return known(entries[i].Stats) < known(entries[j].Stats)
+	})
+
+	var questions []Question
+	for _, entry := range entries {
+		if len(questions) == count {
+			break
+		}
+		// Each word may be asked in different modes, choose one randomly
+		order := q.perm(len(modes))
+		for _, i := range order {
+			if question, ok := q.question(modes[i], entry, entries); ok {
+				questions = append(questions, question)
+				break
+			}
+		}
+	}
+
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("%w: mode %s", ErrNotEnough, mode)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	session := Session{
+		ID:        id,
+		Mode:      mode,
+		Questions: questions,
+		Created:   q.now(),
+	}
+	if err := q.saveSession(user, session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Answer checks answer for question (index in Session.Questions) and updates statistics of asked word
+func (q *Quiz) Answer(user string, id string, question int, answer string) (*Result, error) {
+	session, err := q.Session(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if question < 0 || question >= len(session.Questions) {
+		return nil, fmt.Errorf("%w: question %d", ErrNotExist, question)
+	}
+
+	asked := session.Questions[question]
+	if asked.Answered {
+		return nil, fmt.Errorf("%w: %d", ErrAnswered, question)
+	}
+	correct := normalize(answer) == normalize(asked.Answer)
+
+	// Concurrent answer may have been saved since session was loaded, only one of them counts
+	saved, err := q.s.SaveAnswer(user, id, question, correct, q.now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: SaveAnswer: user %s, id %s, question %d, error: %v", ErrIO, user, id, question, err)
+	}
+	if !saved {
+		return nil, fmt.Errorf("%w: %d", ErrAnswered, question)
+	}
+
+	return &Result{Correct: correct, Answer: asked.Answer}, nil
+}
+
+// Session returns user's session with provided id
+func (q *Quiz) Session(user string, id string) (*Session, error) {
+	session, err := q.s.LoadSession(user, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: LoadSession: user %s, id %s, error: %v", ErrIO, user, id, err)
+	}
+	if session == nil {
+		return nil, fmt.Errorf("session %w: %s", ErrNotExist, id)
+	}
+	return session, nil
+}
+
+// Score returns summary of user's session
+func (q *Quiz) Score(user string, id string) (*Score, error) {
+	session, err := q.Session(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	score := &Score{Total: len(session.Questions)}
+	for _, elem := range session.Questions {
+		if elem.Answered {
+			score.Answered++
+		}
+		if elem.Correct {
+			score.Correct++
+		}
+	}
+	return score, nil
+}
+
+// question tries to build question in mode for entry, others are used as distractors
+func (q *Quiz) question(mode Mode, entry Entry, others []Entry) (Question, bool) {
+	switch mode {
+	case ModeTranslation:
+		return q.translationQuestion(entry, others)
+	case ModeSynonym:
+		return q.thesaurusQuestion(mode, entry, others)
+	case ModeAntonym:
+		return q.thesaurusQuestion(mode, entry, others)
+	case ModeFillBlank:
+		return q.fillQuestion(entry)
+	}
+	return Question{}, false
+}
+
+func (q *Quiz) translationQuestion(entry Entry, others []Entry) (Question, bool) {
+	answer := translation(entry)
+	if len(answer) == 0 {
+		return Question{}, false
+	}
+
+	var candidates []string
+	for _, elem := range others {
+		if elem.Text == entry.Text {
+			continue
+		}
+		candidates = append(candidates, translation(elem))
+	}
+
+	options, ok := q.options(answer, []string{answer}, candidates)
+	if !ok {
+		return Question{}, false
+	}
+	return Question{
+		Mode:    ModeTranslation,
+		Word:    entry.Text,
+		Prompt:  entry.Text,
+		Options: options,
+		Answer:  answer,
+	}, true
+}
+
+func (q *Quiz) thesaurusQuestion(mode Mode, entry Entry, others []Entry) (Question, bool) {
+	if entry.Translation == nil {
+		return Question{}, false
+	}
+
+	// Synonyms are in language of defined side, so must be the other distractors
+	var words []string
+	for _, elem := range others {
+		if elem.Text == entry.Text {
+			continue
+		}
+		if d := defined(elem); len(d) > 0 {
+			words = append(words, d[0])
+		}
+	}
+
+	for _, th := range entry.Translation.Thesaurus {
+		for _, sense := range th.Senses {
+			answers, opposite := sense.Synonyms, sense.Antonyms
+			if mode == ModeAntonym {
+				answers, opposite = sense.Antonyms, sense.Synonyms
+			}
+			if len(answers) == 0 {
+				continue
+			}
+			answer := answers[q.intn(len(answers))]
+
+			// The best distractors are the opposites, then other user's words
+			exclude := append([]string{th.Text}, answers...)
+			options, ok := q.options(answer, exclude, append([]string{}, opposite...), words)
+			if !ok {
+				continue
+			}
+			return Question{
+				Mode:    mode,
+				Word:    entry.Text,
+				Prompt:  th.Text,
+				Options: options,
+				Answer:  answer,
+			}, true
+		}
+	}
+	return Question{}, false
+}
+
+func (q *Quiz) fillQuestion(entry Entry) (Question, bool) {
+	if entry.Translation == nil || entry.Translation.Dictionary == nil {
+		return Question{}, false
+	}
+
+	// Examples use words of defined side
+	for _, word := range defined(entry) {
+		for _, def := range entry.Translation.Dictionary.Defs {
+			for _, example := range def.Examples {
+				if masked, ok := mask(example, word); ok {
+					return Question{
+						Mode:   ModeFillBlank,
+						Word:   entry.Text,
+						Prompt: masked,
+						Answer: word,
+					}, true
+				}
+			}
+		}
+	}
+	return Question{}, false
+}
+
+// options returns shuffled answer with up to maxOptions-1 distractors, which are not excluded.
+// Distractors are taken from groups of candidates, in order of groups. Returns false, if there is not even one distractor
+func (q *Quiz) options(answer string, exclude []string, candidates ...[]string) ([]string, bool) {
+	skip := map[string]bool{}
+	for _, elem := range exclude {
+		skip[normalize(elem)] = true
+	}
+
+	options := []string{answer}
+	for _, group := range candidates {
+		q.shuffle(len(group), func(i, j int) {
+			group[i], group[j] = group[j], group[i]
+		})
+		for _, elem := range group {
+			if len(options) == maxOptions {
+				break
+			}
+			if len(elem) == 0 || skip[normalize(elem)] {
+				continue
+			}
+			skip[normalize(elem)] = true
+			options = append(options, elem)
+		}
+	}
+	if len(options) < 2 {
+		return nil, false
+	}
+
+	q.shuffle(len(options), func(i, j int) {
+		options[i], options[j] = options[j], options[i]
+	})
+	return options, true
+}
+
+// shuffle is q.rand.Shuffle guarded by mutex
+func (q *Quiz) shuffle(n int, swap func(i, j int)) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.rand.Shuffle(n, swap)
+}
+
+// perm is q.rand.Perm guarded by mutex
+func (q *Quiz) perm(n int) []int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return q.rand.Perm(n)
+}
+
+// intn is q.rand.Intn guarded by mutex
+func (q *Quiz) intn(n int) int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return q.rand.Intn(n)
+}
+
+// entry is wrapper for interface call LoadEntry, returns appropriate wrapped error
+func (q *Quiz) entry(user string, text string) (*Entry, error) {
+	entry, err := q.s.LoadEntry(user, text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: LoadEntry: user %s, text %s, error: %v", ErrIO, user, text, err)
+	}
+	return entry, nil
+}
+
+// saveEntry is wrapper for interface call SaveEntry, returns appropriate wrapped error
+func (q *Quiz) saveEntry(user string, entry Entry) error {
+	if err := q.s.SaveEntry(user, entry); err != nil {
+		return fmt.Errorf("%w: SaveEntry: user %s, text %s, error: %v", ErrIO, user, entry.Text, err)
+	}
+	return nil
+}
+
+// saveSession is wrapper for interface call SaveSession, returns appropriate wrapped error
+func (q *Quiz) saveSession(user string, session Session) error {
+	if err := q.s.SaveSession(user, session); err != nil {
+		return fmt.Errorf("%w: SaveSession: user %s, id %s, error: %v", ErrIO, user, session.ID, err)
+	}
+	return nil
+}
+
+// translation returns first translation of entry, "" if there is none
+func translation(entry Entry) string {
+	if entry.Translation == nil || len(entry.Translation.Deepl) == 0 {
+		return ""
+	}
+	return entry.Translation.Deepl[0].Text
+}
+
+// defined returns words described by Dictionary and Thesaurus of entry: recorded text, when source side is defined,
+// translations otherwise (also for entries recorded before translator.Translation.Defined existed)
+func defined(entry Entry) []string {
+	if entry.Translation == nil {
+		return nil
+	}
+	if entry.Translation.Defined == translator.SideSource {
+		return []string{entry.Text}
+	}
+	words := make([]string, 0, len(entry.Translation.Deepl))
+	for _, elem := range entry.Translation.Deepl {
+		words = append(words, elem.Text)
+	}
+	return words
+}
+
+// known returns how well word is known, the higher, the better
+func known(stats Stats) int {
+	return stats.Correct - stats.Wrong
+}
+
+// mask replaces every occurrence of word in text with blank, returns false if word wasn't found
+func mask(text, word string) (string, bool) {
+	if len(word) == 0 {
+		return "", false
+	}
+	re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`)
+	if !re.MatchString(text) {
+		return "", false
+	}
+	return re.ReplaceAllLiteralString(text, blank), true
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// newID returns random, hex encoded identifier
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: rand.Read: %v", ErrIO, err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,273 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package quiz_test
+
+import (
+	"errors"
+	"github.com/a-clap/dictionary/pkg/quiz"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func newTranslation(text string, synonyms, antonyms, examples []string) *translator.Translation {
+	return &translator.Translation{
+		Deepl: []translator.DeeplTranslate{{Text: text}},
+		Dictionary: &translator.DictionaryTranslate{
+			Defs: []translator.Definition{{Examples: examples}},
+		},
+		Thesaurus: []translator.ThesaurusTranslate{{
+			Text: text,
+			Senses: []translator.ThesaurusSense{{
+				Synonyms: synonyms,
+				Antonyms: antonyms,
+			}},
+		}},
+	}
+}
+
+// newQuiz returns Quiz with recorded words for user "adam"
+func newQuiz(t *testing.T) *quiz.Quiz {
+	q := quiz.New(quiz.NewMemoryStore())
+	q.SetRand(rand.New(rand.NewSource(1)))
+
+	words := map[string]*translator.Translation{
+		"mózg":    newTranslation("brain", []string{"genius", "intellect"}, []string{"dunce", "idiot"}, []string{"the {it}brain{/it} is complex"}),
+		"szybki":  newTranslation("fast", []string{"quick", "rapid"}, []string{"slow"}, []string{"a Fast car"}),
+		"szczęśl": newTranslation("happy", []string{"glad", "joyful"}, nil, nil),
+		"dom":     newTranslation("house", nil, nil, nil),
+	}
+	for text, translation := range words {
+		require.Nil(t, q.Record("adam", text, translation))
+	}
+	return q
+}
+
+func TestQuiz_Start(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  quiz.Mode
+		count int
+		words int
+	}{
+		{name: "translation", mode: quiz.ModeTranslation, count: 10, words: 4},
+		{name: "synonym", mode: quiz.ModeSynonym, count: 10, words: 3},
+		{name: "antonym", mode: quiz.ModeAntonym, count: 10, words: 2},
+		{name: "fill in the blank", mode: quiz.ModeFillBlank, count: 10, words: 2},
+		{name: "mixed", mode: quiz.ModeMixed, count: 10, words: 4},
+		{name: "limited count", mode: quiz.ModeTranslation, count: 2, words: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newQuiz(t)
+			session, err := q.Start("adam", tt.mode, tt.count)
+			require.Nil(t, err)
+			require.Len(t, session.Questions, tt.words)
+
+			for _, question := range session.Questions {
+				if tt.mode != quiz.ModeMixed {
+					require.Equal(t, tt.mode, question.Mode)
+				}
+				require.NotEmpty(t, question.Answer)
+
+				switch question.Mode {
+				case quiz.ModeFillBlank:
+					require.Empty(t, question.Options)
+					require.Contains(t, question.Prompt, "_____")
+					require.NotContains(t, question.Prompt, question.Answer)
+				default:
+					require.GreaterOrEqual(t, len(question.Options), 2)
+					require.LessOrEqual(t, len(question.Options), 4)
+					require.Contains(t, question.Options, question.Answer)
+				}
+			}
+		})
+	}
+}
+
+func TestQuiz_StartQuestions(t *testing.T) {
+	q := newQuiz(t)
+
+	session, err := q.Start("adam", quiz.ModeAntonym, 10)
+	require.Nil(t, err)
+	for _, question := range session.Questions {
+		switch question.Prompt {
+		case "brain":
+			require.Contains(t, []string{"dunce", "idiot"}, question.Answer)
+			require.NotContains(t, question.Options, "brain")
+		case "fast":
+			require.Equal(t, "slow", question.Answer)
+			// Synonyms are the best distractors
+			require.Contains(t, question.Options, "quick")
+			require.Contains(t, question.Options, "rapid")
+		default:
+			t.Fatalf("unexpected prompt %s", question.Prompt)
+		}
+	}
+
+	session, err = q.Start("adam", quiz.ModeFillBlank, 10)
+	require.Nil(t, err)
+	prompts := map[string]string{}
+	for _, question := range session.Questions {
+		prompts[question.Answer] = question.Prompt
+	}
+	require.Equal(t, map[string]string{
+		"brain": "the {it}_____{/it} is complex",
+		"fast":  "a _____ car",
+	}, prompts)
+}
+
+func TestQuiz_sourceSide(t *testing.T) {
+	q := quiz.New(quiz.NewMemoryStore())
+	q.SetRand(rand.New(rand.NewSource(1)))
+
+	// English words translated into polish, dictionary describes english source text
+	words := map[string]*translator.Translation{
+		"brain": newTranslation("mózg", nil, nil, []string{"The brain is complex"}),
+		"fast":  newTranslation("szybki", nil, nil, nil),
+		"house": newTranslation("dom", nil, nil, nil),
+	}
+	words["brain"].Thesaurus[0].Text = "brain"
+	words["brain"].Thesaurus[0].Senses[0].Synonyms = []string{"mind"}
+	for text, translation := range words {
+		translation.Defined = translator.SideSource
+		require.Nil(t, q.Record("eve", text, translation))
+	}
+
+	session, err := q.Start("eve", quiz.ModeFillBlank, 10)
+	require.Nil(t, err)
+	require.Len(t, session.Questions, 1)
+	require.Equal(t, "brain", session.Questions[0].Answer)
+	require.Equal(t, "The _____ is complex", session.Questions[0].Prompt)
+
+	// Distractors are english as well
+	session, err = q.Start("eve", quiz.ModeSynonym, 10)
+	require.Nil(t, err)
+	require.Len(t, session.Questions, 1)
+	question := session.Questions[0]
+	require.Equal(t, "mind", question.Answer)
+	require.ElementsMatch(t, []string{"mind", "fast", "house"}, question.Options)
+}
+
+func TestQuiz_Answer(t *testing.T) {
+	q := newQuiz(t)
+	session, err := q.Start("adam", quiz.ModeTranslation, 2)
+	require.Nil(t, err)
+
+	first, second := session.Questions[0], session.Questions[1]
+
+	result, err := q.Answer("adam", session.ID, 0, " "+first.Answer+" ")
+	require.Nil(t, err)
+	require.True(t, result.Correct)
+	require.Equal(t, first.Answer, result.Answer)
+
+	_, err = q.Answer("adam", session.ID, 0, first.Answer)
+	require.True(t, errors.Is(err, quiz.ErrAnswered))
+
+	result, err = q.Answer("adam", session.ID, 1, "definitely wrong")
+	require.Nil(t, err)
+	require.False(t, result.Correct)
+	require.Equal(t, second.Answer, result.Answer)
+
+	score, err := q.Score("adam", session.ID)
+	require.Nil(t, err)
+	require.Equal(t, quiz.Score{Total: 2, Answered: 2, Correct: 1}, *score)
+
+	entries, err := q.Entries("adam")
+	require.Nil(t, err)
+	stats := map[string]quiz.Stats{}
+	for _, elem := range entries {
+		stats[elem.Text] = elem.Stats
+	}
+	require.Equal(t, 1, stats[first.Word].Correct)
+	require.Equal(t, 0, stats[first.Word].Wrong)
+	require.Equal(t, 1, stats[second.Word].Wrong)
+
+	// Recording word again keeps stats
+	require.Nil(t, q.Record("adam", second.Word, newTranslation("x", nil, nil, nil)))
+	entries, err = q.Entries("adam")
+	require.Nil(t, err)
+	for _, elem := range entries {
+		if elem.Text == second.Word {
+			require.Equal(t, 1, elem.Stats.Wrong)
+		}
+	}
+
+	t.Run("the least known words are asked first", func(t *testing.T) {
+		session, err := q.Start("adam", quiz.ModeTranslation, 1)
+		require.Nil(t, err)
+		require.Equal(t, second.Word, session.Questions[0].Word)
+	})
+}
+
+func TestQuiz_concurrent(t *testing.T) {
+	q := newQuiz(t)
+	session, err := q.Start("adam", quiz.ModeTranslation, 1)
+	require.Nil(t, err)
+	question := session.Questions[0]
+
+	const workers = 8
+	var wg sync.WaitGroup
+	var mtx sync.Mutex
+	answered := 0
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := q.Answer("adam", session.ID, 0, question.Answer); err == nil {
+				mtx.Lock()
+				answered++
+				mtx.Unlock()
+			} else {
+				require.True(t, errors.Is(err, quiz.ErrAnswered), err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := q.Start("adam", quiz.ModeMixed, 4)
+			require.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Only one answer counts
+	require.Equal(t, 1, answered)
+	entries, err := q.Entries("adam")
+	require.Nil(t, err)
+	for _, elem := range entries {
+		if elem.Text == question.Word {
+			require.Equal(t, quiz.Stats{Correct: 1, Asked: elem.Stats.Asked}, elem.Stats)
+		}
+	}
+}
+
+func TestQuiz_errors(t *testing.T) {
+	q := newQuiz(t)
+
+	_, err := q.Start("adam", "unknown", 1)
+	require.True(t, errors.Is(err, quiz.ErrInvalid))
+	_, err = q.Start("adam", quiz.ModeMixed, 0)
+	require.True(t, errors.Is(err, quiz.ErrInvalid))
+	_, err = q.Start("eve", quiz.ModeMixed, 1)
+	require.True(t, errors.Is(err, quiz.ErrNotEnough))
+
+	// Single word isn't enough for multiple-choice
+	require.Nil(t, q.Record("eve", "dom", newTranslation("house", nil, nil, nil)))
+	_, err = q.Start("eve", quiz.ModeTranslation, 1)
+	require.True(t, errors.Is(err, quiz.ErrNotEnough))
+
+	session, err := q.Start("adam", quiz.ModeTranslation, 1)
+	require.Nil(t, err)
+	_, err = q.Answer("eve", session.ID, 0, "")
+	require.True(t, errors.Is(err, quiz.ErrNotExist))
+	_, err = q.Answer("adam", session.ID, 1, "")
+	require.True(t, errors.Is(err, quiz.ErrNotExist))
+	_, err = q.Score("eve", session.ID)
+	require.True(t, errors.Is(err, quiz.ErrNotExist))
+
+	require.True(t, errors.Is(q.Record("adam", "", nil), quiz.ErrInvalid))
+}
@@ -0,0 +1,79 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package review
+
+import (
+	"sync"
+)
+
+var _ CardStore = &MemoryStore{}
+
+// MemoryStore satisfies CardStore interface
+type MemoryStore struct {
+	mtx   sync.Mutex
+	cards map[string]map[string]Card
+}
+
+// NewMemoryStore is default constructor for MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		cards: map[string]map[string]Card{},
+	}
+}
+
+// AddCard saves new card for user, unless card with the same ID or WordID exists, under single lock
+func (m *MemoryStore) AddCard(user string, card Card) (bool, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	for _, elem := range m.cards[user] {
+		if elem.ID == card.ID || (len(card.WordID) > 0 && elem.WordID == card.WordID) {
+			return false, nil
+		}
+	}
+	if _, ok := m.cards[user]; !ok {
+		m.cards[user] = map[string]Card{}
+	}
+	m.cards[user][card.ID] = card
+	return true, nil
+}
+
+// UpdateCard applies update to user's card with provided id, under single lock
+func (m *MemoryStore) UpdateCard(user string, id string, update func(card *Card)) (*Card, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	card, ok := m.cards[user][id]
+	if !ok {
+		return nil, nil
+	}
+	update(&card)
+	m.cards[user][id] = card
+	return &card, nil
+}
+
+// LoadCard returns card with provided id, nil if it doesn't exist
+func (m *MemoryStore) LoadCard(user string, id string) (*Card, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	card, ok := m.cards[user][id]
+	if !ok {
+		return nil, nil
+	}
+	return &card, nil
+}
+
+// LoadCards returns every card of user
+func (m *MemoryStore) LoadCards(user string) ([]Card, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	cards := make([]Card, 0, len(m.cards[user]))
+	for _, elem := range m.cards[user] {
+		cards = append(cards, elem)
+	}
+	return cards, nil
+}
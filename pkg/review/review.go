@@ -0,0 +1,218 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package review
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"math"
+	"sort"
+	"time"
+)
+
+var (
+	ErrExist    = errors.New("card already exists")
+	ErrNotExist = errors.New("card doesn't exist")
+	ErrInvalid  = errors.New("invalid argument")
+	ErrIO       = errors.New("io error")
+)
+
+const (
+	// MinGrade means complete blackout, MaxGrade perfect response
+	MinGrade = 0
+	MaxGrade = 5
+	// PassGrade is the lowest grade, which is considered as correct response
+	PassGrade = 3
+
+	initialEase = 2.5
+	minEase     = 1.3
+	day         = 24 * time.Hour
+)
+
+// Card is single flashcard, scheduled with SM-2 algorithm, see https://super-memory.com/english/ol/sm2.htm
+type Card struct {
+	ID          string                  `json:"id"`
+	WordID      string                  `json:"word_id"`
+	Text        string                  `json:"text"`
+	Translation *translator.Translation `json:"translation"`
+	Ease        float64                 `json:"ease"`
+	Interval    int                     `json:"interval"`
+	Repetitions int                     `json:"repetitions"`
+	Due         time.Time               `json:"due"`
+	Reviewed    time.Time               `json:"reviewed"`
+}
+
+// CardStore realizes access to users cards.
+// Errors returned by interface should be ONLY related to internal IO errors
+type CardStore interface {
+	// AddCard saves new card for user. Must be atomic, returns false if user already has card with the same ID
+	// or, when card.WordID isn't empty, card with the same WordID
+	AddCard(user string, card Card) (bool, error)
+	// UpdateCard applies update to user's card with provided id and saves it. Must be atomic,
+	// so concurrent updates aren't lost. Returns updated card, nil if card doesn't exist
+	UpdateCard(user string, id string, update func(card *Card)) (*Card, error)
+	// LoadCard returns card with provided id, nil if card doesn't exist
+	LoadCard(user string, id string) (*Card, error)
+	// LoadCards returns every card of user, in any order
+	LoadCards(user string) ([]Card, error)
+}
+
+// Review schedules users cards
+type Review struct {
+	s   CardStore
+	now func() time.Time
+}
+
+// New is default constructor for Review
+func New(store CardStore) *Review {
+	return &Review{
+		s:   store,
+		now: time.Now,
+	}
+}
+
+// SetClock replaces source of current time, useful for testing
+func (r *Review) SetClock(now func() time.Time) {
+	r.now = now
+}
+
+// Add creates new card for user, which is due immediately. Only one card per card.WordID is allowed
+func (r *Review) Add(user string, card Card) (*Card, error) {
+	if len(user) == 0 || len(card.Text) == 0 {
+		return nil, fmt.Errorf("%w: user and text must be provided", ErrInvalid)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	card.ID = id
+	card.Ease = initialEase
+	card.Interval = 0
+	card.Repetitions = 0
+	card.Due = r.now()
+	card.Reviewed = time.Time{}
+
+	// Uniqueness of WordID is checked by store, so concurrent Add can't duplicate card
+	added, err := r.s.AddCard(user, card)
+	if err != nil {
+		return nil, fmt.Errorf("%w: AddCard: user %s, id %s, error: %v", ErrIO, user, card.ID, err)
+	}
+	if !added {
+		return nil, fmt.Errorf("%w: for word %s", ErrExist, card.WordID)
+	}
+	return &card, nil
+}
+
+// Due returns at most limit cards, which should be reviewed now, the most overdue first.
+// limit <= 0 means no limit
+func (r *Review) Due(user string, limit int) ([]Card, error) {
+	cards, err := r.cards(user)
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.now()
+	due := make([]Card, 0, len(cards))
+	for _, elem := range cards {
+		if !elem.Due.After(now) {
+			due = append(due, elem)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].Due.Equal(due[j].Due) {
+			return due[i].ID < due[j].ID
+		}
+		return due[i].Due.Before(due[j].Due)
+	})
+
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// Card returns user's card with provided id
+func (r *Review) Card(user string, id string) (*Card, error) {
+	card, err := r.s.LoadCard(user, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: LoadCard: user %s, id %s, error: %v", ErrIO, user, id, err)
+	}
+	if card == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotExist, id)
+	}
+	return card, nil
+}
+
+// Grade applies response quality (MinGrade - MaxGrade) to card and schedules next review
+func (r *Review) Grade(user string, id string, grade int) (*Card, error) {
+	if grade < MinGrade || grade > MaxGrade {
+		return nil, fmt.Errorf("%w: grade %d, must be in range <%d, %d>", ErrInvalid, grade, MinGrade, MaxGrade)
+	}
+
+	now := r.now()
+	// Card is scheduled by store, so concurrent grades are applied one after another
+	card, err := r.s.UpdateCard(user, id, func(card *Card) {
+		schedule(card, grade)
+		card.Reviewed = now
+		card.Due = now.Add(time.Duration(card.Interval) * day)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: UpdateCard: user %s, id %s, error: %v", ErrIO, user, id, err)
+	}
+	if card == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotExist, id)
+	}
+	return card, nil
+}
+
+// schedule updates ease, interval and repetitions according to SM-2.
+// Failed response restarts repetitions without changing ease
+func schedule(card *Card, grade int) {
+	if grade < PassGrade {
+		card.Repetitions = 0
+		card.Interval = 1
+		return
+	}
+
+	switch card.Repetitions {
+	case 0:
+		card.Interval = 1
+	case 1:
+		card.Interval = 6
+	default:
+		card.Interval = int(math.Round(float64(card.Interval) * card.Ease))
+	}
+	card.Repetitions++
+
+	q := float64(MaxGrade - grade)
+	card.Ease += 0.1 - q*(0.08+q*0.02)
+	if card.Ease < minEase {
+		card.Ease = minEase
+	}
+}
+
+// cards is wrapper for interface call LoadCards, returns appropriate wrapped error
+func (r *Review) cards(user string) ([]Card, error) {
+	cards, err := r.s.LoadCards(user)
+	if err != nil {
+		return nil, fmt.Errorf("%w: LoadCards: user %s, error: %v", ErrIO, user, err)
+	}
+	return cards, nil
+}
+
+// newID returns random, hex encoded identifier
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: rand.Read: %v", ErrIO, err)
+	}
+	return hex.EncodeToString(b), nil
+}
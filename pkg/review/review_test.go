@@ -0,0 +1,230 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package review_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/a-clap/dictionary/pkg/review"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+var _ review.CardStore = errStore{}
+
+type errStore struct {
+}
+
+func (e errStore) AddCard(_ string, _ review.Card) (bool, error) {
+	return false, fmt.Errorf("io err")
+}
+
+func (e errStore) UpdateCard(_ string, _ string, _ func(card *review.Card)) (*review.Card, error) {
+	return nil, fmt.Errorf("io err")
+}
+
+func (e errStore) LoadCard(_ string, _ string) (*review.Card, error) {
+	return nil, fmt.Errorf("io err")
+}
+
+func (e errStore) LoadCards(_ string) ([]review.Card, error) {
+	return nil, fmt.Errorf("io err")
+}
+
+// clock is fake source of time, moved manually
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) days(n int) {
+	c.now = c.now.Add(time.Duration(n) * 24 * time.Hour)
+}
+
+func TestReview_Grade(t *testing.T) {
+	start := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	type step struct {
+		grade       int
+		interval    int
+		repetitions int
+		ease        float64
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "perfect responses",
+			steps: []step{
+				{grade: 5, interval: 1, repetitions: 1, ease: 2.6},
+				{grade: 5, interval: 6, repetitions: 2, ease: 2.7},
+				{grade: 5, interval: 16, repetitions: 3, ease: 2.8},
+				{grade: 5, interval: 45, repetitions: 4, ease: 2.9},
+			},
+		},
+		{
+			name: "hesitant responses keep ease",
+			steps: []step{
+				{grade: 4, interval: 1, repetitions: 1, ease: 2.5},
+				{grade: 4, interval: 6, repetitions: 2, ease: 2.5},
+				{grade: 4, interval: 15, repetitions: 3, ease: 2.5},
+			},
+		},
+		{
+			name: "failure resets repetitions and keeps ease",
+			steps: []step{
+				{grade: 5, interval: 1, repetitions: 1, ease: 2.6},
+				{grade: 5, interval: 6, repetitions: 2, ease: 2.7},
+				{grade: 2, interval: 1, repetitions: 0, ease: 2.7},
+				{grade: 3, interval: 1, repetitions: 1, ease: 2.56},
+			},
+		},
+		{
+			name: "blackout keeps ease",
+			steps: []step{
+				{grade: 0, interval: 1, repetitions: 0, ease: 2.5},
+				{grade: 0, interval: 1, repetitions: 0, ease: 2.5},
+			},
+		},
+		{
+			name: "ease doesn't drop below 1.3",
+			steps: []step{
+				{grade: 3, interval: 1, repetitions: 1, ease: 2.36},
+				{grade: 3, interval: 6, repetitions: 2, ease: 2.22},
+				{grade: 3, interval: 13, repetitions: 3, ease: 2.08},
+				{grade: 3, interval: 27, repetitions: 4, ease: 1.94},
+				{grade: 3, interval: 52, repetitions: 5, ease: 1.8},
+				{grade: 3, interval: 94, repetitions: 6, ease: 1.66},
+				{grade: 3, interval: 156, repetitions: 7, ease: 1.52},
+				{grade: 3, interval: 237, repetitions: 8, ease: 1.38},
+				{grade: 3, interval: 327, repetitions: 9, ease: 1.3},
+				{grade: 3, interval: 425, repetitions: 10, ease: 1.3},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &clock{now: start}
+			r := review.New(review.NewMemoryStore())
+			r.SetClock(c.Now)
+
+			card, err := r.Add("adam", review.Card{Text: "brain"})
+			require.Nil(t, err)
+			require.Equal(t, start, card.Due)
+
+			for i, step := range tt.steps {
+				card, err = r.Grade("adam", card.ID, step.grade)
+				require.Nil(t, err)
+				require.Equal(t, step.interval, card.Interval, "step %d", i)
+				require.Equal(t, step.repetitions, card.Repetitions, "step %d", i)
+				require.InDelta(t, step.ease, card.Ease, 0.0001, "step %d", i)
+				require.Equal(t, c.now, card.Reviewed)
+				require.Equal(t, c.now.Add(time.Duration(step.interval)*24*time.Hour), card.Due)
+				c.days(step.interval)
+			}
+		})
+	}
+}
+
+func TestReview_Due(t *testing.T) {
+	c := &clock{now: time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)}
+	r := review.New(review.NewMemoryStore())
+	r.SetClock(c.Now)
+
+	brain, err := r.Add("adam", review.Card{Text: "brain", WordID: "1"})
+	require.Nil(t, err)
+	c.days(1)
+	world, err := r.Add("adam", review.Card{Text: "world", WordID: "2"})
+	require.Nil(t, err)
+	_, err = r.Add("eve", review.Card{Text: "apple"})
+	require.Nil(t, err)
+
+	due, err := r.Due("adam", 0)
+	require.Nil(t, err)
+	require.Len(t, due, 2)
+	require.Equal(t, brain.ID, due[0].ID, "the most overdue first")
+	require.Equal(t, world.ID, due[1].ID)
+
+	due, err = r.Due("adam", 1)
+	require.Nil(t, err)
+	require.Len(t, due, 1)
+
+	_, err = r.Grade("adam", brain.ID, 5)
+	require.Nil(t, err)
+	due, err = r.Due("adam", 0)
+	require.Nil(t, err)
+	require.Len(t, due, 1)
+	require.Equal(t, world.ID, due[0].ID)
+
+	// After one day brain is due again
+	c.days(1)
+	due, err = r.Due("adam", 0)
+	require.Nil(t, err)
+	require.Len(t, due, 2)
+}
+
+func TestReview_errors(t *testing.T) {
+	r := review.New(review.NewMemoryStore())
+	card, err := r.Add("adam", review.Card{Text: "brain", WordID: "1"})
+	require.Nil(t, err)
+
+	_, err = r.Add("adam", review.Card{Text: "brain", WordID: "1"})
+	require.True(t, errors.Is(err, review.ErrExist))
+
+	_, err = r.Add("adam", review.Card{})
+	require.True(t, errors.Is(err, review.ErrInvalid))
+
+	for _, grade := range []int{-1, 6} {
+		_, err = r.Grade("adam", card.ID, grade)
+		require.True(t, errors.Is(err, review.ErrInvalid))
+	}
+
+	_, err = r.Grade("eve", card.ID, 5)
+	require.True(t, errors.Is(err, review.ErrNotExist))
+
+	r = review.New(errStore{})
+	_, err = r.Add("adam", review.Card{Text: "brain"})
+	require.True(t, errors.Is(err, review.ErrIO))
+	_, err = r.Due("adam", 0)
+	require.True(t, errors.Is(err, review.ErrIO))
+	_, err = r.Grade("adam", "1", 5)
+	require.True(t, errors.Is(err, review.ErrIO))
+}
+
+func TestReview_concurrent(t *testing.T) {
+	r := review.New(review.NewMemoryStore())
+	card, err := r.Add("adam", review.Card{Text: "brain", WordID: "1"})
+	require.Nil(t, err)
+
+	const n = 8
+	var wg sync.WaitGroup
+	added := make(chan struct{}, n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := r.Grade("adam", card.ID, 5)
+			require.Nil(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := r.Add("adam", review.Card{Text: "world", WordID: "2"}); err == nil {
+				added <- struct{}{}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every grade counts and only one card is added per word
+	card, err = r.Card("adam", card.ID)
+	require.Nil(t, err)
+	require.Equal(t, n, card.Repetitions)
+	require.Len(t, added, 1)
+}
@@ -0,0 +1,33 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// admin allows only users set by SetAdmins, must be used after auth()
+func (s *Server) admin() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if _, ok := s.admins[userName(context)]; !ok {
+			context.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin rights required"})
+			return
+		}
+		context.Next()
+	}
+}
+
+// usage handles GET /api/admin/usage
+func (s *Server) usage() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		report, err := s.reporter.Report(context.Request.Context())
+		if err != nil {
+			context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, report)
+	}
+}
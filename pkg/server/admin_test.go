@@ -0,0 +1,64 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/pkg/usage"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+// fakeUsager returns usage or err
+type fakeUsager struct {
+	usage *deepl.Usage
+	err   error
+}
+
+func (f *fakeUsager) Usage(_ context.Context) (*deepl.Usage, error) {
+	return f.usage, f.err
+}
+
+func TestServer_usage(t *testing.T) {
+	tracker := usage.New(usage.NewMemoryStore(), &fakeUsager{usage: &deepl.Usage{CharacterCount: 10, CharacterLimit: 500000}}, usage.Limits{User: 100})
+	require.Nil(t, tracker.Add("adam", 4))
+	s := newServer(&handler{Reporter: tracker})
+	s.SetAdmins("root")
+	adam := login(t, s, "adam")
+	root := login(t, s, "root")
+
+	require.Equal(t, http.StatusUnauthorized, serve(t, s, http.MethodGet, "/api/admin/usage", "", "").Code)
+	require.Equal(t, http.StatusForbidden, serve(t, s, http.MethodGet, "/api/admin/usage", adam, "").Code)
+
+	response := serve(t, s, http.MethodGet, "/api/admin/usage", root, "")
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var report usage.Report
+	require.Nil(t, json.NewDecoder(response.Body).Decode(&report))
+	require.Equal(t, map[string]int64{"adam": 4}, report.Users)
+	require.EqualValues(t, 4, report.Total)
+	require.EqualValues(t, 100, report.Limits.User)
+	require.Equal(t, &deepl.Usage{CharacterCount: 10, CharacterLimit: 500000}, report.DeepL)
+
+	s = newServer(&handler{Reporter: usage.New(errUsageStore{}, &fakeUsager{err: fmt.Errorf("deepl is down")}, usage.Limits{})})
+	s.SetAdmins("root")
+	root = login(t, s, "root")
+	require.Equal(t, http.StatusInternalServerError, serve(t, s, http.MethodGet, "/api/admin/usage", root, "").Code)
+}
+
+// errUsageStore fails every call
+type errUsageStore struct {
+}
+
+func (e errUsageStore) AddCharacters(_, _ string, _ int64) error {
+	return fmt.Errorf("io err")
+}
+
+func (e errUsageStore) LoadCharacters(_ string) (map[string]int64, error) {
+	return nil, fmt.Errorf("io err")
+}
@@ -0,0 +1,97 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"errors"
+	"github.com/a-clap/dictionary/internal/merriamw/audio"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// audioURL is address of pronunciation in single format
+type audioURL struct {
+	Format audio.Format `json:"format"`
+	// URL is served by Server, so file is cached
+	URL string `json:"url"`
+	// Source is address on MW server
+	Source string `json:"source"`
+}
+
+// getAudio handles GET /api/audio/:filename, filename may have extension of audio.Format, e.g. "brain001.ogg",
+// MP3 is used without one. File is streamed with support of range and conditional requests
+func (s *Server) getAudio() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		filename, format, err := parseAudio(context.Param("filename"))
+		if err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		f, err := s.audio.OpenAudio(context.Request.Context(), filename, format)
+		if err != nil {
+			Logger.Errorf("audio %s.%s failed: %v", filename, format, err)
+			context.AbortWithStatusJSON(audioErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				Logger.Errorf("failed to close %s.%s: %v", filename, format, err)
+			}
+		}()
+
+		// Content never changes, so hash is strong validator
+		context.Header("ETag", `"`+f.Hash+`"`)
+		context.Header("Cache-Control", "private, max-age=31536000, immutable")
+		context.Header("Content-Type", format.ContentType())
+		http.ServeContent(context.Writer, context.Request, filename+"."+string(format), f.ModTime, f)
+	}
+}
+
+// audioURLs handles GET /api/audio/:filename/urls, it lists addresses of filename in every audio.Format
+func (s *Server) audioURLs() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		filename := context.Param("filename")
+		if err := audio.Validate(filename); err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		urls := make([]audioURL, len(audio.Formats))
+		for i, format := range audio.Formats {
+			urls[i] = audioURL{
+				Format: format,
+				URL:    "/api/audio/" + filename + "." + string(format),
+				Source: audio.URL(filename, format),
+			}
+		}
+		context.JSON(http.StatusOK, urls)
+	}
+}
+
+// parseAudio splits name into filename and format
+func parseAudio(name string) (string, audio.Format, error) {
+	filename, ext, ok := strings.Cut(name, ".")
+	if !ok {
+		return filename, audio.MP3, nil
+	}
+	format, err := audio.ParseFormat(ext)
+	return filename, format, err
+}
+
+// audioErrorCode maps errors from audio to http status code
+func audioErrorCode(err error) int {
+	switch {
+	case errors.Is(err, audio.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, audio.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, audio.ErrUpstream):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
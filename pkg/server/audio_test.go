@@ -0,0 +1,139 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/merriamw/audio"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeOpener fails every request
+type fakeOpener struct {
+}
+
+func (f *fakeOpener) OpenAudio(_ context.Context, _ string, _ audio.Format) (*audio.File, error) {
+	return nil, fmt.Errorf("audio not expected")
+}
+
+// fakeFetcher responds with files[filename.format]
+type fakeFetcher struct {
+	files map[string]string
+	err   error
+	calls int
+}
+
+func (f *fakeFetcher) Fetch(_ context.Context, filename string, format audio.Format) (io.ReadCloser, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	data, ok := f.files[filename+"."+string(format)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", audio.ErrNotFound, filename)
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+func TestServer_audio(t *testing.T) {
+	fetcher := &fakeFetcher{files: map[string]string{
+		"brain001.mp3": "0123456789",
+		"brain001.ogg": "OggS",
+	}}
+	c, err := audio.NewCache(t.TempDir(), fetcher)
+	require.Nil(t, err)
+	s := newServer(&handler{Opener: c})
+	token := login(t, s, "adam")
+
+	response := serve(t, s, http.MethodGet, "/api/audio/brain001", "", "")
+	require.Equal(t, http.StatusUnauthorized, response.Code)
+
+	response = serve(t, s, http.MethodGet, "/api/audio/brain001", token, "")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.Equal(t, "0123456789", response.Body.String())
+	require.Equal(t, "audio/mpeg", response.Header().Get("Content-Type"))
+	require.Equal(t, "bytes", response.Header().Get("Accept-Ranges"))
+	etag := response.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	response = serve(t, s, http.MethodGet, "/api/audio/brain001.ogg", token, "")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.Equal(t, "OggS", response.Body.String())
+	require.Equal(t, "audio/ogg", response.Header().Get("Content-Type"))
+
+	// Range request
+	request, err := http.NewRequest(http.MethodGet, "/api/audio/brain001.mp3", nil)
+	require.Nil(t, err)
+	request.Header.Set("Authorization", token)
+	request.Header.Set("Range", "bytes=2-5")
+	response = httptest.NewRecorder()
+	s.ServeHTTP(response, request)
+	require.Equal(t, http.StatusPartialContent, response.Code)
+	require.Equal(t, "2345", response.Body.String())
+	require.Equal(t, "bytes 2-5/10", response.Header().Get("Content-Range"))
+
+	// Conditional request
+	request.Header.Del("Range")
+	request.Header.Set("If-None-Match", etag)
+	response = httptest.NewRecorder()
+	s.ServeHTTP(response, request)
+	require.Equal(t, http.StatusNotModified, response.Code)
+
+	// Everything but the first request of each format was served from cache
+	require.Equal(t, 2, fetcher.calls)
+}
+
+func TestServer_audio_errors(t *testing.T) {
+	fetcher := &fakeFetcher{}
+	c, err := audio.NewCache(t.TempDir(), fetcher)
+	require.Nil(t, err)
+	s := newServer(&handler{Opener: c})
+	token := login(t, s, "adam")
+
+	for _, tt := range []struct {
+		url  string
+		err  error
+		code int
+	}{
+		{url: "/api/audio/brain001.flac", code: http.StatusBadRequest},
+		{url: "/api/audio/br%2E%2E", code: http.StatusBadRequest},
+		{url: "/api/audio/brain001", code: http.StatusNotFound},
+		{url: "/api/audio/brain001", err: fmt.Errorf("%w: status code 503", audio.ErrUpstream), code: http.StatusBadGateway},
+		{url: "/api/audio/brain001", err: fmt.Errorf("disk full"), code: http.StatusInternalServerError},
+	} {
+		fetcher.err = tt.err
+		response := serve(t, s, http.MethodGet, tt.url, token, "")
+		require.Equal(t, tt.code, response.Code, tt.url)
+		require.Contains(t, response.Body.String(), "error", tt.url)
+	}
+}
+
+func TestServer_audioURLs(t *testing.T) {
+	s := newServer(&handler{})
+	token := login(t, s, "adam")
+
+	response := serve(t, s, http.MethodGet, "/api/audio/brain001/urls", token, "")
+	require.Equal(t, http.StatusOK, response.Code)
+	var urls []struct {
+		Format string `json:"format"`
+		URL    string `json:"url"`
+		Source string `json:"source"`
+	}
+	require.Nil(t, json.NewDecoder(response.Body).Decode(&urls))
+	require.Len(t, urls, 3)
+	require.Equal(t, "ogg", urls[1].Format)
+	require.Equal(t, "/api/audio/brain001.ogg", urls[1].URL)
+	require.Equal(t, "https://media.merriam-webster.com/audio/prons/en/us/ogg/b/brain001.ogg", urls[1].Source)
+
+	response = serve(t, s, http.MethodGet, "/api/audio/brain_%C3%B3/urls", token, "")
+	require.Equal(t, http.StatusBadRequest, response.Code)
+}
@@ -0,0 +1,141 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"errors"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/pkg/glossary"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// glossaryRequest is body of POST on /api/glossary
+type glossaryRequest struct {
+	From    string                `json:"from"`
+	To      string                `json:"to"`
+	Entries []deepl.GlossaryEntry `json:"entries"`
+	// Words adds translations of words from user's word list with the same language pair.
+	// Entries provided explicitly take precedence
+	Words bool `json:"words"`
+}
+
+// compileGlossary creates (or replaces) user's glossary for language pair
+func (s *Server) compileGlossary() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var r glossaryRequest
+		if err := context.ShouldBindJSON(&r); err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		from, to, err := parseLangs(r.From, r.To)
+		if err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(from) == 0 {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "glossary requires source language"})
+			return
+		}
+
+		entries := r.Entries
+		if r.Words {
+			if entries, err = s.wordEntries(userName(context), from, to, entries); err != nil {
+				context.AbortWithStatusJSON(wordErrorCode(err), gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		g, err := s.glossaries.Compile(context.Request.Context(), userName(context), from, to, entries)
+		if err != nil {
+			context.AbortWithStatusJSON(glossaryErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusCreated, g)
+	}
+}
+
+func (s *Server) listGlossaries() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		glossaries, err := s.glossaries.List(userName(context))
+		if err != nil {
+			context.AbortWithStatusJSON(glossaryErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, glossaries)
+	}
+}
+
+func (s *Server) getGlossary() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		from, to, err := parseLangs(context.Param("from"), context.Param("to"))
+		if err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		g, err := s.glossaries.Glossary(userName(context), from, to)
+		if err != nil {
+			context.AbortWithStatusJSON(glossaryErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, g)
+	}
+}
+
+func (s *Server) removeGlossary() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		from, to, err := parseLangs(context.Param("from"), context.Param("to"))
+		if err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := s.glossaries.Remove(context.Request.Context(), userName(context), from, to); err != nil {
+			context.AbortWithStatusJSON(glossaryErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"from": from, "to": to.Base()})
+	}
+}
+
+// wordEntries appends to entries translations of user's words for from-to pair, skipping terms already in entries
+func (s *Server) wordEntries(user string, from deepl.SourceLang, to deepl.TargetLang, entries []deepl.GlossaryEntry) ([]deepl.GlossaryEntry, error) {
+	words, err := s.words.Words(user)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(entries))
+	for _, e := range entries {
+		seen[e.Source] = struct{}{}
+	}
+	for _, w := range words {
+		if w.From != from || w.To.Base() != to.Base() || w.Translation == nil || len(w.Translation.Deepl) == 0 {
+			continue
+		}
+		if _, ok := seen[w.Text]; ok {
+			continue
+		}
+		seen[w.Text] = struct{}{}
+		entries = append(entries, deepl.GlossaryEntry{Source: w.Text, Target: w.Translation.Deepl[0].Text})
+	}
+	return entries, nil
+}
+
+// glossaryErrorCode maps errors from glossary to http status code
+func glossaryErrorCode(err error) int {
+	switch {
+	case errors.Is(err, glossary.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, glossary.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, glossary.ErrUpstream):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,144 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/auth"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/pkg/glossary"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fakeGlossaryClient creates glossaries with consecutive ids and remembers their entries
+type fakeGlossaryClient struct {
+	err     error
+	next    int
+	entries map[string]string
+}
+
+func (f *fakeGlossaryClient) CreateGlossary(_ context.Context, name string, source, target deepl.SourceLang, entries string, _ deepl.EntriesFormat) (*deepl.Glossary, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.next++
+	id := fmt.Sprintf("g%d", f.next)
+	if f.entries == nil {
+		f.entries = make(map[string]string)
+	}
+	f.entries[id] = entries
+	return &deepl.Glossary{ID: id, Name: name, Ready: true, SourceLang: string(source), TargetLang: string(target)}, nil
+}
+
+func (f *fakeGlossaryClient) DeleteGlossary(_ context.Context, id string) error {
+	delete(f.entries, id)
+	return nil
+}
+
+func TestServer_glossary(t *testing.T) {
+	translate := &fakeTranslate{translations: map[string]*translator.Translation{
+		"mózg": {Deepl: []translator.DeeplTranslate{{Text: "brain"}}},
+		"kot":  {Deepl: []translator.DeeplTranslate{{Text: "cat"}}},
+	}}
+	client := &fakeGlossaryClient{}
+	s := newServer(&handler{
+		StoreTokener: auth.NewMemoryStore([]byte("key"), time.Hour),
+		Translate:    translate,
+		Client:       client,
+	})
+	adam := login(t, s, "adam")
+	eve := login(t, s, "eve")
+
+	t.Run("translate without glossary", func(t *testing.T) {
+		response := serve(t, s, http.MethodGet, "/api/translate?text=kot&from=PL&to=EN-GB", adam, "")
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		require.Empty(t, translate.opts.Glossary)
+	})
+
+	t.Run("compile from entries and words", func(t *testing.T) {
+		for _, text := range []string{"mózg", "kot"} {
+			response := serve(t, s, http.MethodPost, "/api/words", adam, fmt.Sprintf(`{"text": "%s", "from": "PL", "to": "EN-US"}`, text))
+			require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+		}
+
+		body := `{"from": "PL", "to": "EN-GB", "entries": [{"source": "kot", "target": "kitty"}], "words": true}`
+		response := serve(t, s, http.MethodPost, "/api/glossary", adam, body)
+		require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+		var g glossary.Glossary
+		require.Nil(t, json.NewDecoder(response.Body).Decode(&g))
+		require.Equal(t, "g1", g.ID)
+		require.Equal(t, deepl.SrcPolish, g.From)
+		require.Equal(t, deepl.SrcEnglish, g.To)
+		require.Equal(t, []deepl.GlossaryEntry{{Source: "kot", Target: "kitty"}, {Source: "mózg", Target: "brain"}}, g.Entries)
+		require.Equal(t, "kot\tkitty\nmózg\tbrain\n", client.entries["g1"])
+	})
+
+	t.Run("glossary used by translate", func(t *testing.T) {
+		response := serve(t, s, http.MethodGet, "/api/translate?text=kot&from=PL&to=EN-US", adam, "")
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		require.Equal(t, "g1", translate.opts.Glossary)
+
+		response = serve(t, s, http.MethodGet, "/api/translate?text=kot&to=EN-US", adam, "")
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		require.Empty(t, translate.opts.Glossary)
+
+		response = serve(t, s, http.MethodGet, "/api/translate?text=kot&from=PL&to=EN-US", eve, "")
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		require.Empty(t, translate.opts.Glossary)
+	})
+
+	t.Run("list and get", func(t *testing.T) {
+		response := serve(t, s, http.MethodGet, "/api/glossary", adam, "")
+		require.Equal(t, http.StatusOK, response.Code)
+		var glossaries []glossary.Glossary
+		require.Nil(t, json.NewDecoder(response.Body).Decode(&glossaries))
+		require.Len(t, glossaries, 1)
+
+		response = serve(t, s, http.MethodGet, "/api/glossary/PL/EN-GB", adam, "")
+		require.Equal(t, http.StatusOK, response.Code)
+		require.Contains(t, response.Body.String(), `"id":"g1"`)
+
+		response = serve(t, s, http.MethodGet, "/api/glossary", eve, "")
+		require.Equal(t, http.StatusOK, response.Code)
+		require.Equal(t, "[]", response.Body.String())
+		require.Equal(t, http.StatusNotFound, serve(t, s, http.MethodGet, "/api/glossary/PL/EN-GB", eve, "").Code)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		response := serve(t, s, http.MethodPost, "/api/glossary", adam, `{"from": "PL", "to": "EN-US", "entries": [{"source": "kot", "target": "cat"}]}`)
+		require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+		require.Contains(t, response.Body.String(), `"id":"g2"`)
+		require.NotContains(t, client.entries, "g1")
+	})
+
+	t.Run("compile errors", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, serve(t, s, http.MethodPost, "/api/glossary", "", `{}`).Code)
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, "/api/glossary", adam, `hello`).Code)
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, "/api/glossary", adam, `{"to": "EN-GB", "entries": [{"source": "kot", "target": "cat"}]}`).Code)
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, "/api/glossary", adam, `{"from": "PL", "to": "XX", "entries": [{"source": "kot", "target": "cat"}]}`).Code)
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, "/api/glossary", adam, `{"from": "PL", "to": "EN-GB"}`).Code)
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, "/api/glossary", adam, `{"from": "PL", "to": "EN-GB", "entries": [{"source": "kot", "target": "cat"}, {"source": "kot", "target": "kitty"}]}`).Code)
+
+		client.err = fmt.Errorf("deepl is down")
+		defer func() { client.err = nil }()
+		require.Equal(t, http.StatusBadGateway, serve(t, s, http.MethodPost, "/api/glossary", eve, `{"from": "PL", "to": "EN-GB", "entries": [{"source": "kot", "target": "cat"}]}`).Code)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodDelete, "/api/glossary/XX/EN-GB", adam, "").Code)
+		require.Equal(t, http.StatusNotFound, serve(t, s, http.MethodDelete, "/api/glossary/PL/EN-GB", eve, "").Code)
+
+		response := serve(t, s, http.MethodDelete, "/api/glossary/PL/EN-GB", adam, "")
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		require.Empty(t, client.entries)
+		require.Equal(t, http.StatusNotFound, serve(t, s, http.MethodGet, "/api/glossary/PL/EN-GB", adam, "").Code)
+	})
+}
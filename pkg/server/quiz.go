@@ -0,0 +1,107 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"errors"
+	"github.com/a-clap/dictionary/pkg/quiz"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// defaultQuizCount is number of questions, if request doesn't specify it
+const defaultQuizCount = 10
+
+// record saves word looked up by user, so it can be used in quizzes later.
+// Failure is not a reason to fail whole request, so it is only logged
+func (s *Server) record(context *gin.Context, text string, translation *translator.Translation) {
+	if err := s.quiz.Record(userName(context), text, translation); err != nil {
+		Logger.Errorf("failed to record %s for quiz: %v", text, err)
+	}
+}
+
+// quizWords returns every word recorded for user, together with statistics
+func (s *Server) quizWords() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		entries, err := s.quiz.Entries(userName(context))
+		if err != nil {
+			context.AbortWithStatusJSON(quizErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, entries)
+	}
+}
+
+// startQuiz creates new quiz session
+func (s *Server) startQuiz() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var r struct {
+			Mode  quiz.Mode `json:"mode"`
+			Count int       `json:"count"`
+		}
+		if err := context.ShouldBindJSON(&r); err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if r.Count == 0 {
+			r.Count = defaultQuizCount
+		}
+
+		session, err := s.quiz.Start(userName(context), r.Mode, r.Count)
+		if err != nil {
+			context.AbortWithStatusJSON(quizErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusCreated, session)
+	}
+}
+
+// answerQuiz checks answer for single question
+func (s *Server) answerQuiz() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var r struct {
+			Question *int   `json:"question" binding:"required"`
+			Answer   string `json:"answer"`
+		}
+		if err := context.ShouldBindJSON(&r); err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := s.quiz.Answer(userName(context), context.Param("id"), *r.Question, r.Answer)
+		if err != nil {
+			context.AbortWithStatusJSON(quizErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, result)
+	}
+}
+
+// quizScore returns score of quiz session
+func (s *Server) quizScore() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		score, err := s.quiz.Score(userName(context), context.Param("id"))
+		if err != nil {
+			context.AbortWithStatusJSON(quizErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, score)
+	}
+}
+
+// quizErrorCode maps errors from quiz to http status code
+func quizErrorCode(err error) int {
+	switch {
+	case errors.Is(err, quiz.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, quiz.ErrAnswered):
+		return http.StatusConflict
+	case errors.Is(err, quiz.ErrInvalid), errors.Is(err, quiz.ErrNotEnough):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,78 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/auth"
+	"github.com/a-clap/dictionary/pkg/quiz"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestServer_quiz(t *testing.T) {
+	words := map[string]string{"mózg": "brain", "dom": "house", "kot": "cat"}
+	translations := map[string]*translator.Translation{}
+	for text, translation := range words {
+		translations[text] = &translator.Translation{Deepl: []translator.DeeplTranslate{{Text: translation}}}
+	}
+
+	s := newServer(&handler{
+		StoreTokener: auth.NewMemoryStore([]byte("key"), time.Hour),
+		Translate:    &fakeTranslate{translations: translations},
+		Store:        quiz.NewMemoryStore(),
+	})
+	adam := login(t, s, "adam")
+	eve := login(t, s, "eve")
+
+	t.Run("not enough words", func(t *testing.T) {
+		response := serve(t, s, http.MethodPost, "/api/quiz/sessions", adam, `{"mode": "translation"}`)
+		require.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	// Translations are recorded for quiz
+	for text := range words {
+		response := serve(t, s, http.MethodGet, "/api/translate?to=EN-GB&text="+url.QueryEscape(text), adam, "")
+		require.Equal(t, http.StatusOK, response.Code)
+	}
+
+	response := serve(t, s, http.MethodGet, "/api/quiz/words", adam, "")
+	require.Equal(t, http.StatusOK, response.Code)
+	var entries []quiz.Entry
+	require.Nil(t, json.NewDecoder(response.Body).Decode(&entries))
+	require.Len(t, entries, len(words))
+	require.Equal(t, "[]", serve(t, s, http.MethodGet, "/api/quiz/words", eve, "").Body.String())
+
+	response = serve(t, s, http.MethodPost, "/api/quiz/sessions", adam, `{"mode": "translation", "count": 2}`)
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+	require.NotContains(t, response.Body.String(), `"answer":`, "answers must not be sent")
+	var session quiz.Session
+	require.Nil(t, json.NewDecoder(response.Body).Decode(&session))
+	require.Len(t, session.Questions, 2)
+
+	answers := fmt.Sprintf("/api/quiz/sessions/%s/answers", session.ID)
+	question := session.Questions[0]
+	body := fmt.Sprintf(`{"question": 0, "answer": "%s"}`, words[question.Prompt])
+	response = serve(t, s, http.MethodPost, answers, adam, body)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	require.Equal(t, fmt.Sprintf(`{"correct":true,"answer":"%s"}`, words[question.Prompt]), response.Body.String())
+
+	require.Equal(t, http.StatusConflict, serve(t, s, http.MethodPost, answers, adam, body).Code)
+	require.Equal(t, http.StatusNotFound, serve(t, s, http.MethodPost, answers, eve, body).Code)
+	require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, answers, adam, `{"answer": "x"}`).Code)
+	require.Equal(t, http.StatusOK, serve(t, s, http.MethodPost, answers, adam, `{"question": 1, "answer": "x"}`).Code)
+
+	response = serve(t, s, http.MethodGet, "/api/quiz/sessions/"+session.ID, adam, "")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.Equal(t, `{"total":2,"answered":2,"correct":1}`, response.Body.String())
+	require.Equal(t, http.StatusNotFound, serve(t, s, http.MethodGet, "/api/quiz/sessions/"+session.ID, eve, "").Code)
+
+	require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, "/api/quiz/sessions", adam, `{"mode": "unknown"}`).Code)
+}
@@ -0,0 +1,101 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"errors"
+	"github.com/a-clap/dictionary/pkg/review"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// defaultDueLimit is number of cards returned by dueCards, if request doesn't contain limit
+const defaultDueLimit = 10
+
+// addCard creates review card from word saved in user's word list
+func (s *Server) addCard() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var r struct {
+			WordID string `json:"word_id" binding:"required"`
+		}
+		if err := context.ShouldBindJSON(&r); err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		word, err := s.words.Word(userName(context), r.WordID)
+		if err != nil {
+			context.AbortWithStatusJSON(wordErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		card, err := s.review.Add(userName(context), review.Card{
+			WordID:      word.ID,
+			Text:        word.Text,
+			Translation: word.Translation,
+		})
+		if err != nil {
+			context.AbortWithStatusJSON(reviewErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusCreated, card)
+	}
+}
+
+// dueCards handles GET /api/review/due?limit=10
+func (s *Server) dueCards() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		limit := defaultDueLimit
+		if l, ok := context.GetQuery("limit"); ok {
+			var err error
+			if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+				context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "limit must be positive number"})
+				return
+			}
+		}
+
+		cards, err := s.review.Due(userName(context), limit)
+		if err != nil {
+			context.AbortWithStatusJSON(reviewErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, cards)
+	}
+}
+
+// gradeCard submits review grade for card
+func (s *Server) gradeCard() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var r struct {
+			Grade *int `json:"grade" binding:"required"`
+		}
+		if err := context.ShouldBindJSON(&r); err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		card, err := s.review.Grade(userName(context), context.Param("id"), *r.Grade)
+		if err != nil {
+			context.AbortWithStatusJSON(reviewErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, card)
+	}
+}
+
+// reviewErrorCode maps errors from review to http status code
+func reviewErrorCode(err error) int {
+	switch {
+	case errors.Is(err, review.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, review.ErrExist):
+		return http.StatusConflict
+	case errors.Is(err, review.ErrInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
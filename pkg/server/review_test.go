@@ -0,0 +1,81 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/auth"
+	"github.com/a-clap/dictionary/pkg/review"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/a-clap/dictionary/pkg/wordlist"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServer_review(t *testing.T) {
+	s := newServer(&handler{
+		StoreTokener: auth.NewMemoryStore([]byte("key"), time.Hour),
+		Translate: &fakeTranslate{translation: &translator.Translation{
+			Deepl: []translator.DeeplTranslate{{Text: "brain"}},
+		}},
+		WordStore: wordlist.NewMemoryStore(),
+		CardStore: review.NewMemoryStore(),
+	})
+	adam := login(t, s, "adam")
+	eve := login(t, s, "eve")
+
+	response := serve(t, s, http.MethodPost, "/api/words", adam, `{"text": "mózg", "from": "PL", "to": "EN-GB"}`)
+	require.Equal(t, http.StatusCreated, response.Code)
+	var word wordlist.Word
+	require.Nil(t, json.NewDecoder(response.Body).Decode(&word))
+
+	var card review.Card
+	t.Run("add card", func(t *testing.T) {
+		body := fmt.Sprintf(`{"word_id": "%s"}`, word.ID)
+		response := serve(t, s, http.MethodPost, "/api/review", adam, body)
+		require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+		require.Nil(t, json.NewDecoder(response.Body).Decode(&card))
+		require.Equal(t, word.ID, card.WordID)
+		require.Equal(t, "mózg", card.Text)
+		require.Equal(t, word.Translation, card.Translation)
+
+		require.Equal(t, http.StatusConflict, serve(t, s, http.MethodPost, "/api/review", adam, body).Code)
+		require.Equal(t, http.StatusNotFound, serve(t, s, http.MethodPost, "/api/review", eve, body).Code)
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, "/api/review", adam, `{}`).Code)
+	})
+
+	t.Run("due", func(t *testing.T) {
+		response := serve(t, s, http.MethodGet, "/api/review/due", adam, "")
+		require.Equal(t, http.StatusOK, response.Code)
+		var cards []review.Card
+		require.Nil(t, json.NewDecoder(response.Body).Decode(&cards))
+		require.Len(t, cards, 1)
+		require.Equal(t, card.ID, cards[0].ID)
+
+		require.Equal(t, "[]", serve(t, s, http.MethodGet, "/api/review/due", eve, "").Body.String())
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodGet, "/api/review/due?limit=abc", adam, "").Code)
+		require.Equal(t, http.StatusUnauthorized, serve(t, s, http.MethodGet, "/api/review/due", "", "").Code)
+	})
+
+	t.Run("grade", func(t *testing.T) {
+		url := "/api/review/" + card.ID
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, url, adam, `{}`).Code)
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, url, adam, `{"grade": 6}`).Code)
+		require.Equal(t, http.StatusNotFound, serve(t, s, http.MethodPost, url, eve, `{"grade": 5}`).Code)
+
+		response := serve(t, s, http.MethodPost, url, adam, `{"grade": 0}`)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		var graded review.Card
+		require.Nil(t, json.NewDecoder(response.Body).Decode(&graded))
+		require.Equal(t, 1, graded.Interval)
+		require.Equal(t, 0, graded.Repetitions)
+
+		// Card is not due until tomorrow
+		require.Equal(t, "[]", serve(t, s, http.MethodGet, "/api/review/due", adam, "").Body.String())
+	})
+}
@@ -11,11 +11,52 @@ func (s *Server) routes() {
 		{
 			user.POST("/add", s.addUser())
 			user.POST("/login", s.loginUser())
+			user.POST("/refresh", s.refreshToken())
+			user.POST("/logout", s.logoutUser())
+			user.POST("/logout/all", s.logoutAll())
 		}
 		translate := api.Group("/translate").Use(s.auth())
 		{
+			translate.GET("", s.translate())
 			translate.GET("/ping", s.pong())
 		}
+		words := api.Group("/words").Use(s.auth())
+		{
+			words.POST("", s.addWord())
+			words.GET("", s.listWords())
+			words.GET("/:id", s.getWord())
+			words.PATCH("/:id", s.updateWord())
+			words.DELETE("/:id", s.removeWord())
+		}
+		cards := api.Group("/review").Use(s.auth())
+		{
+			cards.POST("", s.addCard())
+			cards.GET("/due", s.dueCards())
+			cards.POST("/:id", s.gradeCard())
+		}
+		quizzes := api.Group("/quiz").Use(s.auth())
+		{
+			quizzes.GET("/words", s.quizWords())
+			quizzes.POST("/sessions", s.startQuiz())
+			quizzes.GET("/sessions/:id", s.quizScore())
+			quizzes.POST("/sessions/:id/answers", s.answerQuiz())
+		}
+		glossaries := api.Group("/glossary").Use(s.auth())
+		{
+			glossaries.POST("", s.compileGlossary())
+			glossaries.GET("", s.listGlossaries())
+			glossaries.GET("/:from/:to", s.getGlossary())
+			glossaries.DELETE("/:from/:to", s.removeGlossary())
+		}
+		sounds := api.Group("/audio").Use(s.auth())
+		{
+			sounds.GET("/:filename", s.getAudio())
+			sounds.GET("/:filename/urls", s.audioURLs())
+		}
+		admin := api.Group("/admin").Use(s.auth(), s.admin())
+		{
+			admin.GET("/usage", s.usage())
+		}
 	}
 
 }
@@ -6,27 +6,68 @@ package server
 
 import (
 	"github.com/a-clap/dictionary/internal/auth"
+	"github.com/a-clap/dictionary/internal/merriamw/audio"
+	"github.com/a-clap/dictionary/pkg/glossary"
+	"github.com/a-clap/dictionary/pkg/quiz"
+	"github.com/a-clap/dictionary/pkg/review"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/a-clap/dictionary/pkg/usage"
+	"github.com/a-clap/dictionary/pkg/wordlist"
 	"github.com/a-clap/logger"
 	"github.com/gin-gonic/gin"
 )
 
 var Logger logger.Logger = logger.NewNop()
 
+// Handler provides everything Server needs: access to users store, translations, users words, review cards, quizzes,
+// glossaries together with client compiling them on DeepL side, usage of DeepL and pronunciations
 type Handler interface {
 	auth.StoreTokener
+	translator.Translate
+	wordlist.WordStore
+	review.CardStore
+	quiz.Store
+	glossary.GlossaryStore
+	glossary.Client
+	usage.Reporter
+	audio.Opener
 }
 
 type Server struct {
 	*gin.Engine
-	manager *auth.Manager
+	manager    *auth.Manager
+	translator *translator.Translator
+	words      *wordlist.WordList
+	review     *review.Review
+	quiz       *quiz.Quiz
+	glossaries *glossary.Glossaries
+	reporter   usage.Reporter
+	audio      audio.Opener
+	admins     map[string]struct{}
 }
 
 func New(h Handler) *Server {
 	s := &Server{
-		Engine:  gin.Default(),
-		manager: auth.New(h),
+		Engine:     gin.Default(),
+		manager:    auth.New(h),
+		translator: translator.New(h),
+		words:      wordlist.New(h),
+		review:     review.New(h),
+		quiz:       quiz.New(h),
+		glossaries: glossary.New(h, h),
+		reporter:   h,
+		audio:      h,
+		admins:     map[string]struct{}{},
 	}
 
 	s.routes()
 	return s
 }
+
+// SetAdmins sets names of users, who can access /api/admin
+func (s *Server) SetAdmins(names ...string) {
+	s.admins = make(map[string]struct{}, len(names))
+	for _, name := range names {
+		s.admins[name] = struct{}{}
+	}
+}
@@ -5,8 +5,15 @@
 package server
 
 import (
+	"errors"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/internal/mymemory"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/a-clap/dictionary/pkg/usage"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func (s *Server) pong() gin.HandlerFunc {
@@ -14,3 +21,81 @@ func (s *Server) pong() gin.HandlerFunc {
 		context.JSON(http.StatusOK, gin.H{"message": "pong"})
 	}
 }
+
+// translate handles GET /api/translate?text=...&from=PL&to=EN-GB&autocorrect=true.
+// Text unknown to dictionary is not an error, Translation lists spelling suggestions instead
+func (s *Server) translate() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		text := context.Query("text")
+		if len(text) == 0 {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "request doesn't contain text to translate"})
+			return
+		}
+
+		from, to, err := parseLangs(context.Query("from"), context.Query("to"))
+		if err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var opts translator.Options
+		if v, ok := context.GetQuery("autocorrect"); ok {
+			if opts.Autocorrect, err = strconv.ParseBool(v); err != nil {
+				context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid autocorrect: " + v})
+				return
+			}
+		}
+
+		translation, err := s.translation(context, text, from, to, opts)
+		if err != nil {
+			Logger.Errorf("translate %s failed: %v", text, err)
+			abortTranslation(context, err)
+			return
+		}
+
+		s.record(context, text, translation)
+		context.JSON(http.StatusOK, translation)
+	}
+}
+
+// translation translates text with opts on behalf of user, using their glossary for from-to pair, if there is any
+func (s *Server) translation(context *gin.Context, text string, from deepl.SourceLang, to deepl.TargetLang, opts translator.Options) (*translator.Translation, error) {
+	opts.User = userName(context)
+	// DeepL requires source language to use glossary
+	if len(from) > 0 {
+		id, err := s.glossaries.ID(userName(context), from, to)
+		if err != nil {
+			// Translation without glossary is still better than nothing
+			Logger.Errorf("failed to get glossary %s-%s: %v", from, to, err)
+		}
+		opts.Glossary = id
+	}
+	return s.translator.Get(context.Request.Context(), text, from, to, opts)
+}
+
+// abortTranslation aborts context with status code matching err returned by translator,
+// passing on delay requested by DeepL
+func abortTranslation(context *gin.Context, err error) {
+	var statusErr *deepl.StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		seconds := (statusErr.RetryAfter + time.Second - 1) / time.Second
+		context.Header("Retry-After", strconv.Itoa(int(seconds)))
+	}
+	if errors.Is(err, deepl.ErrAuth) {
+		Logger.Errorf("DeepL rejected key of server: %v", err)
+	}
+	context.AbortWithStatusJSON(translateErrorCode(err), gin.H{"error": err.Error()})
+}
+
+// translateErrorCode maps errors from translator to http status code
+func translateErrorCode(err error) int {
+	switch {
+	case errors.Is(err, deepl.ErrAuth):
+		return http.StatusUnauthorized
+	case errors.Is(err, translator.ErrRefused), errors.Is(err, deepl.ErrRateLimited), errors.Is(err, deepl.ErrQuotaExceeded),
+		errors.Is(err, mymemory.ErrRateLimited), errors.Is(err, usage.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadGateway
+	}
+}
@@ -0,0 +1,307 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/auth"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/internal/merriamw/audio"
+	"github.com/a-clap/dictionary/pkg/glossary"
+	"github.com/a-clap/dictionary/pkg/quiz"
+	"github.com/a-clap/dictionary/pkg/review"
+	"github.com/a-clap/dictionary/pkg/server"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/a-clap/dictionary/pkg/usage"
+	"github.com/a-clap/dictionary/pkg/wordlist"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var _ server.Handler = &handler{}
+
+// handler composes server.Handler from separate parts, so each test can replace only what it needs
+type handler struct {
+	auth.StoreTokener
+	translator.Translate
+	wordlist.WordStore
+	review.CardStore
+	quiz.Store
+	glossary.GlossaryStore
+	glossary.Client
+	usage.Reporter
+	audio.Opener
+}
+
+// newServer fills parts of h, which are not set by test, with in-memory stores and creates server
+func newServer(h *handler) *server.Server {
+	if h.StoreTokener == nil {
+		h.StoreTokener = auth.NewMemoryStore([]byte("key"), time.Hour)
+	}
+	if h.Translate == nil {
+		h.Translate = &fakeTranslate{err: fmt.Errorf("translation not expected")}
+	}
+	if h.WordStore == nil {
+		h.WordStore = wordlist.NewMemoryStore()
+	}
+	if h.CardStore == nil {
+		h.CardStore = review.NewMemoryStore()
+	}
+	if h.Store == nil {
+		h.Store = quiz.NewMemoryStore()
+	}
+	if h.GlossaryStore == nil {
+		h.GlossaryStore = glossary.NewMemoryStore()
+	}
+	if h.Client == nil {
+		h.Client = &fakeGlossaryClient{}
+	}
+	if h.Reporter == nil {
+		h.Reporter = usage.New(usage.NewMemoryStore(), &fakeUsager{}, usage.Limits{})
+	}
+	if h.Opener == nil {
+		h.Opener = &fakeOpener{}
+	}
+	return server.New(h)
+}
+
+// fakeTranslate returns translation, or translations[text], if translations is set
+type fakeTranslate struct {
+	translation  *translator.Translation
+	translations map[string]*translator.Translation
+	err          error
+	from         deepl.SourceLang
+	to           deepl.TargetLang
+	opts         translator.Options
+}
+
+func (f *fakeTranslate) Get(_ context.Context, text string, from deepl.SourceLang, to deepl.TargetLang, opts translator.Options) (*translator.Translation, error) {
+	f.from, f.to, f.opts = from, to, opts
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.translations != nil {
+		return f.translations[text], nil
+	}
+	return f.translation, nil
+}
+
+// login adds user to server and returns its token
+func login(t *testing.T, s *server.Server, name string) string {
+	body := fmt.Sprintf(`{"name": "%s", "password": "pwd"}`, name)
+	for _, url := range []string{"/api/user/add", "/api/user/login"} {
+		request, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+		require.Nil(t, err)
+		response := httptest.NewRecorder()
+		s.ServeHTTP(response, request)
+		require.Less(t, response.Code, http.StatusMultipleChoices, response.Body.String())
+		if url == "/api/user/login" {
+			resp := make(map[string]interface{})
+			require.Nil(t, json.NewDecoder(response.Body).Decode(&resp))
+			return resp["token"].(string)
+		}
+	}
+	return ""
+}
+
+// serve sends request with body and optional token to server s
+func serve(t *testing.T, s *server.Server, method, url, token, body string) *httptest.ResponseRecorder {
+	request, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	require.Nil(t, err)
+	if len(token) > 0 {
+		request.Header.Set("Authorization", token)
+	}
+	response := httptest.NewRecorder()
+	s.ServeHTTP(response, request)
+	return response
+}
+
+func TestServer_translate(t *testing.T) {
+	translation := &translator.Translation{
+		Deepl: []translator.DeeplTranslate{{Text: "brain"}},
+	}
+	tests := []struct {
+		name      string
+		translate *fakeTranslate
+		url       string
+		auth      bool
+		code      int
+		body      string
+		from      deepl.SourceLang
+		to        deepl.TargetLang
+	}{
+		{
+			name:      "translation",
+			translate: &fakeTranslate{translation: translation},
+			url:       "/api/translate?text=m%C3%B3zg&from=pl&to=EN-GB",
+			auth:      true,
+			code:      http.StatusOK,
+			body:      `"deepl":[{"text":"brain"}]`,
+			from:      deepl.SrcPolish,
+			to:        deepl.TarEnglishBritish,
+		},
+		{
+			name:      "source language may be omitted",
+			translate: &fakeTranslate{translation: translation},
+			url:       "/api/translate?text=m%C3%B3zg&to=en-us",
+			auth:      true,
+			code:      http.StatusOK,
+			body:      `"deepl":[{"text":"brain"}]`,
+			from:      "",
+			to:        deepl.TarEnglishAmerican,
+		},
+		{
+			name:      "unauthorized",
+			translate: &fakeTranslate{translation: translation},
+			url:       "/api/translate?text=m%C3%B3zg&from=PL&to=EN-GB",
+			auth:      false,
+			code:      http.StatusUnauthorized,
+			body:      "error",
+		},
+		{
+			name:      "missing text",
+			translate: &fakeTranslate{translation: translation},
+			url:       "/api/translate?from=PL&to=EN-GB",
+			auth:      true,
+			code:      http.StatusBadRequest,
+			body:      "error",
+		},
+		{
+			name:      "invalid source language",
+			translate: &fakeTranslate{translation: translation},
+			url:       "/api/translate?text=brain&from=XX&to=EN-GB",
+			auth:      true,
+			code:      http.StatusBadRequest,
+			body:      "invalid language",
+		},
+		{
+			name:      "invalid target language",
+			translate: &fakeTranslate{translation: translation},
+			url:       "/api/translate?text=brain&from=PL&to=EN",
+			auth:      true,
+			code:      http.StatusBadRequest,
+			body:      "invalid language",
+		},
+		{
+			name:      "quota exceeded",
+			translate: &fakeTranslate{err: fmt.Errorf("%w: quota exceeded", translator.ErrRefused)},
+			url:       "/api/translate?text=brain&from=PL&to=EN-GB",
+			auth:      true,
+			code:      http.StatusTooManyRequests,
+			body:      "quota exceeded",
+		},
+		{
+			name:      "user quota exceeded",
+			translate: &fakeTranslate{err: fmt.Errorf("%w: user adam used 10 of 10 characters", usage.ErrQuotaExceeded)},
+			url:       "/api/translate?text=brain&from=PL&to=EN-GB",
+			auth:      true,
+			code:      http.StatusTooManyRequests,
+			body:      "used 10 of 10 characters",
+		},
+		{
+			name:      "cause not wrapped",
+			translate: &fakeTranslate{err: fmt.Errorf("%w: deepl: %v", translator.ErrNoProvider, &deepl.StatusError{Status: http.StatusForbidden})},
+			url:       "/api/translate?text=brain&from=PL&to=EN-GB",
+			auth:      true,
+			code:      http.StatusBadGateway,
+			body:      "no provider",
+		},
+		{
+			name:      "deepl authorization",
+			translate: &fakeTranslate{err: &deepl.StatusError{Status: http.StatusForbidden, Message: "Wrong key"}},
+			url:       "/api/translate?text=brain&from=PL&to=EN-GB",
+			auth:      true,
+			code:      http.StatusUnauthorized,
+			body:      "Wrong key",
+		},
+		{
+			name:      "deepl quota exceeded",
+			translate: &fakeTranslate{err: &deepl.StatusError{Status: deepl.StatusQuotaExceeded}},
+			url:       "/api/translate?text=brain&from=PL&to=EN-GB",
+			auth:      true,
+			code:      http.StatusTooManyRequests,
+			body:      "quota exceeded",
+		},
+		{
+			name:      "upstream error",
+			translate: &fakeTranslate{err: fmt.Errorf("deepl is down")},
+			url:       "/api/translate?text=brain&from=PL&to=EN-GB",
+			auth:      true,
+			code:      http.StatusBadGateway,
+			body:      "deepl is down",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(&handler{
+				StoreTokener: auth.NewMemoryStore([]byte("key"), time.Hour),
+				Translate:    tt.translate,
+			})
+			token := login(t, s, "adam")
+
+			request, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			require.Nil(t, err)
+			if tt.auth {
+				request.Header.Set("Authorization", token)
+			}
+			response := httptest.NewRecorder()
+			s.ServeHTTP(response, request)
+
+			require.Equal(t, tt.code, response.Code)
+			require.Contains(t, response.Body.String(), tt.body)
+			if tt.code == http.StatusOK {
+				require.Equal(t, tt.from, tt.translate.from)
+				require.Equal(t, tt.to, tt.translate.to)
+				require.Equal(t, "adam", tt.translate.opts.User)
+			}
+		})
+	}
+}
+
+func TestServer_translate_retryAfter(t *testing.T) {
+	err := fmt.Errorf("on query %w", &deepl.StatusError{Status: http.StatusTooManyRequests, RetryAfter: 1500 * time.Millisecond})
+	s := newServer(&handler{Translate: &fakeTranslate{err: err}})
+	token := login(t, s, "adam")
+
+	for _, r := range []struct{ method, url, body string }{
+		{method: http.MethodGet, url: "/api/translate?text=brain&from=PL&to=EN-GB"},
+		{method: http.MethodPost, url: "/api/words", body: `{"text": "mózg", "from": "PL", "to": "EN-GB"}`},
+	} {
+		response := serve(t, s, r.method, r.url, token, r.body)
+		require.Equal(t, http.StatusTooManyRequests, response.Code, r.url)
+		require.Equal(t, "2", response.Header().Get("Retry-After"), r.url)
+		require.Contains(t, response.Body.String(), "rate limit", r.url)
+	}
+}
+
+func TestServer_translate_suggestions(t *testing.T) {
+	translate := &fakeTranslate{translation: &translator.Translation{
+		Deepl:       []translator.DeeplTranslate{{Text: "brian"}},
+		Dictionary:  &translator.DictionaryTranslate{Defs: []translator.Definition{}, Synonyms: []string{}},
+		DidYouMean:  true,
+		Suggestions: []string{"brain", "bran"},
+	}}
+	s := newServer(&handler{Translate: translate})
+	token := login(t, s, "adam")
+
+	response := serve(t, s, http.MethodGet, "/api/translate?text=brian&from=PL&to=EN-GB", token, "")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.Contains(t, response.Body.String(), `"did_you_mean":true,"suggestions":["brain","bran"]`)
+	require.False(t, translate.opts.Autocorrect)
+
+	response = serve(t, s, http.MethodGet, "/api/translate?text=brian&from=PL&to=EN-GB&autocorrect=true", token, "")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.True(t, translate.opts.Autocorrect)
+
+	response = serve(t, s, http.MethodGet, "/api/translate?text=brian&from=PL&to=EN-GB&autocorrect=maybe", token, "")
+	require.Equal(t, http.StatusBadRequest, response.Code)
+	require.Contains(t, response.Body.String(), "invalid autocorrect")
+}
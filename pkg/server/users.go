@@ -5,11 +5,15 @@
 package server
 
 import (
+	"errors"
 	"github.com/a-clap/dictionary/internal/auth"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
+// userKey is the key under which auth() stores name of authorized user in gin.Context
+const userKey = "user"
+
 func (s *Server) auth() gin.HandlerFunc {
 	return func(context *gin.Context) {
 		Logger.Infof("auth")
@@ -24,6 +28,7 @@ func (s *Server) auth() gin.HandlerFunc {
 			return
 		}
 		Logger.Infof("user %s logged successfully", user.Name)
+		context.Set(userKey, user.Name)
 		context.Next()
 	}
 }
@@ -53,20 +58,78 @@ func (s *Server) loginUser() gin.HandlerFunc {
 			return
 		}
 
-		if login, err := s.manager.Auth(user); err != nil {
-			context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		token, refresh, err := s.manager.Login(user)
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				code = http.StatusUnauthorized
+			}
+			context.AbortWithStatusJSON(code, gin.H{"error": err.Error()})
 			return
-		} else if !login {
-			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		}
+
+		context.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refresh})
+	}
+}
+
+// refreshToken rotates refresh token and issues new jwtToken
+func (s *Server) refreshToken() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var r struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if err := context.ShouldBindJSON(&r); err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		// All good, generate token
-		token, err := s.manager.Token(user)
+		token, refresh, err := s.manager.Refresh(r.RefreshToken)
 		if err != nil {
-			context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			code := http.StatusUnauthorized
+			if errors.Is(err, auth.ErrIO) {
+				code = http.StatusInternalServerError
+			}
+			context.AbortWithStatusJSON(code, gin.H{"error": err.Error()})
 			return
 		}
-		context.JSON(http.StatusOK, gin.H{"token": token})
+
+		context.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refresh})
 	}
 }
+
+// logoutUser blacklists token from Authorization header
+func (s *Server) logoutUser() gin.HandlerFunc {
+	return s.logout(s.manager.Logout)
+}
+
+// logoutAll invalidates every token issued to user, who owns token from Authorization header
+func (s *Server) logoutAll() gin.HandlerFunc {
+	return s.logout(s.manager.LogoutAll)
+}
+
+func (s *Server) logout(logout func(token string) (*auth.User, error)) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		token := context.GetHeader("Authorization")
+		if len(token) == 0 {
+			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "request doesn't contain an authorization token"})
+			return
+		}
+
+		user, err := logout(token)
+		if err != nil {
+			code := http.StatusUnauthorized
+			if errors.Is(err, auth.ErrIO) {
+				code = http.StatusInternalServerError
+			}
+			context.AbortWithStatusJSON(code, gin.H{"error": err.Error()})
+			return
+		}
+
+		context.JSON(http.StatusOK, gin.H{"name": user.Name})
+	}
+}
+
+// userName returns name of user authorized by auth()
+func userName(context *gin.Context) string {
+	return context.GetString(userKey)
+}
@@ -28,11 +28,11 @@ type memoryStoreError struct {
 	err   bool
 }
 
-func (m *memoryStoreError) AddToken(token string) error {
+func (m *memoryStoreError) AddToken(token string, expires time.Time) error {
 	if m.err {
 		return fmt.Errorf("io error")
 	}
-	return m.store.AddToken(token)
+	return m.store.AddToken(token, expires)
 }
 
 func (m *memoryStoreError) TokenExists(token string) (bool, error) {
@@ -49,6 +49,48 @@ func (m *memoryStoreError) RemoveToken(token string) error {
 	return m.store.RemoveToken(token)
 }
 
+func (m *memoryStoreError) Generation(name string) (uint64, error) {
+	if m.err {
+		return 0, fmt.Errorf("io error")
+	}
+	return m.store.Generation(name)
+}
+
+func (m *memoryStoreError) SetGeneration(name string, generation uint64) error {
+	if m.err {
+		return fmt.Errorf("io error")
+	}
+	return m.store.SetGeneration(name, generation)
+}
+
+func (m *memoryStoreError) SaveRefresh(token auth.RefreshToken) error {
+	if m.err {
+		return fmt.Errorf("io error")
+	}
+	return m.store.SaveRefresh(token)
+}
+
+func (m *memoryStoreError) LoadRefresh(id string) (*auth.RefreshToken, error) {
+	if m.err {
+		return nil, fmt.Errorf("io error")
+	}
+	return m.store.LoadRefresh(id)
+}
+
+func (m *memoryStoreError) UseRefresh(id string) (bool, error) {
+	if m.err {
+		return false, fmt.Errorf("io error")
+	}
+	return m.store.UseRefresh(id)
+}
+
+func (m *memoryStoreError) RemoveFamily(family string) error {
+	if m.err {
+		return fmt.Errorf("io error")
+	}
+	return m.store.RemoveFamily(family)
+}
+
 func (m *memoryStoreError) Key() []byte {
 	return m.store.Key()
 }
@@ -91,7 +133,7 @@ func (m *memoryStoreError) Remove(name string) error {
 
 func TestServer_addUser(t *testing.T) {
 	type fields struct {
-		h server.Handler
+		h *handler
 	}
 	type in struct {
 		url    string
@@ -114,7 +156,7 @@ func TestServer_addUser(t *testing.T) {
 		{
 			name: "add user",
 			fields: fields{
-				h: auth.NewMemoryStore([]byte("extra private key"), 1*time.Minute),
+				h: &handler{StoreTokener: auth.NewMemoryStore([]byte("extra private key"), 1*time.Minute)},
 			},
 			params: []params{
 				{
@@ -133,7 +175,7 @@ func TestServer_addUser(t *testing.T) {
 		{
 			name: "handle errors",
 			fields: fields{
-				h: auth.NewMemoryStore([]byte("extra private key"), 1*time.Minute),
+				h: &handler{StoreTokener: auth.NewMemoryStore([]byte("extra private key"), 1*time.Minute)},
 			},
 			params: []params{
 				{
@@ -163,10 +205,10 @@ func TestServer_addUser(t *testing.T) {
 		{
 			name: "handle IO error",
 			fields: fields{
-				h: &memoryStoreError{
+				h: &handler{StoreTokener: &memoryStoreError{
 					store: auth.NewMemoryStore([]byte("key"), 1*time.Hour),
 					err:   true,
-				},
+				}},
 			},
 			params: []params{
 				{
@@ -186,7 +228,7 @@ func TestServer_addUser(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := require.New(t)
-			s := server.New(tt.fields.h)
+			s := newServer(tt.fields.h)
 
 			for i, param := range tt.params {
 				reader := bytes.NewBuffer([]byte(param.in.body))
@@ -207,7 +249,7 @@ func TestServer_addUser(t *testing.T) {
 
 func TestServer_loginUser(t *testing.T) {
 	type fields struct {
-		h server.Handler
+		h *handler
 	}
 	type in struct {
 		url    string
@@ -231,7 +273,7 @@ func TestServer_loginUser(t *testing.T) {
 		{
 			name: "add user, then login",
 			fields: fields{
-				h: auth.NewMemoryStore([]byte("key"), 1*time.Minute),
+				h: &handler{StoreTokener: auth.NewMemoryStore([]byte("key"), 1*time.Minute)},
 			},
 			params: []params{
 				{
@@ -288,7 +330,7 @@ func TestServer_loginUser(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := require.New(t)
-			s := server.New(tt.fields.h)
+			s := newServer(tt.fields.h)
 
 			for i, param := range tt.params {
 
@@ -309,7 +351,7 @@ func TestServer_loginUser(t *testing.T) {
 
 func TestServer_authUser(t *testing.T) {
 	type fields struct {
-		h server.Handler
+		h *handler
 	}
 	type in struct {
 		url    string
@@ -333,7 +375,7 @@ func TestServer_authUser(t *testing.T) {
 		{
 			name: "add user, login, incorrect auth",
 			fields: fields{
-				h: auth.NewMemoryStore([]byte("key"), 1*time.Minute),
+				h: &handler{StoreTokener: auth.NewMemoryStore([]byte("key"), 1*time.Minute)},
 			},
 			params: []params{
 				{
@@ -390,7 +432,7 @@ func TestServer_authUser(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := require.New(t)
-			s := server.New(tt.fields.h)
+			s := newServer(tt.fields.h)
 
 			for i, param := range tt.params {
 
@@ -420,7 +462,7 @@ func TestServer_authUserToken(t *testing.T) {
 		body: `{"name": "adam", "password": "pwd"}`,
 	}}
 	// Prepare server
-	s := server.New(auth.NewMemoryStore([]byte("key"), time.Hour))
+	s := newServer(&handler{StoreTokener: auth.NewMemoryStore([]byte("key"), time.Hour)})
 
 	t.Run("add users", func(t *testing.T) {
 		for _, user := range users {
@@ -461,3 +503,99 @@ func TestServer_authUserToken(t *testing.T) {
 
 	})
 }
+
+func TestServer_logout(t *testing.T) {
+	newServer := func() *server.Server {
+		return newServer(&handler{StoreTokener: auth.NewMemoryStore([]byte("key"), time.Hour)})
+	}
+	ping := func(s *server.Server, token string) int {
+		return serve(t, s, http.MethodGet, "/api/translate/ping", token, "").Code
+	}
+	loginAgain := func(s *server.Server) string {
+		response := serve(t, s, http.MethodPost, "/api/user/login", "", `{"name": "adam", "password": "pwd"}`)
+		require.Equal(t, http.StatusOK, response.Code)
+		resp := make(map[string]string)
+		require.Nil(t, json.NewDecoder(response.Body).Decode(&resp))
+		return resp["token"]
+	}
+
+	t.Run("logout", func(t *testing.T) {
+		s := newServer()
+		token := login(t, s, "adam")
+		require.Equal(t, http.StatusOK, ping(s, token))
+
+		response := serve(t, s, http.MethodPost, "/api/user/logout", token, "")
+		require.Equal(t, http.StatusOK, response.Code)
+		require.Equal(t, `{"name":"adam"}`, response.Body.String())
+
+		require.Equal(t, http.StatusUnauthorized, ping(s, token))
+		require.Equal(t, http.StatusUnauthorized, serve(t, s, http.MethodPost, "/api/user/logout", token, "").Code)
+	})
+
+	t.Run("logout without token", func(t *testing.T) {
+		s := newServer()
+		require.Equal(t, http.StatusUnauthorized, serve(t, s, http.MethodPost, "/api/user/logout", "", "").Code)
+		require.Equal(t, http.StatusUnauthorized, serve(t, s, http.MethodPost, "/api/user/logout", "123", "").Code)
+		require.Equal(t, http.StatusUnauthorized, serve(t, s, http.MethodPost, "/api/user/logout/all", "", "").Code)
+	})
+
+	t.Run("logout everywhere", func(t *testing.T) {
+		s := newServer()
+		first := login(t, s, "adam")
+		// JWT has precision of seconds, make sure tokens differ
+		time.Sleep(time.Second)
+		second := loginAgain(s)
+		require.NotEqual(t, first, second)
+		eve := login(t, s, "eve")
+
+		response := serve(t, s, http.MethodPost, "/api/user/logout/all", second, "")
+		require.Equal(t, http.StatusOK, response.Code)
+		require.Equal(t, `{"name":"adam"}`, response.Body.String())
+
+		require.Equal(t, http.StatusUnauthorized, ping(s, first))
+		require.Equal(t, http.StatusUnauthorized, ping(s, second))
+		require.Equal(t, http.StatusOK, ping(s, eve))
+
+		// New login works as usual
+		require.Equal(t, http.StatusOK, ping(s, loginAgain(s)))
+	})
+}
+
+func TestServer_refresh(t *testing.T) {
+	s := newServer(&handler{StoreTokener: auth.NewMemoryStore([]byte("key"), time.Hour)})
+	login(t, s, "adam")
+
+	tokens := func(response *httptest.ResponseRecorder) (string, string) {
+		resp := make(map[string]string)
+		require.Nil(t, json.NewDecoder(response.Body).Decode(&resp))
+		require.NotEmpty(t, resp["token"])
+		require.NotEmpty(t, resp["refresh_token"])
+		return resp["token"], resp["refresh_token"]
+	}
+
+	response := serve(t, s, http.MethodPost, "/api/user/login", "", `{"name": "adam", "password": "pwd"}`)
+	require.Equal(t, http.StatusOK, response.Code)
+	_, refresh := tokens(response)
+
+	response = serve(t, s, http.MethodPost, "/api/user/refresh", "", fmt.Sprintf(`{"refresh_token": "%s"}`, refresh))
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	token, newRefresh := tokens(response)
+	require.NotEqual(t, refresh, newRefresh)
+	require.Equal(t, http.StatusOK, serve(t, s, http.MethodGet, "/api/translate/ping", token, "").Code)
+
+	// Reuse revokes newRefresh as well
+	response = serve(t, s, http.MethodPost, "/api/user/refresh", "", fmt.Sprintf(`{"refresh_token": "%s"}`, refresh))
+	require.Equal(t, http.StatusUnauthorized, response.Code)
+	response = serve(t, s, http.MethodPost, "/api/user/refresh", "", fmt.Sprintf(`{"refresh_token": "%s"}`, newRefresh))
+	require.Equal(t, http.StatusUnauthorized, response.Code)
+
+	require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, "/api/user/refresh", "", `{}`).Code)
+
+	// Logout revokes refresh token issued with token
+	response = serve(t, s, http.MethodPost, "/api/user/login", "", `{"name": "adam", "password": "pwd"}`)
+	require.Equal(t, http.StatusOK, response.Code)
+	token, refresh = tokens(response)
+	require.Equal(t, http.StatusOK, serve(t, s, http.MethodPost, "/api/user/logout", token, "").Code)
+	response = serve(t, s, http.MethodPost, "/api/user/refresh", "", fmt.Sprintf(`{"refresh_token": "%s"}`, refresh))
+	require.Equal(t, http.StatusUnauthorized, response.Code)
+}
@@ -0,0 +1,181 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"errors"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/a-clap/dictionary/pkg/wordlist"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// wordRequest is body of POST on /api/words
+type wordRequest struct {
+	Text string `json:"text"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// wordPatch is body of PATCH on /api/words/:id, nil means field is absent
+type wordPatch struct {
+	Text *string `json:"text"`
+	From *string `json:"from"`
+	To   *string `json:"to"`
+}
+
+// addWord translates text and saves it, together with translation, in user's word list
+func (s *Server) addWord() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var r wordRequest
+		if err := context.ShouldBindJSON(&r); err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		word, ok := s.wordFromRequest(context, wordlist.Word{}, r)
+		if !ok {
+			return
+		}
+
+		added, err := s.words.Add(userName(context), *word)
+		if err != nil {
+			context.AbortWithStatusJSON(wordErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusCreated, added)
+	}
+}
+
+func (s *Server) listWords() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		words, err := s.words.Words(userName(context))
+		if err != nil {
+			context.AbortWithStatusJSON(wordErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, words)
+	}
+}
+
+func (s *Server) getWord() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		word, err := s.words.Word(userName(context), context.Param("id"))
+		if err != nil {
+			context.AbortWithStatusJSON(wordErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, word)
+	}
+}
+
+// updateWord changes text or languages of already saved word. Absent fields are left untouched,
+// so empty "from" switches back to detection of source language.
+// Translation is fetched again only if something has changed
+func (s *Server) updateWord() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var p wordPatch
+		if err := context.ShouldBindJSON(&p); err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		old, err := s.words.Word(userName(context), context.Param("id"))
+		if err != nil {
+			context.AbortWithStatusJSON(wordErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		r := wordRequest{Text: old.Text, From: string(old.From), To: string(old.To)}
+		if p.Text != nil {
+			r.Text = *p.Text
+		}
+		if p.From != nil {
+			r.From = *p.From
+		}
+		if p.To != nil {
+			r.To = *p.To
+		}
+
+		word, ok := s.wordFromRequest(context, *old, r)
+		if !ok {
+			return
+		}
+
+		updated, err := s.words.Update(userName(context), *word)
+		if err != nil {
+			context.AbortWithStatusJSON(wordErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, updated)
+	}
+}
+
+func (s *Server) removeWord() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		id := context.Param("id")
+		if err := s.words.Remove(userName(context), id); err != nil {
+			context.AbortWithStatusJSON(wordErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"id": id})
+	}
+}
+
+// wordFromRequest applies r on word, fetching new translation if needed.
+// On failure, it aborts context and returns false
+func (s *Server) wordFromRequest(context *gin.Context, word wordlist.Word, r wordRequest) (*wordlist.Word, bool) {
+	if len(r.Text) == 0 {
+		context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "request doesn't contain text"})
+		return nil, false
+	}
+
+	from, to, err := parseLangs(r.From, r.To)
+	if err != nil {
+		context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if word.Translation != nil && word.Text == r.Text && word.From == from && word.To == to {
+		return &word, true
+	}
+
+	translation, err := s.translation(context, r.Text, from, to, translator.Options{})
+	if err != nil {
+		Logger.Errorf("translate %s failed: %v", r.Text, err)
+		abortTranslation(context, err)
+		return nil, false
+	}
+
+	s.record(context, r.Text, translation)
+	word.Text, word.From, word.To, word.Translation = r.Text, from, to, translation
+	return &word, true
+}
+
+// parseLangs validates source and target language
+func parseLangs(from, to string) (deepl.SourceLang, deepl.TargetLang, error) {
+	src, err := deepl.ParseSourceLang(from)
+	if err != nil {
+		return "", "", err
+	}
+	dst, err := deepl.ParseTargetLang(to)
+	if err != nil {
+		return "", "", err
+	}
+	return src, dst, nil
+}
+
+// wordErrorCode maps errors from wordlist to http status code
+func wordErrorCode(err error) int {
+	switch {
+	case errors.Is(err, wordlist.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, wordlist.ErrInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
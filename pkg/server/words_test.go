@@ -0,0 +1,111 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/auth"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/a-clap/dictionary/pkg/wordlist"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServer_words(t *testing.T) {
+	translate := &fakeTranslate{translation: &translator.Translation{
+		Deepl: []translator.DeeplTranslate{{Text: "brain"}},
+	}}
+	s := newServer(&handler{
+		StoreTokener: auth.NewMemoryStore([]byte("key"), time.Hour),
+		Translate:    translate,
+		WordStore:    wordlist.NewMemoryStore(),
+	})
+	adam := login(t, s, "adam")
+	eve := login(t, s, "eve")
+
+	var word wordlist.Word
+	t.Run("add word", func(t *testing.T) {
+		response := serve(t, s, http.MethodPost, "/api/words", adam, `{"text": "mózg", "from": "PL", "to": "EN-GB"}`)
+		require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+		require.Nil(t, json.NewDecoder(response.Body).Decode(&word))
+		require.NotEmpty(t, word.ID)
+		require.Equal(t, "mózg", word.Text)
+		require.Equal(t, deepl.SrcPolish, word.From)
+		require.Equal(t, deepl.TarEnglishBritish, word.To)
+		require.Equal(t, translate.translation, word.Translation)
+	})
+
+	t.Run("add word errors", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, serve(t, s, http.MethodPost, "/api/words", "", `{"text": "mózg", "to": "EN-GB"}`).Code)
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, "/api/words", adam, `hello`).Code)
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, "/api/words", adam, `{"text": "", "to": "EN-GB"}`).Code)
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPost, "/api/words", adam, `{"text": "mózg", "to": "XX"}`).Code)
+	})
+
+	t.Run("list and get", func(t *testing.T) {
+		response := serve(t, s, http.MethodGet, "/api/words", adam, "")
+		require.Equal(t, http.StatusOK, response.Code)
+		var words []wordlist.Word
+		require.Nil(t, json.NewDecoder(response.Body).Decode(&words))
+		require.Len(t, words, 1)
+		require.Equal(t, word.ID, words[0].ID)
+
+		response = serve(t, s, http.MethodGet, "/api/words/"+word.ID, adam, "")
+		require.Equal(t, http.StatusOK, response.Code)
+		require.Contains(t, response.Body.String(), word.ID)
+
+		response = serve(t, s, http.MethodGet, "/api/words", eve, "")
+		require.Equal(t, http.StatusOK, response.Code)
+		require.Equal(t, "[]", response.Body.String())
+
+		require.Equal(t, http.StatusNotFound, serve(t, s, http.MethodGet, "/api/words/"+word.ID, eve, "").Code)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		translate.translation = &translator.Translation{
+			Deepl: []translator.DeeplTranslate{{Text: "Gehirn"}},
+		}
+		response := serve(t, s, http.MethodPatch, "/api/words/"+word.ID, adam, `{"to": "DE"}`)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		var updated wordlist.Word
+		require.Nil(t, json.NewDecoder(response.Body).Decode(&updated))
+		require.Equal(t, word.ID, updated.ID)
+		require.Equal(t, "mózg", updated.Text)
+		require.Equal(t, deepl.TarGerman, updated.To)
+		require.Equal(t, "Gehirn", updated.Translation.Deepl[0].Text)
+
+		require.Equal(t, http.StatusNotFound, serve(t, s, http.MethodPatch, "/api/words/"+word.ID, eve, `{"to": "DE"}`).Code)
+	})
+
+	t.Run("update doesn't translate again, if nothing changed", func(t *testing.T) {
+		translate.err = fmt.Errorf("shouldn't be called")
+		defer func() { translate.err = nil }()
+
+		response := serve(t, s, http.MethodPatch, "/api/words/"+word.ID, adam, `{}`)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	})
+
+	t.Run("update clears source language", func(t *testing.T) {
+		response := serve(t, s, http.MethodPatch, "/api/words/"+word.ID, adam, `{"from": ""}`)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		var updated wordlist.Word
+		require.Nil(t, json.NewDecoder(response.Body).Decode(&updated))
+		require.Empty(t, updated.From)
+		require.Equal(t, "mózg", updated.Text)
+		require.Equal(t, deepl.TarGerman, updated.To)
+
+		require.Equal(t, http.StatusBadRequest, serve(t, s, http.MethodPatch, "/api/words/"+word.ID, adam, `{"text": ""}`).Code)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, serve(t, s, http.MethodDelete, "/api/words/"+word.ID, eve, "").Code)
+		require.Equal(t, http.StatusOK, serve(t, s, http.MethodDelete, "/api/words/"+word.ID, adam, "").Code)
+		require.Equal(t, http.StatusNotFound, serve(t, s, http.MethodGet, "/api/words/"+word.ID, adam, "").Code)
+	})
+}
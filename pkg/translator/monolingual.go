@@ -0,0 +1,202 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/merriamw/dictionary"
+	"github.com/a-clap/dictionary/internal/merriamw/sense"
+	"github.com/a-clap/dictionary/internal/merriamw/thesaurus"
+	"io"
+	"strings"
+	"sync"
+)
+
+var (
+	_ Monolingual = &MerriamWebster{}
+	_ Monolingual = &Local{}
+)
+
+// Monolingual describes texts in single language
+type Monolingual interface {
+	// Define returns definitions of text. Text not found in dictionary is not an error, dictionary may suggest other spellings instead
+	Define(ctx context.Context, text string) (dict DictionaryTranslate, suggestions []string, err error)
+	// Thesaurus returns thesaurus entries of text, nil if there are none
+	Thesaurus(ctx context.Context, text string) ([]ThesaurusTranslate, error)
+}
+
+// MerriamWebster is Monolingual for english, using Merriam-Webster dictionary and thesaurus
+type MerriamWebster struct {
+	dict      *dictionary.Dictionary
+	thesaurus *thesaurus.Thesaurus
+}
+
+// NewMerriamWebster is default constructor for MerriamWebster
+func NewMerriamWebster(dict *dictionary.Dictionary, th *thesaurus.Thesaurus) *MerriamWebster {
+	return &MerriamWebster{dict: dict, thesaurus: th}
+}
+
+// Define fulfills Monolingual interface, other entries found in dictionary are returned as synonyms
+func (m *MerriamWebster) Define(ctx context.Context, text string) (DictionaryTranslate, []string, error) {
+	d, suggestions, err := m.dict.Definition(ctx, text)
+	if err != nil {
+		Logger.Debugf("definition not found")
+		return DictionaryTranslate{}, nil, err
+	}
+	if suggestions != nil {
+		Logger.Debugf("%s not found, dictionary suggests %v", text, suggestions.Suggestions)
+		return DictionaryTranslate{}, suggestions.Suggestions, nil
+	}
+
+	var (
+		defs     []Definition
+		synonyms []string
+	)
+	for _, dict := range d {
+		Logger.Debugf("definition for %s is %s", text, dict.Text())
+		if dict.Text() != text {
+			Logger.Debugf("skipping definition as it is not equal text, adding as synonym")
+			synonyms = append(synonyms, dict.Text())
+			continue
+		}
+		defs = append(defs, Definition{
+			Offensive:   dict.IsOffensive(),
+			Function:    dict.Function(),
+			Examples:    dict.Examples(),
+			Definition:  dict.Definition(),
+			Audio:       dict.Audio(),
+			Senses:      dict.Senses().Format(sense.Plain),
+			Etymology:   plain(dict.Etymology()),
+			FirstUse:    sense.Plain(dict.FirstUse()),
+			RunOns:      dict.RunOns(),
+			Inflections: dict.Inflections(),
+			Stems:       dict.Stems(),
+		})
+	}
+	return DictionaryTranslate{Defs: defs, Synonyms: synonyms}, nil, nil
+}
+
+// plain strips MW markup from every text
+func plain(texts []string) []string {
+	stripped := make([]string, len(texts))
+	for i, elem := range texts {
+		stripped[i] = sense.Plain(elem)
+	}
+	return stripped
+}
+
+// Thesaurus fulfills Monolingual interface, every entry matching text is returned, e.g. noun and verb
+func (m *MerriamWebster) Thesaurus(ctx context.Context, text string) ([]ThesaurusTranslate, error) {
+	data, err := m.thesaurus.Translate(ctx, text)
+	if err != nil {
+		Logger.Debugf("thesaurus not found for text %s", text)
+		return nil, err
+	}
+
+	var entries []ThesaurusTranslate
+	for _, elem := range data {
+		if elem.Text() != text {
+			continue
+		}
+
+		t := ThesaurusTranslate{
+			Text:      elem.Text(),
+			Offensive: elem.IsOffensive(),
+			Function:  elem.Function(),
+			Senses:    []ThesaurusSense{},
+		}
+		for _, th := range elem.Senses() {
+			t.Senses = append(t.Senses, thesaurusSense(th))
+		}
+		entries = append(entries, t)
+	}
+	return entries, nil
+}
+
+// thesaurusSense converts s, defining text is used, when s has no short definition
+func thesaurusSense(s thesaurus.Sense) ThesaurusSense {
+	definition := s.Shortdef
+	if len(definition) == 0 {
+		definition = sense.Plain(s.Text)
+	}
+	var examples []string
+	if len(s.Examples) > 0 {
+		examples = plain(s.Examples)
+	}
+	return ThesaurusSense{
+		Definition:   definition,
+		Examples:     examples,
+		Synonyms:     s.Synonyms,
+		Antonyms:     s.Antonyms,
+		NearAntonyms: s.NearAntonyms,
+		Related:      s.Related,
+		Phrases:      s.Phrases,
+	}
+}
+
+// LocalEntry is everything, what Local knows about single text
+type LocalEntry struct {
+	Defs      []Definition         `json:"defs"`
+	Synonyms  []string             `json:"synonyms"`
+	Thesaurus []ThesaurusTranslate `json:"thesaurus"`
+}
+
+// Local is Monolingual keeping entries in memory. It stands in for languages, which don't have online dictionary
+type Local struct {
+	mtx     sync.RWMutex
+	entries map[string]LocalEntry
+}
+
+// NewLocal creates Local with entries indexed by text
+func NewLocal(entries map[string]LocalEntry) *Local {
+	l := &Local{entries: make(map[string]LocalEntry, len(entries))}
+	for text, e := range entries {
+		l.Add(text, e)
+	}
+	return l
+}
+
+// LoadLocal creates Local from JSON object, which maps texts to their LocalEntry
+func LoadLocal(r io.Reader) (*Local, error) {
+	var entries map[string]LocalEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("decode local dictionary: %w", err)
+	}
+	return NewLocal(entries), nil
+}
+
+// Add sets entry of text, case and surrounding spaces of text don't matter
+func (l *Local) Add(text string, e LocalEntry) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.entries[localKey(text)] = e
+}
+
+// Define fulfills Monolingual interface, Local never suggests other spellings
+func (l *Local) Define(ctx context.Context, text string) (DictionaryTranslate, []string, error) {
+	if err := ctx.Err(); err != nil {
+		return DictionaryTranslate{}, nil, err
+	}
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+	e := l.entries[localKey(text)]
+	return DictionaryTranslate{Defs: e.Defs, Synonyms: e.Synonyms}, nil, nil
+}
+
+// Thesaurus fulfills Monolingual interface
+func (l *Local) Thesaurus(ctx context.Context, text string) ([]ThesaurusTranslate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+	return l.entries[localKey(text)].Thesaurus, nil
+}
+
+func localKey(text string) string {
+	return strings.ToLower(strings.TrimSpace(text))
+}
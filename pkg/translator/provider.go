@@ -0,0 +1,336 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/internal/mymemory"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported language pair")
+	ErrNoProvider  = errors.New("no provider succeeded")
+	ErrRefused     = errors.New("translation refused")
+)
+
+// Lang is language code common for every Provider: uppercase ISO 639-1 code, optionally followed by region, e.g. "PL", "EN-GB".
+// Empty source Lang means auto-detection
+type Lang string
+
+// English is base of every variant of english, it is the side of translation, which Translator prefers to define
+const English Lang = "EN"
+
+// Base returns language without region, e.g. "EN" for "EN-GB"
+func (l Lang) Base() Lang {
+	base, _, _ := strings.Cut(string(l), "-")
+	return Lang(base)
+}
+
+// Provider translates text, returning possible translations.
+// Provider, which can't handle from-to pair, returns ErrUnsupported
+type Provider interface {
+	Name() Source
+	Translate(ctx context.Context, text string, from, to Lang, opts Options) ([]string, error)
+}
+
+// Alternative is another possible translation, which may be offered by translation memory
+type Alternative struct {
+	Text       string  `json:"text"`
+	Segment    string  `json:"segment"`
+	Match      float64 `json:"match"`
+	Quality    int     `json:"quality"`
+	UsageCount int     `json:"usage_count"`
+	Origin     string  `json:"origin"`
+	Provider   Source  `json:"provider"`
+}
+
+// Alternativer may be implemented by Provider, which can find alternative translations.
+// Alternatives are returned from the best one, unsupported pair gives ErrUnsupported
+type Alternativer interface {
+	Name() Source
+	Alternatives(ctx context.Context, text string, from, to Lang) ([]Alternative, error)
+}
+
+// alternativesTranslator may be implemented by Alternativer, which finds alternatives in the same upstream response
+// as translation. Then alternatives don't have to be asked for again, when it is the one, which translated text
+type alternativesTranslator interface {
+	translateAlternatives(ctx context.Context, text string, from, to Lang) ([]string, []Alternative, error)
+}
+
+// sourceDetector may be implemented by Provider, which reports source language it detected, when from is empty
+type sourceDetector interface {
+	translateDetect(ctx context.Context, text string, from, to Lang, opts Options) ([]string, Lang, error)
+}
+
+var (
+	_ Provider               = &DeepLProvider{}
+	_ sourceDetector         = &DeepLProvider{}
+	_ Provider               = &MyMemoryProvider{}
+	_ Alternativer           = &MyMemoryProvider{}
+	_ alternativesTranslator = &MyMemoryProvider{}
+)
+
+// DeepLProvider adapts deepl.DeepL to Provider
+type DeepLProvider struct {
+	d *deepl.DeepL
+}
+
+func NewDeepLProvider(d *deepl.DeepL) *DeepLProvider {
+	return &DeepLProvider{d: d}
+}
+
+func (p *DeepLProvider) Name() Source {
+	return SourceDeepL
+}
+
+// Translate uses opts.Glossary, if source language is known. DeepL requires it for glossaries
+func (p *DeepLProvider) Translate(ctx context.Context, text string, from, to Lang, opts Options) ([]string, error) {
+	texts, _, err := p.translateDetect(ctx, text, from, to, opts)
+	return texts, err
+}
+
+func (p *DeepLProvider) translateDetect(ctx context.Context, text string, from, to Lang, opts Options) ([]string, Lang, error) {
+	// DeepL doesn't distinguish regions of source language
+	src, err := deepl.ParseSourceLang(string(from.Base()))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	dst, err := deepl.ParseTargetLang(string(to))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+
+	var o deepl.Options
+	if len(src) > 0 {
+		o.GlossaryID = opts.Glossary
+	}
+	w, err := p.d.Translate(ctx, text, src, dst, o)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var detected Lang
+	if langs := w.SourceLang(); len(langs) > 0 {
+		detected = Lang(strings.ToUpper(langs[0]))
+	}
+	return w.Translation(), detected, nil
+}
+
+// MyMemoryProvider adapts mymemory.MyMemory to Provider
+type MyMemoryProvider struct {
+	m *mymemory.MyMemory
+}
+
+func NewMyMemoryProvider(m *mymemory.MyMemory) *MyMemoryProvider {
+	return &MyMemoryProvider{m: m}
+}
+
+func (p *MyMemoryProvider) Name() Source {
+	return SourceMyMemory
+}
+
+// Translate ignores opts, MyMemory doesn't have equivalent of any of them
+func (p *MyMemoryProvider) Translate(ctx context.Context, text string, from, to Lang, _ Options) ([]string, error) {
+	w, err := p.translate(ctx, text, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return []string{w.Translated()}, nil
+}
+
+func (p *MyMemoryProvider) Alternatives(ctx context.Context, text string, from, to Lang) ([]Alternative, error) {
+	w, err := p.translate(ctx, text, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return alternativesOf(w), nil
+}
+
+func (p *MyMemoryProvider) translateAlternatives(ctx context.Context, text string, from, to Lang) ([]string, []Alternative, error) {
+	w, err := p.translate(ctx, text, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []string{w.Translated()}, alternativesOf(w), nil
+}
+
+// alternativesOf converts matches of w to Alternatives
+func alternativesOf(w *mymemory.Word) []Alternative {
+	matches := w.Alternatives()
+	alt := make([]Alternative, len(matches))
+	for i, m := range matches {
+		alt[i] = Alternative{
+			Text:       m.Translation,
+			Segment:    m.Text,
+			Match:      m.Match,
+			Quality:    m.Quality,
+			UsageCount: m.UsageCount,
+			Origin:     m.Source,
+			Provider:   SourceMyMemory,
+		}
+	}
+	return alt
+}
+
+func (p *MyMemoryProvider) translate(ctx context.Context, text string, from, to Lang) (*mymemory.Word, error) {
+	src := mymemory.Autodetect
+	if len(from) > 0 {
+		var err error
+		if src, err = mymemory.ParseLanguage(string(from)); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+		}
+	}
+	dst, err := mymemory.ParseLanguage(string(to))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+
+	w, err := p.m.Translate(ctx, text, src, dst)
+	if err != nil {
+		return nil, err
+	}
+	if w.QuotaFinished {
+		return nil, fmt.Errorf("%w: quota finished: %s", mymemory.ErrRateLimited, w.ResponseDetails)
+	}
+	if len(w.Translated()) == 0 {
+		return nil, fmt.Errorf("empty translation: %s", w.ResponseDetails)
+	}
+	return w, nil
+}
+
+// answer is result of translate
+type answer struct {
+	// provider, which translated text
+	provider Provider
+	texts    []string
+	// alternatives found by provider together with texts, valid only if hasAlternatives is true
+	alternatives    []Alternative
+	hasAlternatives bool
+	// detected is source language detected by provider, empty if it doesn't tell
+	detected Lang
+}
+
+// translate asks providers in order, returning answer of first one, which succeeded.
+// Failures of providers asked before are returned as well.
+// When every provider fails, returned error matches ErrNoProvider and unwraps to the most relevant failure
+func translate(ctx context.Context, providers []Provider, text string, from, to Lang, opts Options) (answer, []SourceError, error) {
+	var (
+		failures []SourceError
+		// cause is error of the first provider, which supports from-to pair
+		cause error
+	)
+	for _, p := range providers {
+		a, err := translateWith(ctx, p, text, from, to, opts)
+		if err == nil {
+			return a, failures, nil
+		}
+		if cause == nil && !errors.Is(err, ErrUnsupported) {
+			cause = err
+		}
+
+		Logger.Debugf("provider %s failed: %v", p.Name(), err)
+		failures = append(failures, SourceError{Source: p.Name(), Text: text, Error: err.Error()})
+		// Next provider won't do better
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return answer{}, failures, ctxErr
+		}
+		if errors.Is(err, ErrRefused) {
+			return answer{}, failures, err
+		}
+	}
+
+	msgs := make([]string, len(failures))
+	for i, f := range failures {
+		msgs[i] = fmt.Sprintf("%s: %s", f.Source, f.Error)
+	}
+	return answer{}, failures, &noProviderError{msg: strings.Join(msgs, ", "), err: cause}
+}
+
+// translateWith translates text with p, taking alternatives or detected source language from the same response, if p is able to
+func translateWith(ctx context.Context, p Provider, text string, from, to Lang, opts Options) (answer, error) {
+	if t, ok := p.(alternativesTranslator); ok {
+		texts, alt, err := t.translateAlternatives(ctx, text, from, to)
+		return answer{provider: p, texts: texts, alternatives: alt, hasAlternatives: true}, err
+	}
+	if d, ok := p.(sourceDetector); ok {
+		texts, detected, err := d.translateDetect(ctx, text, from, to, opts)
+		return answer{provider: p, texts: texts, detected: detected}, err
+	}
+	texts, err := p.Translate(ctx, text, from, to, opts)
+	return answer{provider: p, texts: texts}, err
+}
+
+// noProviderError matches ErrNoProvider, while it unwraps to cause of failure, e.g. deepl.ErrAuth
+type noProviderError struct {
+	msg string
+	err error
+}
+
+func (e *noProviderError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrNoProvider, e.msg)
+}
+
+func (e *noProviderError) Is(target error) bool {
+	return target == ErrNoProvider
+}
+
+func (e *noProviderError) Unwrap() error {
+	return e.err
+}
+
+// alternatives asks every provider implementing Alternativer for alternatives of text,
+// except provider of translated, which already found them.
+// Returned alternatives are ranked by match score, skipping the ones equal to translations
+func alternatives(ctx context.Context, providers []Provider, text string, from, to Lang, translated answer) ([]Alternative, []SourceError) {
+	seen := make(map[string]struct{})
+	for _, t := range translated.texts {
+		seen[strings.ToLower(t)] = struct{}{}
+	}
+
+	var (
+		alt      []Alternative
+		failures []SourceError
+	)
+	add := func(found []Alternative) {
+		for _, elem := range found {
+			key := strings.ToLower(elem.Text)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			alt = append(alt, elem)
+		}
+	}
+
+	for _, p := range providers {
+		a, ok := p.(Alternativer)
+		if !ok {
+			continue
+		}
+		if p == translated.provider && translated.hasAlternatives {
+			add(translated.alternatives)
+			continue
+		}
+
+		found, err := a.Alternatives(ctx, text, from, to)
+		if err != nil {
+			if !errors.Is(err, ErrUnsupported) {
+				failures = append(failures, SourceError{Source: a.Name(), Text: text, Error: err.Error()})
+			}
+			continue
+		}
+		add(found)
+	}
+
+	sort.SliceStable(alt, func(i, j int) bool {
+		return alt[i].Match > alt[j].Match
+	})
+	return alt, failures
+}
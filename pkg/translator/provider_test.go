@@ -0,0 +1,308 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package translator_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/internal/mymemory"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// fakeProvider returns texts or err, remembering languages it was asked for
+type fakeProvider struct {
+	name     translator.Source
+	texts    []string
+	err      error
+	calls    int
+	from, to translator.Lang
+}
+
+func (f *fakeProvider) Name() translator.Source {
+	return f.name
+}
+
+func (f *fakeProvider) Translate(_ context.Context, _ string, from, to translator.Lang, _ translator.Options) ([]string, error) {
+	f.calls++
+	f.from, f.to = from, to
+	return f.texts, f.err
+}
+
+type fakeGetWord struct {
+	response string
+	from, to mymemory.Language
+	calls    int
+}
+
+func (f *fakeGetWord) Get(_ context.Context, _ string, from, to mymemory.Language) ([]byte, error) {
+	f.calls++
+	f.from, f.to = from, to
+	return []byte(f.response), nil
+}
+
+// newChain returns Translator without dictionaries, so only providers are asked
+func newChain(providers ...translator.Provider) *translator.Translator {
+	return translator.NewStandardWithDictionaries(providers, nil, translator.Limits{})
+}
+
+func TestStandard_Get_fallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []*fakeProvider
+		want      translator.Source
+		texts     []string
+		errs      []translator.Source
+		err       error
+	}{
+		{
+			name: "first provider",
+			providers: []*fakeProvider{
+				{name: translator.SourceDeepL, texts: []string{"mózg"}},
+				{name: translator.SourceMyMemory, texts: []string{"umysł"}},
+			},
+			want:  translator.SourceDeepL,
+			texts: []string{"mózg"},
+		},
+		{
+			name: "fallback",
+			providers: []*fakeProvider{
+				{name: translator.SourceDeepL, err: fmt.Errorf("quota exceeded")},
+				{name: translator.SourceMyMemory, texts: []string{"umysł"}},
+			},
+			want:  translator.SourceMyMemory,
+			texts: []string{"umysł"},
+			errs:  []translator.Source{translator.SourceDeepL},
+		},
+		{
+			name: "every provider failed",
+			providers: []*fakeProvider{
+				{name: translator.SourceDeepL, err: fmt.Errorf("quota exceeded")},
+				{name: translator.SourceMyMemory, err: translator.ErrUnsupported},
+			},
+			err: translator.ErrNoProvider,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := make([]translator.Provider, len(tt.providers))
+			for i, p := range tt.providers {
+				providers[i] = p
+			}
+
+			got, err := newChain(providers...).Get(context.Background(), "brain", deepl.SrcEnglish, deepl.TarPolish, translator.Options{})
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				require.Contains(t, err.Error(), "quota exceeded")
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got.Provider)
+			require.Len(t, got.Deepl, len(tt.texts))
+			for i, text := range tt.texts {
+				require.Equal(t, text, got.Deepl[i].Text)
+			}
+			require.Len(t, got.Errors, len(tt.errs))
+			for i, source := range tt.errs {
+				require.Equal(t, source, got.Errors[i].Source)
+			}
+			// Providers after the successful one are not asked
+			for _, p := range tt.providers[len(tt.errs)+1:] {
+				require.Zero(t, p.calls)
+			}
+			require.Equal(t, translator.Lang("EN"), tt.providers[0].from)
+			require.Equal(t, translator.Lang("PL"), tt.providers[0].to)
+		})
+	}
+}
+
+func TestStandard_Get_cause(t *testing.T) {
+	first := &fakeProvider{name: translator.SourceDeepL, err: translator.ErrUnsupported}
+	second := &fakeProvider{name: translator.SourceDeepL, err: fmt.Errorf("on query %w", deepl.ErrAuth)}
+	third := &fakeProvider{name: translator.SourceMyMemory, err: fmt.Errorf("quota finished")}
+
+	_, err := newChain(first, second, third).Get(context.Background(), "brain", deepl.SrcEnglish, deepl.TarPolish, translator.Options{})
+	require.ErrorIs(t, err, translator.ErrNoProvider)
+	require.ErrorIs(t, err, deepl.ErrAuth)
+	require.Contains(t, err.Error(), "quota finished")
+}
+
+func TestStandard_Get_cancelledNoFallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	first := &fakeProvider{name: translator.SourceDeepL, err: fmt.Errorf("cancelled")}
+	second := &fakeProvider{name: translator.SourceMyMemory, texts: []string{"mózg"}}
+	cancel()
+
+	_, err := newChain(first, second).Get(ctx, "brain", deepl.SrcEnglish, deepl.TarPolish, translator.Options{})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Zero(t, second.calls)
+}
+
+func TestMyMemoryProvider_Translate(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		from, to translator.Lang
+		src, dst mymemory.Language
+		want     []string
+		err      error
+	}{
+		{
+			name:     "english to polish",
+			response: `{"responseData":{"translatedText":"mózg"},"responseStatus":200}`,
+			from:     "EN",
+			to:       "PL",
+			src:      mymemory.English,
+			dst:      mymemory.Polish,
+			want:     []string{"mózg"},
+		},
+		{
+			name:     "polish to british english",
+			response: `{"responseData":{"translatedText":"brain"},"responseStatus":200}`,
+			from:     "PL",
+			to:       "EN-GB",
+			src:      mymemory.Polish,
+			dst:      mymemory.EnglishBritish,
+			want:     []string{"brain"},
+		},
+		{
+			name:     "autodetect to chinese",
+			response: `{"responseData":{"translatedText":"脑"},"responseStatus":200}`,
+			from:     "",
+			to:       "ZH",
+			src:      mymemory.Autodetect,
+			dst:      mymemory.Chinese,
+			want:     []string{"脑"},
+		},
+		{
+			name: "unsupported language",
+			from: "XX",
+			to:   "PL",
+			err:  translator.ErrUnsupported,
+		},
+		{
+			name:     "quota finished",
+			response: `{"responseData":{"translatedText":"MYMEMORY WARNING"},"quotaFinished":true,"responseStatus":200}`,
+			from:     "EN",
+			to:       "PL",
+			err:      mymemory.ErrRateLimited,
+		},
+		{
+			name:     "rate limited",
+			response: `{"responseData":{"translatedText":"MYMEMORY WARNING: YOU USED ALL AVAILABLE FREE TRANSLATIONS"},"responseStatus":429}`,
+			from:     "EN",
+			to:       "PL",
+			err:      mymemory.ErrRateLimited,
+		},
+		{
+			name:     "error as translation",
+			response: `{"responseData":{"translatedText":"INVALID LANGUAGE PAIR"},"responseStatus":"403","responseDetails":"INVALID LANGUAGE PAIR"}`,
+			from:     "EN",
+			to:       "PL",
+			err:      mymemory.ErrUpstream,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &fakeGetWord{response: tt.response}
+			p := translator.NewMyMemoryProvider(mymemory.NewMyMemory(g))
+			require.Equal(t, translator.SourceMyMemory, p.Name())
+
+			got, err := p.Translate(context.Background(), "text", tt.from, tt.to, translator.Options{})
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.src, g.from)
+			require.Equal(t, tt.dst, g.to)
+		})
+	}
+}
+
+func TestDeepLProvider_Translate(t *testing.T) {
+	p := translator.NewDeepLProvider(deepl.NewDeepL(&fakeDeepler{texts: []string{"brain"}}))
+	require.Equal(t, translator.SourceDeepL, p.Name())
+
+	got, err := p.Translate(context.Background(), "mózg", "PL", "EN-GB", translator.Options{})
+	require.Nil(t, err)
+	require.Equal(t, []string{"brain"}, got)
+
+	// DeepL requires variant of english as target
+	_, err = p.Translate(context.Background(), "mózg", "PL", "EN", translator.Options{})
+	require.ErrorIs(t, err, translator.ErrUnsupported)
+}
+
+func TestStandard_Get_alternatives(t *testing.T) {
+	response := `{
+		"responseData": {"translatedText": "mózg", "match": 0.99},
+		"responseStatus": 200,
+		"matches": [
+			{"segment": "brain", "translation": "Mózg", "match": 0.99},
+			{"segment": "brain", "translation": "umysł", "quality": "74", "match": 0.98},
+			{"segment": "brains", "translation": "mózgi", "match": 0.5}
+		]
+	}`
+	tests := []struct {
+		name         string
+		from         deepl.SourceLang
+		to           deepl.TargetLang
+		getWord      mymemory.GetWord
+		alternatives []string
+		errs         int
+	}{
+		{
+			name:         "alternatives without primary translation",
+			from:         deepl.SrcEnglish,
+			to:           deepl.TarPolish,
+			getWord:      &fakeGetWord{response: response},
+			alternatives: []string{"umysł", "mózgi"},
+		},
+		{
+			name:    "failure is reported",
+			from:    deepl.SrcEnglish,
+			to:      deepl.TarPolish,
+			getWord: &fakeGetWord{response: `{"quotaFinished": true, "responseStatus": 200}`},
+			errs:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newChain(
+				translator.NewDeepLProvider(deepl.NewDeepL(&fakeDeepler{texts: []string{"mózg"}})),
+				translator.NewMyMemoryProvider(mymemory.NewMyMemory(tt.getWord)),
+			)
+			got, err := tr.Get(context.Background(), "brain", tt.from, tt.to, translator.Options{})
+			require.Nil(t, err)
+			require.Equal(t, translator.SourceDeepL, got.Provider)
+
+			texts := make([]string, len(got.Alternatives))
+			for i, a := range got.Alternatives {
+				texts[i] = a.Text
+				require.Equal(t, translator.SourceMyMemory, a.Provider)
+			}
+			// Ranked by match score
+			require.Equal(t, len(tt.alternatives), len(texts))
+			for i := range tt.alternatives {
+				require.Equal(t, tt.alternatives[i], texts[i])
+			}
+			require.Len(t, got.Errors, tt.errs)
+		})
+	}
+
+	t.Run("alternatives of translating provider are reused", func(t *testing.T) {
+		g := &fakeGetWord{response: response}
+		tr := newChain(translator.NewMyMemoryProvider(mymemory.NewMyMemory(g)))
+		got, err := tr.Get(context.Background(), "brain", deepl.SrcEnglish, deepl.TarPolish, translator.Options{})
+		require.Nil(t, err)
+		require.Equal(t, translator.SourceMyMemory, got.Provider)
+		require.Len(t, got.Alternatives, 2)
+		require.Equal(t, "umysł", got.Alternatives[0].Text)
+		require.Equal(t, 1, g.calls)
+	})
+}
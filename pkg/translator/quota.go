@@ -0,0 +1,87 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package translator
+
+import (
+	"context"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"unicode/utf8"
+)
+
+// Quota limits number of characters, which user can send to DeepL, satisfied by usage.Tracker
+type Quota interface {
+	// Reserve counts chars characters, which user is about to send. If user can't send them, returns error
+	// and nothing is counted. Check and count must be atomic, so concurrent requests don't exceed Quota
+	Reserve(user string, chars int) error
+	// Refund gives back chars characters reserved by user, which weren't sent after all
+	Refund(user string, chars int) error
+}
+
+// QuotaMode tells, what happens when translation would exceed Quota
+type QuotaMode int
+
+const (
+	// QuotaFallback skips provider, so the next one translates text
+	QuotaFallback QuotaMode = iota
+	// QuotaRefuse fails whole translation with ErrRefused
+	QuotaRefuse
+)
+
+var _ deepl.Deepler = &QuotaDeepler{}
+
+// QuotaDeepler counts characters sent to DeepL against Quota of Options.User, passed by Translator in context.
+// It belongs below cache (e.g. cache.NewDeepler(NewQuotaDeepler(...), c)), so cached translations are free
+type QuotaDeepler struct {
+	d    deepl.Deepler
+	q    Quota
+	mode QuotaMode
+}
+
+func NewQuotaDeepler(d deepl.Deepler, q Quota, mode QuotaMode) *QuotaDeepler {
+	return &QuotaDeepler{
+		d:    d,
+		q:    q,
+		mode: mode,
+	}
+}
+
+// Query reserves characters of texts before asking DeepL, they are refunded when query fails
+func (q *QuotaDeepler) Query(ctx context.Context, texts []string, sourceLang deepl.SourceLang, targetLang deepl.TargetLang, opts deepl.Options) ([]byte, error) {
+	user := userFrom(ctx)
+	chars := 0
+	for _, text := range texts {
+		chars += utf8.RuneCountInString(text)
+	}
+
+	if err := q.q.Reserve(user, chars); err != nil {
+		if q.mode == QuotaRefuse {
+			return nil, fmt.Errorf("%w: %v", ErrRefused, err)
+		}
+		return nil, err
+	}
+
+	data, err := q.d.Query(ctx, texts, sourceLang, targetLang, opts)
+	if err != nil {
+		if refundErr := q.q.Refund(user, chars); refundErr != nil {
+			Logger.Errorf("failed to refund %d characters of %s: %v", chars, user, refundErr)
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+type userKey struct{}
+
+// withUser returns ctx carrying Options.User down to QuotaDeepler
+func withUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// userFrom returns user stored by withUser, "" if there is none
+func userFrom(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
@@ -0,0 +1,126 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package translator_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/cache"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// fakeQuota allows limit characters per user
+type fakeQuota struct {
+	limit int
+	used  map[string]int
+}
+
+func (f *fakeQuota) Reserve(user string, chars int) error {
+	if f.used[user]+chars > f.limit {
+		return fmt.Errorf("quota exceeded by %s", user)
+	}
+	f.used[user] += chars
+	return nil
+}
+
+func (f *fakeQuota) Refund(user string, chars int) error {
+	f.used[user] -= chars
+	return nil
+}
+
+// countingDeepler counts queries, which reached it
+type countingDeepler struct {
+	fakeDeepler
+	calls int
+	err   error
+}
+
+func (c *countingDeepler) Query(ctx context.Context, texts []string, src deepl.SourceLang, dst deepl.TargetLang, opts deepl.Options) ([]byte, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.fakeDeepler.Query(ctx, texts, src, dst, opts)
+}
+
+func TestQuotaDeepler(t *testing.T) {
+	tests := []struct {
+		name string
+		mode translator.QuotaMode
+		want translator.Source
+		err  error
+	}{
+		{
+			name: "fallback",
+			mode: translator.QuotaFallback,
+			want: translator.SourceMyMemory,
+		},
+		{
+			name: "refuse",
+			mode: translator.QuotaRefuse,
+			err:  translator.ErrRefused,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			q := &fakeQuota{limit: 6, used: map[string]int{}}
+			d := &countingDeepler{fakeDeepler: fakeDeepler{texts: []string{"brain"}}}
+			c := cache.New(cache.NewLRU(100, time.Hour))
+			first := translator.NewDeepLProvider(deepl.NewDeepL(cache.NewDeepler(translator.NewQuotaDeepler(d, q, tt.mode), c)))
+			second := &fakeProvider{name: translator.SourceMyMemory, texts: []string{"mind"}}
+			chain := newChain(first, second)
+
+			// Characters, not bytes, are counted
+			got, err := chain.Get(ctx, "mózg", deepl.SrcPolish, deepl.TarEnglishBritish, translator.Options{User: "adam"})
+			require.Nil(t, err)
+			require.Equal(t, translator.SourceDeepL, got.Provider)
+			require.Equal(t, 4, q.used["adam"])
+
+			// Cached translation is neither counted nor refused
+			got, err = chain.Get(ctx, "mózg", deepl.SrcPolish, deepl.TarEnglishBritish, translator.Options{User: "adam"})
+			require.Nil(t, err)
+			require.Equal(t, translator.SourceDeepL, got.Provider)
+			require.Equal(t, 1, d.calls)
+			require.Equal(t, 4, q.used["adam"])
+
+			got, err = chain.Get(ctx, "umysł", deepl.SrcPolish, deepl.TarEnglishBritish, translator.Options{User: "adam"})
+			require.Equal(t, 1, d.calls)
+			require.Equal(t, 4, q.used["adam"])
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				require.Contains(t, err.Error(), "quota exceeded by adam")
+				require.Zero(t, second.calls)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got.Provider)
+			require.Equal(t, translator.SourceDeepL, got.Errors[0].Source)
+			require.Contains(t, got.Errors[0].Error, "quota exceeded by adam")
+
+			// Other users have their own quota
+			got, err = chain.Get(ctx, "umysł", deepl.SrcPolish, deepl.TarEnglishBritish, translator.Options{User: "eve"})
+			require.Nil(t, err)
+			require.Equal(t, translator.SourceDeepL, got.Provider)
+			require.Equal(t, 5, q.used["eve"])
+		})
+	}
+}
+
+func TestQuotaDeepler_failedRefunded(t *testing.T) {
+	q := &fakeQuota{limit: 6, used: map[string]int{}}
+	d := &countingDeepler{err: fmt.Errorf("deepl is down")}
+	p := translator.NewDeepLProvider(deepl.NewDeepL(translator.NewQuotaDeepler(d, q, translator.QuotaRefuse)))
+
+	_, err := newChain(p).Get(context.Background(), "mózg", deepl.SrcPolish, deepl.TarEnglishBritish, translator.Options{User: "adam"})
+	require.NotNil(t, err)
+	require.NotErrorIs(t, err, translator.ErrRefused)
+	require.Equal(t, 1, d.calls)
+	require.Zero(t, q.used["adam"])
+}
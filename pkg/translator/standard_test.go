@@ -0,0 +1,427 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package translator_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/internal/merriamw/dictionary"
+	"github.com/a-clap/dictionary/internal/merriamw/thesaurus"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeDeepler struct {
+	texts []string
+	// detected is reported as detected_source_language, if not empty
+	detected string
+}
+
+func (f *fakeDeepler) Query(ctx context.Context, _ []string, _ deepl.SourceLang, _ deepl.TargetLang, _ deepl.Options) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	resp := `{"translations":[`
+	for i, text := range f.texts {
+		if i > 0 {
+			resp += ","
+		}
+		if len(f.detected) > 0 {
+			resp += fmt.Sprintf(`{"detected_source_language":"%s","text":"%s"}`, f.detected, text)
+			continue
+		}
+		resp += fmt.Sprintf(`{"text":"%s"}`, text)
+	}
+	return []byte(resp + "]}"), nil
+}
+
+// gauge keeps track of concurrent calls
+type gauge struct {
+	mtx     sync.Mutex
+	running int
+	max     int
+}
+
+func (g *gauge) add(delta int) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	g.running += delta
+	if g.running > g.max {
+		g.max = g.running
+	}
+}
+
+// upstream responds to dictionary and thesaurus queries after delay
+type upstream struct {
+	delay     time.Duration
+	responses map[string]string
+	errs      map[string]error
+	gauge
+	// shared is optional gauge common for many upstreams
+	shared *gauge
+}
+
+func (u *upstream) Get(ctx context.Context, text string) ([]byte, error) {
+	u.add(1)
+	if u.shared != nil {
+		u.shared.add(1)
+	}
+	defer func() {
+		u.add(-1)
+		if u.shared != nil {
+			u.shared.add(-1)
+		}
+	}()
+
+	select {
+	case <-time.After(u.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if err := u.errs[text]; err != nil {
+		return nil, err
+	}
+	return []byte(u.responses[text]), nil
+}
+
+func newStandard(texts []string, dict, th *upstream, limits translator.Limits) *translator.Translator {
+	return translator.NewStandardWith(
+		[]translator.Provider{translator.NewDeepLProvider(deepl.NewDeepL(&fakeDeepler{texts: texts}))},
+		dictionary.NewDictionary(dict),
+		thesaurus.NewThesaurus(th),
+		limits,
+	)
+}
+
+func TestStandard_Get(t *testing.T) {
+	shared := &gauge{}
+	dict := &upstream{
+		shared: shared,
+		delay:  20 * time.Millisecond,
+		responses: map[string]string{
+			"brain": `[{"meta":{"id":"brain:1","stems":["brain","brains"]},"fl":"noun","shortdef":["organ"],
+				"ins":[{"if":"brains"}],"uros":[{"ure":"brain*less","fl":"adjective"}],
+				"et":[["text","Middle English, from Old English {it}brægen{/it}"]],"date":"before 12th century{ds||1||}"}]`,
+			"mind": `[{"meta":{"id":"mind:1"},"fl":"noun","shortdef":["memory"]}, {"meta":{"id":"mind-set"}}]`,
+		},
+		errs: map[string]error{
+			"head": fmt.Errorf("dictionary is down"),
+		},
+	}
+	th := &upstream{
+		shared: shared,
+		delay:  20 * time.Millisecond,
+		responses: map[string]string{
+			"brain": `[{"meta":{"id":"brain","syns":[["mind"]],"ants":[["fool"]]},"fl":"noun"}]`,
+			"mind":  `[{"meta":{"id":"mind","syns":[["brain"]]},"fl":"noun"}]`,
+			"head":  `[{"meta":{"id":"head"},"fl":"noun"}]`,
+		},
+	}
+
+	tr := newStandard([]string{"brain", "mind", "head"}, dict, th, translator.Limits{Workers: 2})
+	got, err := tr.Get(context.Background(), "mózg", deepl.SrcPolish, deepl.TarEnglishBritish, translator.Options{})
+	require.Nil(t, err)
+
+	// Results keep order of DeepL translations
+	require.Equal(t, []translator.Definition{
+		{
+			Function:    "noun",
+			Definition:  []string{"organ"},
+			Examples:    []string{},
+			Audio:       []dictionary.Pronunciation{},
+			Etymology:   []string{"Middle English, from Old English brægen"},
+			FirstUse:    "before 12th century",
+			RunOns:      []dictionary.RunOn{{Word: "brainless", Function: "adjective"}},
+			Inflections: []string{"brains"},
+			Stems:       []string{"brain", "brains"},
+		},
+		{
+			Function:    "noun",
+			Definition:  []string{"memory"},
+			Examples:    []string{},
+			Audio:       []dictionary.Pronunciation{},
+			Etymology:   []string{},
+			RunOns:      []dictionary.RunOn{},
+			Inflections: []string{},
+		},
+	}, got.Dictionary.Defs)
+	require.Equal(t, []string{"mind-set"}, got.Dictionary.Synonyms)
+	require.Len(t, got.Thesaurus, 3)
+	require.Equal(t, "brain", got.Thesaurus[0].Text)
+	require.Equal(t, []translator.ThesaurusSense{{Synonyms: []string{"mind"}, Antonyms: []string{"fool"}}}, got.Thesaurus[0].Senses)
+	require.Equal(t, "mind", got.Thesaurus[1].Text)
+	require.Equal(t, "head", got.Thesaurus[2].Text)
+
+	// Failed lookup is reported, other ones are still there
+	require.Len(t, got.Errors, 1)
+	require.Equal(t, translator.SourceDictionary, got.Errors[0].Source)
+	require.Equal(t, "head", got.Errors[0].Text)
+	require.Contains(t, got.Errors[0].Error, "dictionary is down")
+
+	// Both upstreams share the pool
+	require.Equal(t, 2, shared.max)
+}
+
+func TestStandard_Get_concurrent(t *testing.T) {
+	dict := &upstream{delay: 50 * time.Millisecond}
+	th := &upstream{delay: 50 * time.Millisecond}
+	tr := newStandard([]string{"a", "b", "c", "d"}, dict, th, translator.Limits{Workers: 8})
+
+	start := time.Now()
+	_, err := tr.Get(context.Background(), "x", deepl.SrcPolish, deepl.TarEnglishAmerican, translator.Options{})
+	require.Nil(t, err)
+	// Serially it would take 8 * delay
+	require.Less(t, time.Since(start), 200*time.Millisecond)
+	require.Greater(t, dict.max, 1)
+	require.Greater(t, th.max, 1)
+}
+
+func TestStandard_Get_timeout(t *testing.T) {
+	dict := &upstream{
+		delay:     time.Second,
+		responses: map[string]string{"brain": `[{"meta":{"id":"brain"},"fl":"noun","shortdef":["organ"]}]`},
+	}
+	th := &upstream{
+		responses: map[string]string{"brain": `[{"meta":{"id":"brain"},"fl":"noun"}]`},
+	}
+	tr := newStandard([]string{"brain"}, dict, th, translator.Limits{Timeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	got, err := tr.Get(context.Background(), "mózg", deepl.SrcPolish, deepl.TarEnglishAmerican, translator.Options{})
+	require.Nil(t, err)
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// Thesaurus made it before deadline, dictionary didn't
+	require.Len(t, got.Thesaurus, 1)
+	require.Empty(t, got.Dictionary.Defs)
+	require.Len(t, got.Errors, 1)
+	require.Equal(t, translator.SourceDictionary, got.Errors[0].Source)
+	require.Contains(t, got.Errors[0].Error, "deadline")
+}
+
+func TestStandard_Get_nonEnglish(t *testing.T) {
+	dict := &upstream{responses: map[string]string{"brain": `[{"meta":{"id":"brain"},"fl":"noun","shortdef":["organ"]}]`}}
+	th := &upstream{responses: map[string]string{"brain": `[{"meta":{"id":"brain","syns":[["mind"]]},"fl":"noun"}]`}}
+	tr := newStandard([]string{"mózg"}, dict, th, translator.Limits{})
+
+	// English source text is defined instead of translations
+	got, err := tr.Get(context.Background(), "brain", deepl.SrcEnglish, deepl.TarPolish, translator.Options{})
+	require.Nil(t, err)
+	require.Equal(t, translator.SideSource, got.Defined)
+	require.Equal(t, []translator.DeeplTranslate{{Text: "mózg"}}, got.Deepl)
+	require.Len(t, got.Dictionary.Defs, 1)
+	require.Equal(t, []string{"organ"}, got.Dictionary.Defs[0].Definition)
+	require.Len(t, got.Thesaurus, 1)
+	require.Equal(t, "brain", got.Thesaurus[0].Text)
+	require.Empty(t, got.Errors)
+
+	// Neither side has dictionary
+	dict, th = &upstream{}, &upstream{}
+	tr = newStandard([]string{"Gehirn"}, dict, th, translator.Limits{})
+	got, err = tr.Get(context.Background(), "mózg", deepl.SrcPolish, deepl.TarGerman, translator.Options{})
+	require.Nil(t, err)
+	require.Equal(t, &translator.Translation{Provider: translator.SourceDeepL, Deepl: []translator.DeeplTranslate{{Text: "Gehirn"}}}, got)
+	require.Zero(t, dict.max)
+	require.Zero(t, th.max)
+}
+
+func TestStandard_Get_dictionaries(t *testing.T) {
+	pl := translator.NewLocal(map[string]translator.LocalEntry{
+		"Mózg": {
+			Defs:      []translator.Definition{{Function: "rzeczownik", Definition: []string{"narząd"}}},
+			Thesaurus: []translator.ThesaurusTranslate{{Text: "mózg", Senses: []translator.ThesaurusSense{{Synonyms: []string{"umysł"}}}}},
+		},
+	})
+	dict := &upstream{responses: map[string]string{"brain": `[{"meta":{"id":"brain"},"fl":"noun","shortdef":["organ"]}]`}}
+	th := &upstream{responses: map[string]string{"brain": `[{"meta":{"id":"brain","syns":[["mind"]]},"fl":"noun"}]`}}
+	deepler := &fakeDeepler{texts: []string{"mózg", "móżdżek"}}
+	tr := translator.NewStandardWithDictionaries(
+		[]translator.Provider{translator.NewDeepLProvider(deepl.NewDeepL(deepler))},
+		map[translator.Lang]translator.Monolingual{
+			translator.English: translator.NewMerriamWebster(dictionary.NewDictionary(dict), thesaurus.NewThesaurus(th)),
+			"PL":               pl,
+		},
+		translator.Limits{},
+	)
+
+	// English side has the priority
+	got, err := tr.Get(context.Background(), "brain", deepl.SrcEnglish, deepl.TarPolish, translator.Options{})
+	require.Nil(t, err)
+	require.Equal(t, translator.SideSource, got.Defined)
+	require.Equal(t, []string{"organ"}, got.Dictionary.Defs[0].Definition)
+	require.Empty(t, got.Errors)
+
+	// Auto-detected source language is used as well
+	deepler.detected = string(deepl.SrcEnglish)
+	got, err = tr.Get(context.Background(), "brain", "", deepl.TarPolish, translator.Options{})
+	require.Nil(t, err)
+	require.Equal(t, translator.SideSource, got.Defined)
+	require.Equal(t, []string{"organ"}, got.Dictionary.Defs[0].Definition)
+	deepler.detected = ""
+
+	// Otherwise, target language has the priority
+	dict.max, th.max = 0, 0
+	got, err = tr.Get(context.Background(), "Gehirn", deepl.SrcGerman, deepl.TarPolish, translator.Options{})
+	require.Nil(t, err)
+	require.Equal(t, translator.SideTarget, got.Defined)
+	require.Equal(t, &translator.DictionaryTranslate{
+		Defs:     []translator.Definition{{Function: "rzeczownik", Definition: []string{"narząd"}}},
+		Synonyms: []string{},
+	}, got.Dictionary)
+	require.Equal(t, []string{"umysł"}, got.Thesaurus[0].Senses[0].Synonyms)
+	require.Empty(t, got.Errors)
+	require.Zero(t, dict.max)
+	require.Zero(t, th.max)
+
+	// Local is also used for source text
+	got, err = tr.Get(context.Background(), " MÓZG", deepl.SrcPolish, deepl.TarGerman, translator.Options{})
+	require.Nil(t, err)
+	require.Equal(t, translator.SideSource, got.Defined)
+	require.Len(t, got.Dictionary.Defs, 1)
+	require.Len(t, got.Thesaurus, 1)
+}
+
+func TestStandard_Get_cancelled(t *testing.T) {
+	dict := &upstream{delay: time.Second}
+	th := &upstream{delay: time.Second}
+	tr := newStandard([]string{"brain"}, dict, th, translator.Limits{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	got, err := tr.Get(ctx, "mózg", deepl.SrcPolish, deepl.TarEnglishAmerican, translator.Options{})
+	require.Nil(t, err)
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+	require.Len(t, got.Errors, 2)
+	for _, e := range got.Errors {
+		require.Contains(t, e.Error, context.Canceled.Error())
+	}
+
+	// Already cancelled context doesn't even reach DeepL
+	_, err = tr.Get(ctx, "mózg", deepl.SrcPolish, deepl.TarEnglishAmerican, translator.Options{})
+	require.NotNil(t, err)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestStandard_Get_suggestions(t *testing.T) {
+	dict := &upstream{
+		responses: map[string]string{
+			"brian": `["brain", "bran"]`,
+			"mnid":  `["mind", "brain"]`,
+			"brain": `[{"meta":{"id":"brain"},"fl":"noun","shortdef":["organ"]}]`,
+			"mind":  `[{"meta":{"id":"mind"},"fl":"noun","shortdef":["memory"]}]`,
+		},
+	}
+	th := &upstream{responses: map[string]string{"brian": `[]`, "mnid": `[]`}}
+	tr := newStandard([]string{"brian", "mnid"}, dict, th, translator.Limits{})
+
+	// Suggestions don't fail translation
+	got, err := tr.Get(context.Background(), "mózg", deepl.SrcPolish, deepl.TarEnglishAmerican, translator.Options{})
+	require.Nil(t, err)
+	require.Empty(t, got.Errors)
+	require.Empty(t, got.Dictionary.Defs)
+	require.True(t, got.DidYouMean)
+	require.Equal(t, []string{"brain", "bran", "mind"}, got.Suggestions)
+	require.Nil(t, got.Corrected)
+
+	// Top suggestion is defined instead
+	got, err = tr.Get(context.Background(), "mózg", deepl.SrcPolish, deepl.TarEnglishAmerican, translator.Options{Autocorrect: true})
+	require.Nil(t, err)
+	require.Empty(t, got.Errors)
+	require.Len(t, got.Dictionary.Defs, 2)
+	require.Equal(t, []string{"organ"}, got.Dictionary.Defs[0].Definition)
+	require.Equal(t, []string{"memory"}, got.Dictionary.Defs[1].Definition)
+	require.True(t, got.DidYouMean)
+	require.Equal(t, map[string]string{"brian": "brain", "mnid": "mind"}, got.Corrected)
+
+	// Known words don't have any suggestions
+	tr = newStandard([]string{"brain"}, dict, th, translator.Limits{})
+	got, err = tr.Get(context.Background(), "mózg", deepl.SrcPolish, deepl.TarEnglishAmerican, translator.Options{Autocorrect: true})
+	require.Nil(t, err)
+	require.False(t, got.DidYouMean)
+	require.Nil(t, got.Suggestions)
+	require.Nil(t, got.Corrected)
+}
+
+func TestStandard_Get_thesaurusSenses(t *testing.T) {
+	dict := &upstream{responses: map[string]string{"brain": `[]`}}
+	th := &upstream{
+		responses: map[string]string{
+			"brain": `[{
+				"meta": {"id": "brain", "syns": [["genius"], ["mind"]], "ants": [["dunce"]]},
+				"fl": "noun",
+				"def": [{"sseq": [
+					[["sense", {"sn": "1", "dt": [["text", "a very smart person"], ["vis", [{"t": "the {it}brain{/it} of the class"}]]],
+						"syn_list": [[{"wd": "genius"}]], "near_list": [[{"wd": "ignoramus"}]], "ant_list": [[{"wd": "dunce"}]]}]],
+					[["sense", {"sn": "2", "dt": [["text", "the part of a person that thinks"]],
+						"rel_list": [[{"wd": "psyche"}]], "phrase_list": [[{"wd": "gray matter"}]]}]]
+				]}],
+				"shortdef": ["a very smart person"]
+			}, {
+				"meta": {"id": "brain", "syns": [["bean"]]},
+				"fl": "verb",
+				"shortdef": ["to hit on the head"]
+			}, {
+				"meta": {"id": "brainy"},
+				"fl": "adjective"
+			}]`,
+		},
+	}
+	tr := newStandard([]string{"brain"}, dict, th, translator.Limits{})
+
+	got, err := tr.Get(context.Background(), "mózg", deepl.SrcPolish, deepl.TarEnglishAmerican, translator.Options{})
+	require.Nil(t, err)
+	require.Equal(t, []translator.ThesaurusTranslate{
+		{
+			Text:     "brain",
+			Function: "noun",
+			Senses: []translator.ThesaurusSense{
+				{
+					Definition:   "a very smart person",
+					Examples:     []string{"the brain of the class"},
+					Synonyms:     []string{"genius"},
+					Antonyms:     []string{"dunce"},
+					NearAntonyms: []string{"ignoramus"},
+				},
+				{
+					Definition: "the part of a person that thinks",
+					Synonyms:   []string{"mind"},
+					Related:    []string{"psyche"},
+					Phrases:    []string{"gray matter"},
+				},
+			},
+		},
+		{
+			Text:     "brain",
+			Function: "verb",
+			Senses:   []translator.ThesaurusSense{{Definition: "to hit on the head", Synonyms: []string{"bean"}}},
+		},
+	}, got.Thesaurus)
+}
+
+func TestLoadLocal(t *testing.T) {
+	l, err := translator.LoadLocal(strings.NewReader(`{"Mózg": {"defs": [{"function": "rzeczownik", "definition": ["narząd"]}], "synonyms": ["móżdżek"]}}`))
+	require.Nil(t, err)
+	dict, suggestions, err := l.Define(context.Background(), "mózg")
+	require.Nil(t, err)
+	require.Nil(t, suggestions)
+	require.Equal(t, []string{"narząd"}, dict.Defs[0].Definition)
+	require.Equal(t, []string{"móżdżek"}, dict.Synonyms)
+
+	// Unknown text is not an error
+	dict, _, err = l.Define(context.Background(), "serce")
+	require.Nil(t, err)
+	require.Empty(t, dict.Defs)
+
+	_, err = translator.LoadLocal(strings.NewReader(`["mózg"]`))
+	require.NotNil(t, err)
+}
@@ -5,16 +5,30 @@
 package translator
 
 import (
+	"context"
 	"github.com/a-clap/dictionary/internal/deepl"
 	"github.com/a-clap/dictionary/internal/merriamw/dictionary"
+	"github.com/a-clap/dictionary/internal/merriamw/sense"
 	"github.com/a-clap/dictionary/internal/merriamw/thesaurus"
+	"github.com/a-clap/dictionary/internal/mymemory"
 	"github.com/a-clap/logger"
+	"time"
 )
 
 var Logger logger.Logger = logger.NewNop()
 
 type Translate interface {
-	Get(text string, from deepl.SourceLang, to deepl.TargetLang) (*Translation, error)
+	Get(ctx context.Context, text string, from deepl.SourceLang, to deepl.TargetLang, opts Options) (*Translation, error)
+}
+
+// Options are optional parameters of Get
+type Options struct {
+	// Glossary is id of DeepL glossary for from-to pair, it is used only when DeepL translates
+	Glossary string
+	// User, on whose behalf text is translated, characters are counted against their Quota
+	User string
+	// Autocorrect defines the top suggestion instead, when dictionary doesn't know translated text
+	Autocorrect bool
 }
 
 type DeeplTranslate struct {
@@ -26,6 +40,15 @@ type Definition struct {
 	Examples   []string                   `json:"examples"`
 	Definition []string                   `json:"definition"`
 	Audio      []dictionary.Pronunciation `json:"audio"`
+	// Senses are full definitions, with markup stripped
+	Senses sense.Sseq `json:"senses,omitempty"`
+	// Etymology is origin of word, followed by supplemental notes
+	Etymology []string `json:"etymology"`
+	// FirstUse is date of the first known use of word, e.g. "before 12th century"
+	FirstUse    string             `json:"first_use"`
+	RunOns      []dictionary.RunOn `json:"run_ons"`
+	Inflections []string           `json:"inflections"`
+	Stems       []string           `json:"stems"`
 }
 
 type DictionaryTranslate struct {
@@ -33,149 +56,346 @@ type DictionaryTranslate struct {
 	Synonyms []string     `json:"synonyms"`
 }
 
+// ThesaurusTranslate is single thesaurus entry, e.g. "brain" as noun, with every its sense
 type ThesaurusTranslate struct {
-	Text       string   `json:"text"`
-	Synonyms   []string `json:"synonyms"`
-	Antonyms   []string `json:"antonyms"`
-	Offensive  bool     `json:"offensive"`
-	Function   string   `json:"function"`
-	Definition []string `json:"definition"`
+	Text      string           `json:"text"`
+	Offensive bool             `json:"offensive"`
+	Function  string           `json:"function"`
+	Senses    []ThesaurusSense `json:"senses"`
+}
+
+// ThesaurusSense is single meaning of thesaurus entry, with words similar and opposite to it
+type ThesaurusSense struct {
+	// Definition is short definition of sense
+	Definition   string   `json:"definition"`
+	Examples     []string `json:"examples,omitempty"`
+	Synonyms     []string `json:"synonyms"`
+	Antonyms     []string `json:"antonyms"`
+	NearAntonyms []string `json:"near_antonyms,omitempty"`
+	Related      []string `json:"related,omitempty"`
+	Phrases      []string `json:"phrases,omitempty"`
 }
 
-// Translation contains everything, what can be received from Translator
+// Source names part of Translation, which is looked up independently
+type Source string
+
+const (
+	SourceDeepL      Source = "deepl"
+	SourceMyMemory   Source = "mymemory"
+	SourceDictionary Source = "dictionary"
+	SourceThesaurus  Source = "thesaurus"
+)
+
+// SourceError describes failed lookup of Text in Source
+type SourceError struct {
+	Source Source `json:"source"`
+	Text   string `json:"text"`
+	Error  string `json:"error"`
+}
+
+// Translation contains everything, what can be received from Translator.
+// Failed lookups don't fail whole Translation, instead they are listed in Errors.
+// Deepl holds translations made by Provider, not necessarily by DeepL
 type Translation struct {
+	Provider   Source               `json:"provider"`
 	Deepl      []DeeplTranslate     `json:"deepl"`
 	Dictionary *DictionaryTranslate `json:"dictionary"`
 	Thesaurus  []ThesaurusTranslate `json:"thesaurus"`
+	// Alternatives are other possible translations, ranked from the best one
+	Alternatives []Alternative `json:"alternatives,omitempty"`
+	// Defined tells, which side of translation is described by Dictionary and Thesaurus, empty if none
+	Defined Side `json:"defined,omitempty"`
+	// DidYouMean is set, when dictionary didn't know some of translations and suggested other spellings
+	DidYouMean bool `json:"did_you_mean"`
+	// Suggestions are spellings suggested by dictionary, the best ones first
+	Suggestions []string `json:"suggestions,omitempty"`
+	// Corrected maps translations to suggestions, which were defined instead of them, see Options.Autocorrect
+	Corrected map[string]string `json:"corrected,omitempty"`
+	Errors    []SourceError     `json:"errors,omitempty"`
 }
 
+// Side of translation
+type Side string
+
+const (
+	// SideTarget are translations of text
+	SideTarget Side = "target"
+	// SideSource is translated text itself
+	SideSource Side = "source"
+)
+
 type Translator struct {
 	Translate
 }
 
+const (
+	// DefaultWorkers is number of concurrent dictionary and thesaurus lookups
+	DefaultWorkers = 4
+	// DefaultTimeout is overall deadline of single Get
+	DefaultTimeout = 10 * time.Second
+)
+
+// Limits configures fan-out of standard Translate
+type Limits struct {
+	// Workers is maximum number of lookups done at the same time, DefaultWorkers if not positive
+	Workers int
+	// Timeout is overall deadline for single Get, DefaultTimeout if not positive
+	Timeout time.Duration
+}
+
 type standard struct {
-	deepl     *deepl.DeepL
-	dict      *dictionary.Dictionary
-	thesaurus *thesaurus.Thesaurus
+	providers []Provider
+	// dictionaries are indexed with base language, e.g. "EN"
+	dictionaries map[Lang]Monolingual
+	limits       Limits
+}
+
+// lookup is single dictionary or thesaurus query for one of defined texts
+type lookup struct {
+	index  int
+	source Source
+}
+
+// lookupResult carries outcome of lookup back to Get
+type lookupResult struct {
+	lookup
+	dict        DictionaryTranslate
+	suggestions []string
+	thesaurus   []ThesaurusTranslate
+	err         error
+	// corrected is suggestion, which was defined instead of text
+	corrected string
 }
 
-func (s *standard) Get(text string, from deepl.SourceLang, to deepl.TargetLang) (*Translation, error) {
-	deeplTranslate, err := s.deepl.Translate(text, from, to)
+// Get translates text with first Provider, which succeeds, cancelling upstream calls when ctx is done or limits.Timeout passes
+func (s *standard) Get(ctx context.Context, text string, from deepl.SourceLang, to deepl.TargetLang, opts Options) (*Translation, error) {
+	ctx, cancel := context.WithTimeout(withUser(ctx, opts.User), s.limits.Timeout)
+	defer cancel()
+
+	translated, failures, err := translate(ctx, s.providers, text, Lang(from), Lang(to), opts)
 	if err != nil {
 		return nil, err
 	}
+	provider, texts := translated.provider.Name(), translated.texts
 
 	t := &Translation{
-		Deepl:      make([]DeeplTranslate, len(deeplTranslate.Translations)),
+		Provider:   provider,
+		Deepl:      make([]DeeplTranslate, len(texts)),
 		Dictionary: nil,
 		Thesaurus:  nil,
+		Errors:     failures,
+	}
+
+	for i, elem := range texts {
+		Logger.Infof("got translation %s from %s", elem, provider)
+		t.Deepl[i].Text = elem
 	}
 
-	for i, elem := range deeplTranslate.Translations {
-		Logger.Infof("got translation %s", elem.Translation())
-		t.Deepl[i].Text = elem.Translation()
+	// Alternatives are looked up meanwhile dictionary and thesaurus
+	var (
+		alt         []Alternative
+		altFailures []SourceError
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		alt, altFailures = alternatives(ctx, s.providers, text, Lang(from), Lang(to), translated)
+	}()
+
+	// Auto-detected source language is known only after translation
+	src := Lang(from)
+	if len(src) == 0 {
+		src = translated.detected
+	}
+	switch s.side(src, Lang(to)) {
+	case SideTarget:
+		t.Defined = SideTarget
+		s.define(ctx, t, s.dictionaries[Lang(to).Base()], texts, opts)
+	case SideSource:
+		t.Defined = SideSource
+		s.define(ctx, t, s.dictionaries[src.Base()], []string{text}, opts)
 	}
 
-	t.Dictionary = s.getDefinitions(to, &t.Deepl)
-	t.Thesaurus = s.getThesaurus(to, &t.Deepl)
+	<-done
+	t.Alternatives = alt
+	t.Errors = append(t.Errors, altFailures...)
 	return t, nil
 }
 
-func (s *standard) getDefinitions(to deepl.TargetLang, deeplTranslate *[]DeeplTranslate) *DictionaryTranslate {
-	// Currently supported only for english
-	if to != deepl.TarEnglishAmerican && to != deepl.TarEnglishBritish {
-		return nil
+// side returns side of from-to pair, which has dictionary, "" if none of them has.
+// English side is preferred, it is what Translation is mostly enriched with. Otherwise, translations are preferred over text
+func (s *standard) side(from, to Lang) Side {
+	_, hasTarget := s.dictionaries[to.Base()]
+	_, hasSource := s.dictionaries[from.Base()]
+	switch {
+	case hasTarget && to.Base() == English:
+		return SideTarget
+	case hasSource && from.Base() == English:
+		return SideSource
+	case hasTarget:
+		return SideTarget
+	case hasSource:
+		return SideSource
 	}
+	return ""
+}
 
-	dictTranslates := &DictionaryTranslate{
+// define fills Dictionary and Thesaurus of t with definitions of texts found in m
+func (s *standard) define(ctx context.Context, t *Translation, m Monolingual, texts []string, opts Options) {
+	results := s.lookups(ctx, m, texts, opts)
+
+	t.Dictionary = &DictionaryTranslate{
 		Defs:     []Definition{},
 		Synonyms: []string{},
 	}
-
-	for _, elem := range *deeplTranslate {
-		text := elem.Text
-		d, _, err := s.dict.Definition(text)
-		if err != nil || d == nil {
-			Logger.Debugf("definition not found")
-			continue
+	// Results are merged in order of texts, regardless of which lookup finished first
+	for i, text := range texts {
+		if r := results[lookup{index: i, source: SourceDictionary}]; r.err == nil {
+			t.Dictionary.Defs = append(t.Dictionary.Defs, r.dict.Defs...)
+			t.Dictionary.Synonyms = append(t.Dictionary.Synonyms, r.dict.Synonyms...)
+			t.suggest(text, r.suggestions, r.corrected)
+		} else {
+			t.Errors = append(t.Errors, SourceError{Source: SourceDictionary, Text: text, Error: r.err.Error()})
 		}
 
-		for _, dict := range d {
-			Logger.Debugf("definition for %s is %s", text, dict.Text())
-			if dict.Text() != text {
-				Logger.Debugf("skipping definition as it is not equal text, adding as synonym")
-				dictTranslates.Synonyms = append(dictTranslates.Synonyms, dict.Text())
-				continue
-			}
-			dictTranslate := Definition{
-				Offensive:  dict.IsOffensive(),
-				Function:   dict.Function(),
-				Examples:   dict.Examples(),
-				Definition: dict.Definition(),
-				Audio:      dict.Audio(),
-			}
-
-			dictTranslates.Defs = append(dictTranslates.Defs, dictTranslate)
+		if r := results[lookup{index: i, source: SourceThesaurus}]; r.err == nil {
+			t.Thesaurus = append(t.Thesaurus, r.thesaurus...)
+		} else {
+			t.Errors = append(t.Errors, SourceError{Source: SourceThesaurus, Text: text, Error: r.err.Error()})
 		}
 	}
-	return dictTranslates
 }
 
-func (s *standard) getThesaurus(to deepl.TargetLang, deeplTranslates *[]DeeplTranslate) []ThesaurusTranslate {
-	// Currently supported only for english
-	if to != deepl.TarEnglishAmerican && to != deepl.TarEnglishBritish {
-		return nil
+// suggest adds suggestions for text, which aren't listed yet
+func (t *Translation) suggest(text string, suggestions []string, corrected string) {
+	for _, elem := range suggestions {
+		known := false
+		for _, s := range t.Suggestions {
+			known = known || s == elem
+		}
+		if !known {
+			t.Suggestions = append(t.Suggestions, elem)
+		}
 	}
-	var th []ThesaurusTranslate
-	for _, elem := range *deeplTranslates {
-		text := elem.Text
-		data, err := s.thesaurus.Translate(text)
-		if err != nil {
-			Logger.Debugf("thesaurus not found for text %s", text)
-			continue
+	t.DidYouMean = len(t.Suggestions) > 0
+
+	if len(corrected) > 0 {
+		if t.Corrected == nil {
+			t.Corrected = make(map[string]string)
 		}
+		t.Corrected[text] = corrected
+	}
+}
 
-		for _, elem := range data {
-			if elem.Text() != text {
-				continue
-			}
+// lookups queries dictionary and thesaurus of m for each text, using at most limits.Workers goroutines.
+// When ctx is done before every lookup finishes, missing ones are reported with ctx.Err()
+func (s *standard) lookups(ctx context.Context, m Monolingual, texts []string, opts Options) map[lookup]lookupResult {
+	jobs := make(chan lookup)
+	// Buffered, so workers never block on send, even if nobody is listening anymore
+	done := make(chan lookupResult, 2*len(texts))
 
-			t := ThesaurusTranslate{
-				Text:       elem.Text(),
-				Synonyms:   nil,
-				Antonyms:   nil,
-				Offensive:  elem.IsOffensive(),
-				Function:   elem.Function(),
-				Definition: elem.Definition(),
+	workers := s.limits.Workers
+	if workers > 2*len(texts) {
+		workers = 2 * len(texts)
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for l := range jobs {
+				done <- s.lookup(ctx, m, l, texts[l.index], opts)
 			}
+		}()
+	}
 
-			if len(elem.Synonyms()) > 0 {
-				t.Synonyms = elem.Synonyms()[0]
+	go func() {
+		defer close(jobs)
+		for i := range texts {
+			for _, source := range []Source{SourceDictionary, SourceThesaurus} {
+				select {
+				case jobs <- lookup{index: i, source: source}:
+				case <-ctx.Done():
+					return
+				}
 			}
+		}
+	}()
 
-			if len(elem.Antonyms()) > 0 {
-				t.Antonyms = elem.Antonyms()[0]
+	results := make(map[lookup]lookupResult, 2*len(texts))
+	for len(results) < 2*len(texts) {
+		select {
+		case r := <-done:
+			results[r.lookup] = r
+		case <-ctx.Done():
+			Logger.Debugf("lookups interrupted: %v", ctx.Err())
+			for i := range texts {
+				for _, source := range []Source{SourceDictionary, SourceThesaurus} {
+					l := lookup{index: i, source: source}
+					if _, ok := results[l]; !ok {
+						results[l] = lookupResult{lookup: l, err: ctx.Err()}
+					}
+				}
 			}
-
-			th = append(th, t)
-			// Naive implementation - get just one Thesaurus for each elem.Text()
-			break
+			return results
 		}
+	}
+	return results
+}
 
+// lookup does single query, unless ctx is already done
+func (s *standard) lookup(ctx context.Context, m Monolingual, l lookup, text string, opts Options) lookupResult {
+	r := lookupResult{lookup: l}
+	if r.err = ctx.Err(); r.err != nil {
+		return r
 	}
 
-	return th
+	switch l.source {
+	case SourceDictionary:
+		r.dict, r.suggestions, r.err = m.Define(ctx, text)
+		if r.err == nil && len(r.dict.Defs) == 0 && len(r.suggestions) > 0 && opts.Autocorrect {
+			r.corrected = r.suggestions[0]
+			Logger.Debugf("%s not found, defining %s instead", text, r.corrected)
+			r.dict, _, r.err = m.Define(ctx, r.corrected)
+		}
+	case SourceThesaurus:
+		r.thesaurus, r.err = m.Thesaurus(ctx, text)
+	}
+	return r
 }
 
 func New(translate Translate) *Translator {
 	return &Translator{Translate: translate}
 }
 
+// NewStandard creates Translator using DeepL, with MyMemory as fallback
 func NewStandard(deeplKey, dictKey, thKey string) *Translator {
+	return NewStandardWith(
+		[]Provider{
+			NewDeepLProvider(deepl.NewDeepLDefault(deeplKey)),
+			NewMyMemoryProvider(mymemory.NewMyMemoryDefault("")),
+		},
+		dictionary.NewDictDefault(dictKey),
+		thesaurus.NewThesaurusDefault(thKey),
+		Limits{},
+	)
+}
+
+// NewStandardWith allows to use standard Translate with custom clients, e.g. cached ones.
+// Providers are asked in order, until one of them succeeds. Zero fields of limits are replaced with defaults
+func NewStandardWith(providers []Provider, dict *dictionary.Dictionary, th *thesaurus.Thesaurus, limits Limits) *Translator {
+	return NewStandardWithDictionaries(providers, map[Lang]Monolingual{English: NewMerriamWebster(dict, th)}, limits)
+}
+
+// NewStandardWithDictionaries is NewStandardWith, which uses dictionaries indexed with base language, e.g. "EN", instead of Merriam-Webster only
+func NewStandardWithDictionaries(providers []Provider, dictionaries map[Lang]Monolingual, limits Limits) *Translator {
+	if limits.Workers <= 0 {
+		limits.Workers = DefaultWorkers
+	}
+	if limits.Timeout <= 0 {
+		limits.Timeout = DefaultTimeout
+	}
 	standard := &standard{
-		deepl:     deepl.NewDeepLDefault(deeplKey),
-		dict:      dictionary.NewDictDefault(dictKey),
-		thesaurus: thesaurus.NewThesaurusDefault(thKey),
+		providers:    providers,
+		dictionaries: dictionaries,
+		limits:       limits,
 	}
 
 	return New(standard)
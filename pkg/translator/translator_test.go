@@ -5,6 +5,7 @@
 package translator_test
 
 import (
+	"context"
 	"github.com/a-clap/dictionary/internal/deepl"
 	"github.com/a-clap/dictionary/internal/merriamw/dictionary"
 	"github.com/a-clap/dictionary/pkg/translator"
@@ -58,6 +59,7 @@ func TestTranslator_Get(t *testing.T) {
 				to:   deepl.TarPolish,
 			},
 			want: &translator.Translation{
+				Provider: translator.SourceDeepL,
 				Deepl: []translator.DeeplTranslate{
 					{
 						Text: "mózg",
@@ -78,6 +80,7 @@ func TestTranslator_Get(t *testing.T) {
 				to:   deepl.TarEnglishBritish,
 			},
 			want: &translator.Translation{
+				Provider: translator.SourceDeepL,
 				Deepl: []translator.DeeplTranslate{
 					{
 						Text: "brain",
@@ -98,6 +101,7 @@ func TestTranslator_Get(t *testing.T) {
 								{
 									PhoneticNotation: "ˈbrān",
 									Url:              "https://media.merriam-webster.com/audio/prons/en/us/mp3/b/brain001.mp3",
+									Filename:         "brain001",
 								},
 							},
 						},
@@ -125,42 +129,44 @@ func TestTranslator_Get(t *testing.T) {
 				},
 				Thesaurus: []translator.ThesaurusTranslate{
 					{
-						Text: "brain",
-						Synonyms: []string{
-							"brainiac",
-							"genius",
-							"intellect",
-							"thinker",
-							"whiz",
-							"wiz",
-							"wizard",
-						},
-						Antonyms: []string{
-							"blockhead",
-							"dodo",
-							"dolt",
-							"dope",
-							"dumbbell",
-							"dummy",
-							"dunce",
-							"fathead",
-							"goon",
-							"half-wit",
-							"hammerhead",
-							"idiot",
-							"imbecile",
-							"knucklehead",
-							"moron",
-							"nitwit",
-							"numskull",
-							"pinhead",
-						},
+						Text:      "brain",
 						Offensive: false,
 						Function:  "noun",
-						Definition: []string{
-							"a very smart person",
-							"the ability to learn and understand or to deal with problems",
-							"the part of a person that feels, thinks, perceives, wills, and especially reasons",
+						Senses: []translator.ThesaurusSense{
+							{
+								Definition: "a very smart person",
+								Synonyms: []string{
+									"brainiac",
+									"genius",
+									"intellect",
+									"thinker",
+									"whiz",
+									"wiz",
+									"wizard",
+								},
+								Antonyms: []string{
+									"blockhead",
+									"dodo",
+									"dolt",
+									"dope",
+									"dumbbell",
+									"dummy",
+									"dunce",
+									"fathead",
+									"goon",
+									"half-wit",
+									"hammerhead",
+									"idiot",
+									"imbecile",
+									"knucklehead",
+									"moron",
+									"nitwit",
+									"numskull",
+									"pinhead",
+								},
+							},
+							{Definition: "the ability to learn and understand or to deal with problems"},
+							{Definition: "the part of a person that feels, thinks, perceives, wills, and especially reasons"},
 						},
 					},
 				},
@@ -173,7 +179,7 @@ func TestTranslator_Get(t *testing.T) {
 			req := require.New(t)
 			translate := tt.fields.translate
 
-			got, err := translate.Get(tt.args.text, tt.args.from, tt.args.to)
+			got, err := translate.Get(context.Background(), tt.args.text, tt.args.from, tt.args.to, translator.Options{})
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Get() error = %v, wantErr %v", err, tt.wantErr)
@@ -187,7 +193,20 @@ func TestTranslator_Get(t *testing.T) {
 
 			req.Equal(got.Deepl, tt.want.Deepl, "got = %#v, want %#v", got.Deepl, tt.want.Deepl)
 			req.Equal(got.Dictionary, tt.want.Dictionary, "got = %#v, want %#v", got.Dictionary, tt.want.Dictionary)
-			req.Equal(got.Thesaurus, tt.want.Thesaurus, "got = %#v, want %#v", got.Thesaurus, tt.want.Thesaurus)
+			// Thesaurus may have more entries and words than listed, only definitions and words of the first sense are compared
+			req.GreaterOrEqual(len(got.Thesaurus), len(tt.want.Thesaurus))
+			for i, want := range tt.want.Thesaurus {
+				th := got.Thesaurus[i]
+				req.Equal(want.Text, th.Text)
+				req.Equal(want.Offensive, th.Offensive)
+				req.Equal(want.Function, th.Function)
+				req.Len(th.Senses, len(want.Senses))
+				for j, sense := range want.Senses {
+					req.Equal(sense.Definition, th.Senses[j].Definition)
+				}
+				req.Equal(want.Senses[0].Synonyms, th.Senses[0].Synonyms)
+				req.Equal(want.Senses[0].Antonyms, th.Senses[0].Antonyms)
+			}
 		})
 	}
 }
@@ -0,0 +1,48 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package usage
+
+import (
+	"sync"
+)
+
+var _ UsageStore = &MemoryStore{}
+
+// MemoryStore satisfies UsageStore interface
+type MemoryStore struct {
+	mtx        sync.Mutex
+	characters map[string]map[string]int64
+}
+
+// NewMemoryStore is default constructor for MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		characters: map[string]map[string]int64{},
+	}
+}
+
+// AddCharacters increases number of characters sent by user in period
+func (m *MemoryStore) AddCharacters(user, period string, n int64) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.characters[period]; !ok {
+		m.characters[period] = map[string]int64{}
+	}
+	m.characters[period][user] += n
+	return nil
+}
+
+// LoadCharacters returns number of characters sent in period, by every user
+func (m *MemoryStore) LoadCharacters(period string) (map[string]int64, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	used := make(map[string]int64, len(m.characters[period]))
+	for user, n := range m.characters[period] {
+		used[user] = n
+	}
+	return used, nil
+}
@@ -0,0 +1,203 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/logger"
+	"sync"
+	"time"
+)
+
+var Logger logger.Logger = logger.NewNop()
+
+var (
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrInvalid       = errors.New("invalid argument")
+	ErrIO            = errors.New("io error")
+)
+
+// periodLayout formats period, in which characters are counted. DeepL bills characters monthly
+const periodLayout = "2006-01"
+
+// Limits are maximum numbers of characters, which can be sent to DeepL within single period. Zero means no limit
+type Limits struct {
+	// User is limit of every single user
+	User int64 `json:"user"`
+	// Global is limit of all users together
+	Global int64 `json:"global"`
+}
+
+// UsageStore realizes access to characters counted per user and period.
+// Errors returned by interface should be ONLY related to internal IO errors
+type UsageStore interface {
+	// AddCharacters increases number of characters sent by user in period, n is negative when characters are refunded
+	AddCharacters(user, period string, n int64) error
+	// LoadCharacters returns number of characters sent in period, by every user
+	LoadCharacters(period string) (map[string]int64, error)
+}
+
+// Reporter summarizes usage, satisfied by Tracker
+type Reporter interface {
+	Report(ctx context.Context) (*Report, error)
+}
+
+// Report is usage in current period, counted locally and reported by DeepL
+type Report struct {
+	Period string           `json:"period"`
+	Limits Limits           `json:"limits"`
+	Users  map[string]int64 `json:"users"`
+	Total  int64            `json:"total"`
+	DeepL  *deepl.Usage     `json:"deepl,omitempty"`
+	// Error tells, why DeepL is missing
+	Error string `json:"error,omitempty"`
+}
+
+var _ Reporter = &Tracker{}
+
+// Tracker counts characters sent by users to DeepL and checks them against Limits
+type Tracker struct {
+	s      UsageStore
+	c      deepl.Usager
+	limits Limits
+	now    func() time.Time
+	// mtx makes check and count of Reserve atomic, also against Add
+	mtx sync.Mutex
+}
+
+// New is default constructor for Tracker
+func New(store UsageStore, client deepl.Usager, limits Limits) *Tracker {
+	return &Tracker{
+		s:      store,
+		c:      client,
+		limits: limits,
+		now:    time.Now,
+	}
+}
+
+// SetClock replaces source of current time, useful for testing
+func (t *Tracker) SetClock(now func() time.Time) {
+	t.now = now
+}
+
+// Allow returns error wrapping ErrQuotaExceeded, if sending chars characters by user would exceed any of Limits.
+// Use Reserve, if characters are about to be sent, concurrent Allow and Add may exceed Limits
+func (t *Tracker) Allow(user string, chars int) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.allow(user, chars)
+}
+
+// Add counts chars characters sent by user in current period
+func (t *Tracker) Add(user string, chars int) error {
+	if chars < 0 {
+		return fmt.Errorf("%w: negative number of characters", ErrInvalid)
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.add(user, int64(chars))
+}
+
+// Reserve counts chars characters, which user is about to send, if they don't exceed any of Limits.
+// Otherwise, returns error wrapping ErrQuotaExceeded and nothing is counted. Use Refund, if characters weren't sent after all
+func (t *Tracker) Reserve(user string, chars int) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if err := t.allow(user, chars); err != nil {
+		return err
+	}
+	return t.add(user, int64(chars))
+}
+
+// Refund gives back chars characters reserved by user
+func (t *Tracker) Refund(user string, chars int) error {
+	if chars < 0 {
+		return fmt.Errorf("%w: negative number of characters", ErrInvalid)
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.add(user, -int64(chars))
+}
+
+// allow is Allow, caller must hold t.mtx
+func (t *Tracker) allow(user string, chars int) error {
+	if chars < 0 {
+		return fmt.Errorf("%w: negative number of characters", ErrInvalid)
+	}
+	if t.limits.User <= 0 && t.limits.Global <= 0 {
+		return nil
+	}
+
+	period := t.period()
+	used, err := t.load(period)
+	if err != nil {
+		return err
+	}
+
+	n := int64(chars)
+	if t.limits.User > 0 && used[user]+n > t.limits.User {
+		return fmt.Errorf("%w: user %s used %d of %d characters in %s", ErrQuotaExceeded, user, used[user], t.limits.User, period)
+	}
+	if t.limits.Global > 0 && total(used)+n > t.limits.Global {
+		return fmt.Errorf("%w: used %d of %d characters in %s", ErrQuotaExceeded, total(used), t.limits.Global, period)
+	}
+	return nil
+}
+
+// Report returns usage in current period. Failure to get usage from DeepL isn't an error, it is described in Report
+func (t *Tracker) Report(ctx context.Context) (*Report, error) {
+	period := t.period()
+	used, err := t.load(period)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{
+		Period: period,
+		Limits: t.limits,
+		Users:  used,
+		Total:  total(used),
+	}
+	if r.DeepL, err = t.c.Usage(ctx); err != nil {
+		Logger.Errorf("failed to get DeepL usage: %v", err)
+		r.Error = err.Error()
+	}
+	return r, nil
+}
+
+// add is wrapper for interface call AddCharacters in current period, returns appropriate wrapped error
+func (t *Tracker) add(user string, n int64) error {
+	if err := t.s.AddCharacters(user, t.period(), n); err != nil {
+		return fmt.Errorf("%w: AddCharacters: user %s, error: %v", ErrIO, user, err)
+	}
+	return nil
+}
+
+func (t *Tracker) period() string {
+	return t.now().UTC().Format(periodLayout)
+}
+
+// load is wrapper for interface call LoadCharacters, returns appropriate wrapped error
+func (t *Tracker) load(period string) (map[string]int64, error) {
+	used, err := t.s.LoadCharacters(period)
+	if err != nil {
+		return nil, fmt.Errorf("%w: LoadCharacters: period %s, error: %v", ErrIO, period, err)
+	}
+	if used == nil {
+		used = map[string]int64{}
+	}
+	return used, nil
+}
+
+func total(used map[string]int64) int64 {
+	var sum int64
+	for _, n := range used {
+		sum += n
+	}
+	return sum
+}
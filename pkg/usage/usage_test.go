@@ -0,0 +1,129 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package usage_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/pkg/usage"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+var _ usage.UsageStore = errStore{}
+
+type errStore struct {
+}
+
+func (e errStore) AddCharacters(_, _ string, _ int64) error {
+	return fmt.Errorf("io err")
+}
+
+func (e errStore) LoadCharacters(_ string) (map[string]int64, error) {
+	return nil, fmt.Errorf("io err")
+}
+
+// fakeUsager returns usage or err
+type fakeUsager struct {
+	usage *deepl.Usage
+	err   error
+}
+
+func (f *fakeUsager) Usage(_ context.Context) (*deepl.Usage, error) {
+	return f.usage, f.err
+}
+
+func TestTracker_Allow(t *testing.T) {
+	now := time.Date(2022, 9, 30, 12, 0, 0, 0, time.UTC)
+	tr := usage.New(usage.NewMemoryStore(), &fakeUsager{}, usage.Limits{User: 10, Global: 15})
+	tr.SetClock(func() time.Time { return now })
+
+	require.Nil(t, tr.Allow("adam", 10))
+	require.Nil(t, tr.Add("adam", 8))
+	require.ErrorIs(t, tr.Allow("adam", 3), usage.ErrQuotaExceeded)
+	require.Nil(t, tr.Allow("adam", 2))
+
+	// Global limit is shared by every user
+	require.Nil(t, tr.Add("eve", 5))
+	require.ErrorIs(t, tr.Allow("eve", 3), usage.ErrQuotaExceeded)
+	require.Nil(t, tr.Allow("eve", 2))
+
+	require.ErrorIs(t, tr.Allow("eve", -1), usage.ErrInvalid)
+	require.ErrorIs(t, tr.Add("eve", -1), usage.ErrInvalid)
+
+	// Counting starts again in next month
+	now = now.Add(24 * time.Hour)
+	require.Nil(t, tr.Allow("adam", 10))
+}
+
+func TestTracker_Reserve(t *testing.T) {
+	tr := usage.New(usage.NewMemoryStore(), &fakeUsager{}, usage.Limits{User: 10})
+
+	// Concurrent reservations never exceed limit
+	const workers = 20
+	var wg sync.WaitGroup
+	reserved := make(chan struct{}, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := tr.Reserve("adam", 1); err == nil {
+				reserved <- struct{}{}
+			} else {
+				require.ErrorIs(t, err, usage.ErrQuotaExceeded)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Len(t, reserved, 10)
+
+	// Refunded characters can be reserved again
+	require.Nil(t, tr.Refund("adam", 3))
+	require.Nil(t, tr.Reserve("adam", 3))
+	require.ErrorIs(t, tr.Reserve("adam", 1), usage.ErrQuotaExceeded)
+
+	require.ErrorIs(t, tr.Reserve("adam", -1), usage.ErrInvalid)
+	require.ErrorIs(t, tr.Refund("adam", -1), usage.ErrInvalid)
+}
+
+func TestTracker_Unlimited(t *testing.T) {
+	tr := usage.New(errStore{}, &fakeUsager{}, usage.Limits{})
+	require.Nil(t, tr.Allow("adam", 1000000))
+	require.ErrorIs(t, tr.Add("adam", 1), usage.ErrIO)
+}
+
+func TestTracker_Report(t *testing.T) {
+	client := &fakeUsager{usage: &deepl.Usage{CharacterCount: 100, CharacterLimit: 500000}}
+	tr := usage.New(usage.NewMemoryStore(), client, usage.Limits{User: 1000})
+	tr.SetClock(func() time.Time { return time.Date(2022, 9, 30, 12, 0, 0, 0, time.UTC) })
+
+	require.Nil(t, tr.Add("adam", 8))
+	require.Nil(t, tr.Add("eve", 5))
+	require.Nil(t, tr.Add("adam", 2))
+
+	r, err := tr.Report(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, &usage.Report{
+		Period: "2022-09",
+		Limits: usage.Limits{User: 1000},
+		Users:  map[string]int64{"adam": 10, "eve": 5},
+		Total:  15,
+		DeepL:  client.usage,
+	}, r)
+
+	// DeepL failure is only reported
+	client.usage, client.err = nil, fmt.Errorf("deepl is down")
+	r, err = tr.Report(context.Background())
+	require.Nil(t, err)
+	require.Nil(t, r.DeepL)
+	require.Equal(t, "deepl is down", r.Error)
+
+	_, err = usage.New(errStore{}, client, usage.Limits{}).Report(context.Background())
+	require.ErrorIs(t, err, usage.ErrIO)
+	require.ErrorIs(t, usage.New(errStore{}, client, usage.Limits{User: 1}).Allow("adam", 1), usage.ErrIO)
+}
@@ -0,0 +1,69 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package wordlist
+
+import (
+	"sync"
+)
+
+var _ WordStore = &MemoryStore{}
+
+// MemoryStore satisfies WordStore interface
+type MemoryStore struct {
+	mtx   sync.Mutex
+	words map[string]map[string]Word
+}
+
+// NewMemoryStore is default constructor for MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		words: map[string]map[string]Word{},
+	}
+}
+
+// SaveWord saves word for user
+func (m *MemoryStore) SaveWord(user string, word Word) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.words[user]; !ok {
+		m.words[user] = map[string]Word{}
+	}
+	m.words[user][word.ID] = word
+	return nil
+}
+
+// LoadWord returns word with provided id, nil if it doesn't exist
+func (m *MemoryStore) LoadWord(user string, id string) (*Word, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	word, ok := m.words[user][id]
+	if !ok {
+		return nil, nil
+	}
+	return &word, nil
+}
+
+// LoadWords returns every word of user
+func (m *MemoryStore) LoadWords(user string) ([]Word, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	words := make([]Word, 0, len(m.words[user]))
+	for _, elem := range m.words[user] {
+		words = append(words, elem)
+	}
+	return words, nil
+}
+
+// RemoveWord removes word with provided id
+func (m *MemoryStore) RemoveWord(user string, id string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	delete(m.words[user], id)
+	return nil
+}
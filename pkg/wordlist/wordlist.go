@@ -0,0 +1,155 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package wordlist
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/pkg/translator"
+	"sort"
+	"time"
+)
+
+var (
+	ErrNotExist = errors.New("word doesn't exist")
+	ErrInvalid  = errors.New("invalid argument")
+	ErrIO       = errors.New("io error")
+)
+
+// Word is single entry in user's personal dictionary
+type Word struct {
+	ID          string                  `json:"id"`
+	Text        string                  `json:"text"`
+	From        deepl.SourceLang        `json:"from"`
+	To          deepl.TargetLang        `json:"to"`
+	Translation *translator.Translation `json:"translation"`
+	Created     time.Time               `json:"created"`
+	Updated     time.Time               `json:"updated"`
+}
+
+// WordStore realizes access to users words.
+// Errors returned by interface should be ONLY related to internal IO errors
+type WordStore interface {
+	// SaveWord saves word for user. Overwrites, if word with the same ID already exists
+	SaveWord(user string, word Word) error
+	// LoadWord returns word with provided id, nil if word doesn't exist
+	LoadWord(user string, id string) (*Word, error)
+	// LoadWords returns every word of user, in any order
+	LoadWords(user string) ([]Word, error)
+	// RemoveWord removes word with provided id, if word doesn't exist, don't do anything
+	RemoveWord(user string, id string) error
+}
+
+// WordList manages users words on top of WordStore
+type WordList struct {
+	s WordStore
+}
+
+// New is default constructor for WordList
+func New(store WordStore) *WordList {
+	return &WordList{s: store}
+}
+
+// Add saves new word for user, returns it with assigned ID
+func (w *WordList) Add(user string, word Word) (*Word, error) {
+	if len(user) == 0 || len(word.Text) == 0 {
+		return nil, fmt.Errorf("%w: user and text must be provided", ErrInvalid)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	word.ID = id
+	word.Created = time.Now()
+	word.Updated = word.Created
+
+	if err := w.save(user, word); err != nil {
+		return nil, err
+	}
+	return &word, nil
+}
+
+// Words returns every word of user, sorted from the oldest one
+func (w *WordList) Words(user string) ([]Word, error) {
+	words, err := w.s.LoadWords(user)
+	if err != nil {
+		return nil, fmt.Errorf("%w: LoadWords: user %s, error: %v", ErrIO, user, err)
+	}
+	if words == nil {
+		words = []Word{}
+	}
+
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].Created.Equal(words[j].Created) {
+			return words[i].ID < words[j].ID
+		}
+		return words[i].Created.Before(words[j].Created)
+	})
+	return words, nil
+}
+
+// Word returns user's word with provided id
+func (w *WordList) Word(user string, id string) (*Word, error) {
+	word, err := w.s.LoadWord(user, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: LoadWord: user %s, id %s, error: %v", ErrIO, user, id, err)
+	}
+	if word == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotExist, id)
+	}
+	return word, nil
+}
+
+// Update overwrites already existing word, word.ID must be set
+func (w *WordList) Update(user string, word Word) (*Word, error) {
+	if len(word.Text) == 0 {
+		return nil, fmt.Errorf("%w: text must be provided", ErrInvalid)
+	}
+
+	old, err := w.Word(user, word.ID)
+	if err != nil {
+		return nil, err
+	}
+	word.Created = old.Created
+	word.Updated = time.Now()
+
+	if err := w.save(user, word); err != nil {
+		return nil, err
+	}
+	return &word, nil
+}
+
+// Remove removes user's word with provided id
+func (w *WordList) Remove(user string, id string) error {
+	if _, err := w.Word(user, id); err != nil {
+		return err
+	}
+
+	if err := w.s.RemoveWord(user, id); err != nil {
+		return fmt.Errorf("%w: RemoveWord: user %s, id %s, error: %v", ErrIO, user, id, err)
+	}
+	return nil
+}
+
+// save is wrapper for interface call SaveWord, returns appropriate wrapped error
+func (w *WordList) save(user string, word Word) error {
+	if err := w.s.SaveWord(user, word); err != nil {
+		return fmt.Errorf("%w: SaveWord: user %s, id %s, error: %v", ErrIO, user, word.ID, err)
+	}
+	return nil
+}
+
+// newID returns random, hex encoded identifier
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: rand.Read: %v", ErrIO, err)
+	}
+	return hex.EncodeToString(b), nil
+}
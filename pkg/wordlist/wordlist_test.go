@@ -0,0 +1,152 @@
+//  Copyright 2022 a-clap. All rights reserved.
+//  Use of this source code is governed by a MIT-style
+//  license that can be found in the LICENSE file.
+
+package wordlist_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/a-clap/dictionary/internal/deepl"
+	"github.com/a-clap/dictionary/pkg/wordlist"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var _ wordlist.WordStore = &errStore{}
+
+type errStore struct {
+}
+
+func (e errStore) SaveWord(_ string, _ wordlist.Word) error {
+	return fmt.Errorf("io err")
+}
+
+func (e errStore) LoadWord(_ string, _ string) (*wordlist.Word, error) {
+	return nil, fmt.Errorf("io err")
+}
+
+func (e errStore) LoadWords(_ string) ([]wordlist.Word, error) {
+	return nil, fmt.Errorf("io err")
+}
+
+func (e errStore) RemoveWord(_ string, _ string) error {
+	return fmt.Errorf("io err")
+}
+
+func TestWordList_Add(t *testing.T) {
+	tests := []struct {
+		name    string
+		store   wordlist.WordStore
+		user    string
+		word    wordlist.Word
+		errType error
+	}{
+		{
+			name:    "add word",
+			store:   wordlist.NewMemoryStore(),
+			user:    "adam",
+			word:    wordlist.Word{Text: "brain", From: deepl.SrcEnglish, To: deepl.TarPolish},
+			errType: nil,
+		},
+		{
+			name:    "empty text",
+			store:   wordlist.NewMemoryStore(),
+			user:    "adam",
+			word:    wordlist.Word{Text: ""},
+			errType: wordlist.ErrInvalid,
+		},
+		{
+			name:    "empty user",
+			store:   wordlist.NewMemoryStore(),
+			user:    "",
+			word:    wordlist.Word{Text: "brain"},
+			errType: wordlist.ErrInvalid,
+		},
+		{
+			name:    "handle io error",
+			store:   errStore{},
+			user:    "adam",
+			word:    wordlist.Word{Text: "brain"},
+			errType: wordlist.ErrIO,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := wordlist.New(tt.store)
+			got, err := w.Add(tt.user, tt.word)
+			if tt.errType != nil {
+				require.True(t, errors.Is(err, tt.errType), "got %v, want %v", err, tt.errType)
+				require.Nil(t, got)
+				return
+			}
+			require.Nil(t, err)
+			require.NotEmpty(t, got.ID)
+			require.Equal(t, tt.word.Text, got.Text)
+			require.False(t, got.Created.IsZero())
+
+			stored, err := w.Word(tt.user, got.ID)
+			require.Nil(t, err)
+			require.Equal(t, got, stored)
+		})
+	}
+}
+
+func TestWordList_UserScope(t *testing.T) {
+	w := wordlist.New(wordlist.NewMemoryStore())
+
+	adams := []string{"brain", "world", "learn"}
+	ids := make([]string, len(adams))
+	for i, text := range adams {
+		got, err := w.Add("adam", wordlist.Word{Text: text})
+		require.Nil(t, err)
+		ids[i] = got.ID
+	}
+	_, err := w.Add("eve", wordlist.Word{Text: "apple"})
+	require.Nil(t, err)
+
+	t.Run("list only own words, sorted", func(t *testing.T) {
+		words, err := w.Words("adam")
+		require.Nil(t, err)
+		require.Len(t, words, len(adams))
+		for i, elem := range words {
+			require.Equal(t, adams[i], elem.Text)
+		}
+	})
+
+	t.Run("can't access words of other user", func(t *testing.T) {
+		_, err := w.Word("eve", ids[0])
+		require.True(t, errors.Is(err, wordlist.ErrNotExist))
+		require.True(t, errors.Is(w.Remove("eve", ids[0]), wordlist.ErrNotExist))
+		_, err = w.Update("eve", wordlist.Word{ID: ids[0], Text: "brains"})
+		require.True(t, errors.Is(err, wordlist.ErrNotExist))
+	})
+
+	t.Run("update keeps creation time", func(t *testing.T) {
+		old, err := w.Word("adam", ids[0])
+		require.Nil(t, err)
+
+		updated, err := w.Update("adam", wordlist.Word{ID: ids[0], Text: "brains"})
+		require.Nil(t, err)
+		require.Equal(t, "brains", updated.Text)
+		require.Equal(t, old.Created, updated.Created)
+		require.False(t, updated.Updated.Before(old.Updated))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		require.Nil(t, w.Remove("adam", ids[1]))
+		_, err := w.Word("adam", ids[1])
+		require.True(t, errors.Is(err, wordlist.ErrNotExist))
+
+		words, err := w.Words("adam")
+		require.Nil(t, err)
+		require.Len(t, words, len(adams)-1)
+	})
+
+	t.Run("no words", func(t *testing.T) {
+		words, err := w.Words("nobody")
+		require.Nil(t, err)
+		require.NotNil(t, words)
+		require.Empty(t, words)
+	})
+}